@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/demeero/pocket-link/keygen/audit"
+	"github.com/demeero/pocket-link/keygen/key"
+)
+
+// runAudit checks used keys of the pool against links of the links service and repairs mismatches if it's configured.
+func runAudit(ctx context.Context, cfg config) error {
+	if cfg.UsedKeysRepositoryType == UsedKeysRepositoryTypeBolt {
+		return fmt.Errorf("audit of embedded used keys isn't supported: the database is locked by the running service")
+	}
+	newUsedRepo, err := usedKeysRepoFactory(ctx, cfg, nil)
+	if err != nil {
+		return fmt.Errorf("failed create used keys repository: %w", err)
+	}
+	usedRepo, err := newUsedRepo(poolNamespace(cfg.Audit.Pool))
+	if err != nil {
+		return fmt.Errorf("failed create used keys repository of pool %s: %w", cfg.Audit.Pool, err)
+	}
+	keys, ok := usedRepo.(audit.KeyStore)
+	if !ok {
+		return fmt.Errorf("audit of used keys isn't supported by the repository type: %s", cfg.UsedKeysRepositoryType)
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, cfg.Audit.LinksMongo.InitialConnectTimeout)
+	defer cancel()
+	client, err := mongo.Connect(connectCtx, options.Client().ApplyURI(cfg.Audit.LinksMongo.URI))
+	if err != nil {
+		return fmt.Errorf("failed connect to MongoDB of links: %w", err)
+	}
+	defer func() {
+		if err := client.Disconnect(context.Background()); err != nil {
+			slog.Error("failed disconnect from MongoDB of links", slog.Any("err", err))
+		}
+	}()
+	links := audit.NewMongoLinks(client.Database("pocket-link"))
+
+	if !cfg.Audit.Repair {
+		slog.Info("audit runs in dry-run mode - mismatches are only reported")
+	}
+	// links don't record the pool of their keys, so a link without a key of another pool may have a key of the default one
+	skipMissing := cfg.Audit.Pool != key.DefaultPool
+	if skipMissing {
+		slog.Info("missing keys are checked only in the default pool", slog.String("pool", cfg.Audit.Pool))
+	}
+	report, err := audit.New(keys, links, audit.Config{
+		PageSize:        cfg.Audit.PageSize,
+		Grace:           cfg.Audit.Grace,
+		Tolerance:       cfg.Audit.Tolerance,
+		Repair:          cfg.Audit.Repair,
+		SkipMissingKeys: skipMissing,
+	}).Run(ctx)
+	if err != nil {
+		return err
+	}
+	kinds := map[audit.Kind]int{}
+	var repaired int
+	for _, m := range report.Mismatches {
+		kinds[m.Kind]++
+		if m.Repaired {
+			repaired++
+		}
+	}
+	slog.Info("audit completed",
+		slog.Int64("keys_scanned", report.KeysScanned),
+		slog.Int64("links_scanned", report.LinksScanned),
+		slog.Int("orphaned_keys", kinds[audit.KindOrphanedKey]),
+		slog.Int("missing_keys", kinds[audit.KindMissingKey]),
+		slog.Int("expiry_mismatches", kinds[audit.KindExpiry]),
+		slog.Int("repaired", repaired))
+	return nil
+}
@@ -0,0 +1,251 @@
+// Package audit finds mismatches between used keys and links that are created with them.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// KeyStore is a store of used keys.
+type KeyStore interface {
+	// Scan calls fn with pages of used keys that haven't expired until all keys are scanned or fn returns an error.
+	// A page maps keys to their expiration times. The expiration time is zero if the key never expires.
+	// A key may be passed more than once if the store is modified during the scan.
+	Scan(ctx context.Context, pageSize int64, fn func(map[string]time.Time) error) error
+	// ExpiresAt returns expiration times of the used keys. Keys that aren't used are missing in the result.
+	ExpiresAt(ctx context.Context, keys []string) (map[string]time.Time, error)
+	Store(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	Delete(ctx context.Context, key string) (bool, error)
+	Extend(ctx context.Context, key string, expiresAt time.Time) (bool, error)
+}
+
+// LinkStore is a store of links. Links are identified by their keys.
+type LinkStore interface {
+	// Scan calls fn with pages of links that haven't expired until all links are scanned or fn returns an error.
+	// A page maps keys of links to their expiration times.
+	Scan(ctx context.Context, pageSize int64, fn func(map[string]time.Time) error) error
+	// ExpiresAt returns expiration times of links with the keys. Missing links are missing in the result.
+	ExpiresAt(ctx context.Context, keys []string) (map[string]time.Time, error)
+}
+
+// Kind is a kind of mismatch.
+type Kind string
+
+const (
+	// KindOrphanedKey is a used key without a link. The repair releases the key.
+	KindOrphanedKey Kind = "orphaned_key"
+	// KindMissingKey is a link without a used key, so the key can be issued again. The repair stores the key as used.
+	KindMissingKey Kind = "missing_key"
+	// KindExpiry is a key that expires at a different time than its link. The repair sets the key expiration to the link.
+	KindExpiry Kind = "expiry"
+)
+
+// Mismatch is a key that doesn't match its link.
+type Mismatch struct {
+	// KeyExpAt is an expiration time of the used key. It's zero if the key is missing.
+	KeyExpAt time.Time
+	// LinkExpAt is an expiration time of the link. It's zero if the link is missing.
+	LinkExpAt time.Time
+	Key       string
+	Kind      Kind
+	// Repaired reports whether the mismatch has been repaired.
+	Repaired bool
+}
+
+// Report is a result of the audit.
+type Report struct {
+	Mismatches   []Mismatch
+	KeysScanned  int64
+	LinksScanned int64
+}
+
+const (
+	// DefaultPageSize is a default number of keys and links that are checked in a single round trip.
+	DefaultPageSize = 500
+	// DefaultGrace is a default time before expiration of keys that aren't reported as orphaned.
+	DefaultGrace = 2 * time.Minute
+	// DefaultTolerance is a default difference of expiration times that isn't reported.
+	DefaultTolerance = time.Second
+)
+
+// Config is a configuration of Auditor.
+type Config struct {
+	// PageSize is a number of keys and links that are checked in a single round trip.
+	PageSize int64
+	// Grace skips orphaned keys that expire within it. Such keys may be held by clients that haven't saved the link yet
+	// (e.g. keys that wait for confirmation).
+	Grace time.Duration
+	// Tolerance is a difference of expiration times that isn't reported (e.g. the precision of the stores).
+	Tolerance time.Duration
+	// Repair repairs found mismatches. The audit only reports them if it's false (dry run).
+	Repair bool
+	// SkipMissingKeys skips the scan of links for missing keys. Links don't record the pool of their keys,
+	// so missing keys can be checked only in the pool that every link is created with.
+	SkipMissingKeys bool
+}
+
+// Auditor finds and optionally repairs mismatches between used keys and links.
+type Auditor struct {
+	keys  KeyStore
+	links LinkStore
+	cfg   Config
+}
+
+// New creates a new Auditor. Zero values of the configuration are replaced with defaults.
+func New(keys KeyStore, links LinkStore, cfg Config) *Auditor {
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = DefaultPageSize
+	}
+	if cfg.Grace == 0 {
+		cfg.Grace = DefaultGrace
+	}
+	if cfg.Tolerance == 0 {
+		cfg.Tolerance = DefaultTolerance
+	}
+	return &Auditor{keys: keys, links: links, cfg: cfg}
+}
+
+// Run scans used keys and then links unless missing keys are skipped and reports every mismatch.
+// Mismatches are repaired if it's configured. A failed repair is logged and the mismatch is reported as not repaired.
+func (a *Auditor) Run(ctx context.Context) (Report, error) {
+	var report Report
+	err := a.keys.Scan(ctx, a.cfg.PageSize, func(page map[string]time.Time) error {
+		report.KeysScanned += int64(len(page))
+		mismatches, err := a.checkKeys(ctx, page)
+		if err != nil {
+			return err
+		}
+		report.Mismatches = append(report.Mismatches, mismatches...)
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed scan used keys: %w", err)
+	}
+	if a.cfg.SkipMissingKeys {
+		return report, nil
+	}
+	err = a.links.Scan(ctx, a.cfg.PageSize, func(page map[string]time.Time) error {
+		report.LinksScanned += int64(len(page))
+		mismatches, err := a.checkLinks(ctx, page)
+		if err != nil {
+			return err
+		}
+		report.Mismatches = append(report.Mismatches, mismatches...)
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed scan links: %w", err)
+	}
+	return report, nil
+}
+
+// checkKeys finds orphaned keys and keys that expire at a different time than their links.
+func (a *Auditor) checkKeys(ctx context.Context, page map[string]time.Time) ([]Mismatch, error) {
+	links, err := a.links.ExpiresAt(ctx, pageKeys(page))
+	if err != nil {
+		return nil, fmt.Errorf("failed load links: %w", err)
+	}
+	var result []Mismatch
+	for k, keyExpAt := range page {
+		linkExpAt, ok := links[k]
+		switch {
+		case !ok:
+			if !keyExpAt.IsZero() && time.Until(keyExpAt) < a.cfg.Grace {
+				continue
+			}
+			m := Mismatch{Kind: KindOrphanedKey, Key: k, KeyExpAt: keyExpAt}
+			if a.cfg.Repair {
+				m.Repaired = a.release(ctx, k)
+			}
+			result = append(result, a.report(m))
+		case !sameTime(keyExpAt, linkExpAt, a.cfg.Tolerance):
+			m := Mismatch{Kind: KindExpiry, Key: k, KeyExpAt: keyExpAt, LinkExpAt: linkExpAt}
+			if a.cfg.Repair {
+				m.Repaired = a.extend(ctx, k, linkExpAt)
+			}
+			result = append(result, a.report(m))
+		}
+	}
+	return result, nil
+}
+
+// checkLinks finds links without used keys. Expiration times are already checked with the keys.
+func (a *Auditor) checkLinks(ctx context.Context, page map[string]time.Time) ([]Mismatch, error) {
+	keys, err := a.keys.ExpiresAt(ctx, pageKeys(page))
+	if err != nil {
+		return nil, fmt.Errorf("failed load used keys: %w", err)
+	}
+	var result []Mismatch
+	for k, linkExpAt := range page {
+		if _, ok := keys[k]; ok {
+			continue
+		}
+		m := Mismatch{Kind: KindMissingKey, Key: k, LinkExpAt: linkExpAt}
+		if a.cfg.Repair {
+			m.Repaired = a.store(ctx, k, linkExpAt)
+		}
+		result = append(result, a.report(m))
+	}
+	return result, nil
+}
+
+// release deletes the orphaned key unless its link has been created since the key was checked.
+func (a *Auditor) release(ctx context.Context, key string) bool {
+	links, err := a.links.ExpiresAt(ctx, []string{key})
+	if err != nil {
+		slog.Error("failed recheck link of orphaned key", slog.String("key", key), slog.Any("err", err))
+		return false
+	}
+	if _, ok := links[key]; ok {
+		return false
+	}
+	deleted, err := a.keys.Delete(ctx, key)
+	if err != nil {
+		slog.Error("failed release orphaned key", slog.String("key", key), slog.Any("err", err))
+		return false
+	}
+	return deleted
+}
+
+func (a *Auditor) extend(ctx context.Context, key string, expAt time.Time) bool {
+	extended, err := a.keys.Extend(ctx, key, expAt)
+	if err != nil {
+		slog.Error("failed fix expiration of key", slog.String("key", key), slog.Any("err", err))
+		return false
+	}
+	return extended
+}
+
+func (a *Auditor) store(ctx context.Context, key string, expAt time.Time) bool {
+	stored, err := a.keys.Store(ctx, key, time.Until(expAt))
+	if err != nil {
+		slog.Error("failed store missing key", slog.String("key", key), slog.Any("err", err))
+		return false
+	}
+	return stored
+}
+
+func (a *Auditor) report(m Mismatch) Mismatch {
+	slog.Warn("found mismatch of key and link",
+		slog.String("kind", string(m.Kind)),
+		slog.String("key", m.Key),
+		slog.Time("key_exp_at", m.KeyExpAt),
+		slog.Time("link_exp_at", m.LinkExpAt),
+		slog.Bool("repaired", m.Repaired))
+	return m
+}
+
+func sameTime(t1, t2 time.Time, tolerance time.Duration) bool {
+	d := t1.Sub(t2)
+	return d <= tolerance && d >= -tolerance
+}
+
+func pageKeys(page map[string]time.Time) []string {
+	result := make([]string, 0, len(page))
+	for k := range page {
+		result = append(result, k)
+	}
+	return result
+}
@@ -0,0 +1,220 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditor_Run_DryRun(t *testing.T) {
+	now := time.Now()
+	keys := newMemStore(map[string]time.Time{
+		"ok":       now.Add(time.Hour),
+		"orphaned": now.Add(time.Hour),
+		"held":     now.Add(time.Minute),
+		"drifted":  now.Add(2 * time.Hour),
+	})
+	links := newMemStore(map[string]time.Time{
+		"ok":      now.Add(time.Hour),
+		"drifted": now.Add(time.Hour),
+		"missing": now.Add(time.Hour),
+	})
+
+	report, err := New(keys, links, Config{PageSize: 2}).Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(4), report.KeysScanned)
+	assert.Equal(t, int64(3), report.LinksScanned)
+	// the key that expires within the grace period may wait for confirmation, so it isn't reported
+	assert.Equal(t, []Mismatch{
+		{Kind: KindExpiry, Key: "drifted", KeyExpAt: now.Add(2 * time.Hour), LinkExpAt: now.Add(time.Hour)},
+		{Kind: KindMissingKey, Key: "missing", LinkExpAt: now.Add(time.Hour)},
+		{Kind: KindOrphanedKey, Key: "orphaned", KeyExpAt: now.Add(time.Hour)},
+	}, sorted(report.Mismatches))
+	// nothing is changed in the dry run
+	assert.Len(t, keys.entries, 4)
+	assert.Equal(t, now.Add(2*time.Hour), keys.entries["drifted"])
+}
+
+func TestAuditor_Run_Repair(t *testing.T) {
+	now := time.Now()
+	keys := newMemStore(map[string]time.Time{
+		"ok":       now.Add(time.Hour),
+		"orphaned": now.Add(time.Hour),
+		"drifted":  now.Add(2 * time.Hour),
+	})
+	links := newMemStore(map[string]time.Time{
+		"ok":      now.Add(time.Hour),
+		"drifted": now.Add(time.Hour),
+		"missing": now.Add(time.Hour),
+	})
+
+	report, err := New(keys, links, Config{PageSize: 2, Repair: true}).Run(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, report.Mismatches, 3)
+	for _, m := range report.Mismatches {
+		assert.True(t, m.Repaired, m.Key)
+	}
+	assert.Equal(t, []string{"drifted", "missing", "ok"}, keys.keys())
+	assert.Equal(t, now.Add(time.Hour), keys.entries["drifted"])
+	assert.WithinDuration(t, now.Add(time.Hour), keys.entries["missing"], time.Second)
+
+	// the stores are consistent after the repair
+	report, err = New(keys, links, Config{PageSize: 2}).Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, report.Mismatches)
+}
+
+func TestAuditor_Run_SkipMissingKeys(t *testing.T) {
+	now := time.Now()
+	keys := newMemStore(map[string]time.Time{"ok": now.Add(time.Hour)})
+	// the link is created with a key of another pool
+	links := newMemStore(map[string]time.Time{"ok": now.Add(time.Hour), "other": now.Add(time.Hour)})
+
+	report, err := New(keys, links, Config{Repair: true, SkipMissingKeys: true}).Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Zero(t, report.LinksScanned)
+	assert.Empty(t, report.Mismatches)
+	assert.Equal(t, []string{"ok"}, keys.keys())
+}
+
+func TestAuditor_Run_Repair_LinkCreatedDuringAudit(t *testing.T) {
+	keys := newMemStore(map[string]time.Time{"k1": time.Now().Add(time.Hour)})
+	links := newMemStore(nil)
+	// the link is saved right after the audit has checked its key
+	links.onExpiresAt = func() {
+		links.onExpiresAt = nil
+		links.entries["k1"] = time.Now().Add(time.Hour)
+	}
+
+	report, err := New(keys, links, Config{Repair: true}).Run(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, report.Mismatches, 1)
+	assert.False(t, report.Mismatches[0].Repaired)
+	assert.Equal(t, []string{"k1"}, keys.keys())
+}
+
+func TestAuditor_Run_Tolerance(t *testing.T) {
+	now := time.Now()
+	keys := newMemStore(map[string]time.Time{"k1": now.Add(time.Hour)})
+	links := newMemStore(map[string]time.Time{"k1": now.Add(time.Hour + 500*time.Millisecond)})
+
+	report, err := New(keys, links, Config{}).Run(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, report.Mismatches)
+}
+
+func TestAuditor_Run_ScanErr(t *testing.T) {
+	keys := newMemStore(map[string]time.Time{"k1": time.Now().Add(time.Hour)})
+	links := newMemStore(nil)
+	links.err = errors.New("test err")
+
+	_, err := New(keys, links, Config{}).Run(context.Background())
+	assert.ErrorIs(t, err, links.err)
+}
+
+func sorted(mismatches []Mismatch) []Mismatch {
+	sort.Slice(mismatches, func(i, j int) bool {
+		return mismatches[i].Key < mismatches[j].Key
+	})
+	return mismatches
+}
+
+// memStore is an in-memory store of used keys or links.
+type memStore struct {
+	err         error
+	entries     map[string]time.Time
+	onExpiresAt func()
+	mu          sync.Mutex
+}
+
+func newMemStore(entries map[string]time.Time) *memStore {
+	if entries == nil {
+		entries = map[string]time.Time{}
+	}
+	return &memStore{entries: entries}
+}
+
+func (s *memStore) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]string, 0, len(s.entries))
+	for k := range s.entries {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (s *memStore) Scan(_ context.Context, pageSize int64, fn func(map[string]time.Time) error) error {
+	keys := s.keys()
+	for len(keys) > 0 {
+		n := min(int(pageSize), len(keys))
+		s.mu.Lock()
+		page := make(map[string]time.Time, n)
+		for _, k := range keys[:n] {
+			page[k] = s.entries[k]
+		}
+		s.mu.Unlock()
+		if err := fn(page); err != nil {
+			return err
+		}
+		keys = keys[n:]
+	}
+	return nil
+}
+
+func (s *memStore) ExpiresAt(_ context.Context, keys []string) (map[string]time.Time, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[string]time.Time, len(keys))
+	for _, k := range keys {
+		if expAt, ok := s.entries[k]; ok {
+			result[k] = expAt
+		}
+	}
+	if s.onExpiresAt != nil {
+		s.onExpiresAt()
+	}
+	return result, nil
+}
+
+func (s *memStore) Store(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[key]; ok {
+		return false, nil
+	}
+	s.entries[key] = time.Now().Add(ttl)
+	return true, nil
+}
+
+func (s *memStore) Delete(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.entries[key]
+	delete(s.entries, key)
+	return ok, nil
+}
+
+func (s *memStore) Extend(_ context.Context, key string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[key]; !ok {
+		return false, nil
+	}
+	s.entries[key] = expiresAt
+	return true, nil
+}
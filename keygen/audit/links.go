@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type link struct {
+	ExpAt time.Time `bson:"exp_at"`
+	ID    string    `bson:"_id"`
+}
+
+// MongoLinks reads links of the links service from MongoDB.
+type MongoLinks struct {
+	coll *mongo.Collection
+}
+
+// NewMongoLinks creates a new MongoLinks of the links collection in the database.
+func NewMongoLinks(db *mongo.Database) *MongoLinks {
+	return &MongoLinks{coll: db.Collection("links")}
+}
+
+// Scan reads links in the order of their keys, so every page starts after the last key of the previous one.
+// Expired links are skipped, since MongoDB deletes them with a delay.
+func (l *MongoLinks) Scan(ctx context.Context, pageSize int64, fn func(map[string]time.Time) error) error {
+	var after string
+	for {
+		filter := bson.M{"_id": bson.M{"$gt": after}, "exp_at": bson.M{"$gt": time.Now().UTC()}}
+		opts := options.Find().SetSort(bson.M{"_id": 1}).SetLimit(pageSize).SetProjection(bson.M{"exp_at": 1})
+		cursor, err := l.coll.Find(ctx, filter, opts)
+		if err != nil {
+			return err
+		}
+		var found []link
+		if err := cursor.All(ctx, &found); err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			return nil
+		}
+		page := make(map[string]time.Time, len(found))
+		for _, f := range found {
+			page[f.ID] = f.ExpAt
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if int64(len(found)) < pageSize {
+			return nil
+		}
+		after = found[len(found)-1].ID
+	}
+}
+
+// ExpiresAt loads links with the keys with a single $in query. Expired links are missing in the result.
+func (l *MongoLinks) ExpiresAt(ctx context.Context, keys []string) (map[string]time.Time, error) {
+	filter := bson.M{"_id": bson.M{"$in": keys}, "exp_at": bson.M{"$gt": time.Now().UTC()}}
+	cursor, err := l.coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"exp_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var found []link
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	result := make(map[string]time.Time, len(found))
+	for _, f := range found {
+		result[f.ID] = f.ExpAt
+	}
+	return result, nil
+}
@@ -2,7 +2,14 @@ package grpcsvc
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/demeero/bricks/errbrick"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	pb "github.com/demeero/pocket-link/proto/gen/go/pocketlink/keygen/v1beta1"
@@ -12,20 +19,208 @@ import (
 
 type Service struct {
 	pb.KeygenServiceServer
-	k *key.Keys
+	pools *key.Pools
 }
 
-func New(k *key.Keys) *Service {
-	return &Service{k: k}
+func New(pools *key.Pools) *Service {
+	return &Service{pools: pools}
 }
 
-func (s *Service) GenerateKey(ctx context.Context, _ *pb.GenerateKeyRequest) (*pb.GenerateKeyResponse, error) {
-	result, err := s.k.Use(ctx)
+func (s *Service) GenerateKey(ctx context.Context, req *pb.GenerateKeyRequest) (*pb.GenerateKeyResponse, error) {
+	var ttl time.Duration
+	if req.GetTtl() != nil {
+		if err := req.GetTtl().CheckValid(); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		ttl = req.GetTtl().AsDuration()
+		if ttl == 0 {
+			return nil, status.Error(codes.InvalidArgument, "ttl must be positive")
+		}
+	}
+	keys, err := s.pools.Get(req.GetPool())
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	md := key.Metadata{
+		ClientID: req.GetMetadata().GetClientId(),
+		Tenant:   req.GetMetadata().GetTenant(),
+		TraceID:  req.GetMetadata().GetTraceId(),
+	}
+	if req.GetRequireConfirmation() {
+		result, deadline, err := keys.Hold(ctx, ttl, md)
+		if err != nil {
+			return nil, statusErr(err)
+		}
+		return &pb.GenerateKeyResponse{Key: toPBKey(result), ConfirmDeadline: timestamppb.New(deadline)}, nil
+	}
+	result, err := keys.Use(ctx, ttl, md)
 	if err != nil {
-		return nil, err
+		return nil, statusErr(err)
+	}
+	return &pb.GenerateKeyResponse{Key: toPBKey(result)}, nil
+}
+
+func (s *Service) ConfirmKey(ctx context.Context, req *pb.ConfirmKeyRequest) (*pb.ConfirmKeyResponse, error) {
+	if err := req.GetExpireTime().CheckValid(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	keys, err := s.pools.Get(req.GetPool())
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	result, err := keys.Confirm(ctx, req.GetVal(), req.GetExpireTime().AsTime())
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	return &pb.ConfirmKeyResponse{Key: toPBKey(result)}, nil
+}
+
+func (s *Service) GenerateKeys(ctx context.Context, req *pb.GenerateKeysRequest) (*pb.GenerateKeysResponse, error) {
+	keys, err := s.pools.Get(req.GetPool())
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	result, err := keys.UseN(ctx, int64(req.GetCount()))
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	resp := &pb.GenerateKeysResponse{
+		Keys:   make([]*pb.Key, 0, len(result)),
+		Status: pb.GenerateKeysResponse_STATUS_COMPLETE,
+	}
+	for _, k := range result {
+		resp.Keys = append(resp.Keys, toPBKey(k))
+	}
+	if len(result) < int(req.GetCount()) {
+		resp.Status = pb.GenerateKeysResponse_STATUS_PARTIAL
+	}
+	return resp, nil
+}
+
+func (s *Service) ReserveKey(ctx context.Context, req *pb.ReserveKeyRequest) (*pb.ReserveKeyResponse, error) {
+	keys, err := s.pools.Get(req.GetPool())
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	result, err := keys.Reserve(ctx, req.GetVal())
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	return &pb.ReserveKeyResponse{Key: toPBKey(result)}, nil
+}
+
+func (s *Service) ReleaseKey(ctx context.Context, req *pb.ReleaseKeyRequest) (*pb.ReleaseKeyResponse, error) {
+	keys, err := s.pools.Get(req.GetPool())
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	if err = keys.Release(ctx, req.GetVal()); err != nil {
+		return nil, statusErr(err)
+	}
+	return &pb.ReleaseKeyResponse{}, nil
+}
+
+func (s *Service) ExtendKey(ctx context.Context, req *pb.ExtendKeyRequest) (*pb.ExtendKeyResponse, error) {
+	if err := req.GetNewExpireTime().CheckValid(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	keys, err := s.pools.Get(req.GetPool())
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	result, err := keys.Extend(ctx, req.GetVal(), req.GetNewExpireTime().AsTime())
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	return &pb.ExtendKeyResponse{Key: toPBKey(result)}, nil
+}
+
+func (s *Service) LookupKey(ctx context.Context, req *pb.LookupKeyRequest) (*pb.LookupKeyResponse, error) {
+	keys, err := s.pools.Get(req.GetPool())
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	result, err := keys.Lookup(ctx, req.GetVal())
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	resp := &pb.LookupKeyResponse{
+		Key: toPBKey(result.Key),
+		Metadata: &pb.KeyMetadata{
+			ClientId: result.Metadata.ClientID,
+			Tenant:   result.Metadata.Tenant,
+			TraceId:  result.Metadata.TraceID,
+		},
+	}
+	if !result.IssuedAt.IsZero() {
+		resp.IssueTime = timestamppb.New(result.IssuedAt)
+	}
+	return resp, nil
+}
+
+func (s *Service) GetStats(ctx context.Context, req *pb.GetStatsRequest) (*pb.GetStatsResponse, error) {
+	stats, err := s.pools.Stats(ctx, req.GetPool())
+	if err != nil {
+		return nil, statusErr(err)
+	}
+	resp := &pb.GetStatsResponse{
+		UnusedKeys: stats.UnusedKeys,
+		UsedKeys:   stats.UsedKeys,
+		Collisions: stats.Collisions,
+		IssueRates: make([]*pb.IssueRate, 0, len(stats.IssueRates)),
+	}
+	for _, r := range stats.IssueRates {
+		resp.IssueRates = append(resp.IssueRates, &pb.IssueRate{
+			Window:        durationpb.New(r.Window),
+			KeysPerSecond: r.PerSecond,
+		})
+	}
+	if stats.TimeUntilEmpty > 0 {
+		resp.TimeUntilEmpty = durationpb.New(stats.TimeUntilEmpty)
+	}
+	return resp, nil
+}
+
+// exhaustedRetryDelay is how long clients are advised to wait when there are no free keys.
+// It's enough for the generator to refill free keys, since it's woken up as soon as they run out.
+const exhaustedRetryDelay = time.Second
+
+// statusErr converts errors to GRPC status errors. Unknown errors are failures of the storage, so they are UNAVAILABLE.
+func statusErr(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, key.ErrExhausted):
+		return exhaustedErr(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, errbrick.ErrInvalidData):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, errbrick.ErrConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, errbrick.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+}
+
+// exhaustedErr returns the RESOURCE_EXHAUSTED status that advises clients when to retry.
+func exhaustedErr(err error) error {
+	st, detailsErr := status.New(codes.ResourceExhausted, err.Error()).
+		WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(exhaustedRetryDelay)})
+	if detailsErr != nil {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return st.Err()
+}
+
+func toPBKey(k key.Key) *pb.Key {
+	return &pb.Key{
+		Val:        k.Val,
+		ExpireTime: timestamppb.New(k.ExpiresAt),
 	}
-	return &pb.GenerateKeyResponse{Key: &pb.Key{
-		Val:        result.Val,
-		ExpireTime: timestamppb.New(result.ExpiresAt),
-	}}, nil
 }
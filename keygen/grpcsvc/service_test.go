@@ -3,12 +3,20 @@ package grpcsvc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/demeero/bricks/errbrick"
 	pb "github.com/demeero/pocket-link/proto/gen/go/pocketlink/keygen/v1beta1"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/demeero/pocket-link/keygen/key"
 )
@@ -26,7 +34,7 @@ func TestController_GenerateKey(t *testing.T) {
 	usedRepo.EXPECT().Store(ctx, testKey, gomock.Any()).Return(true, nil)
 	keys := key.New(time.Hour, usedRepo, unusedRepo)
 
-	c := New(keys)
+	c := New(defaultPool(keys))
 
 	actual, err := c.GenerateKey(ctx, &pb.GenerateKeyRequest{})
 	assert.NoError(t, err)
@@ -46,11 +54,532 @@ func TestController_GenerateKey_Err(t *testing.T) {
 	unusedRepo.EXPECT().LoadAndDelete(ctx).Return(testKey, nil)
 	testErr := errors.New("test err")
 	usedRepo.EXPECT().Store(ctx, testKey, gomock.Any()).Return(false, testErr)
+	unusedRepo.EXPECT().Store(gomock.Any(), testKey).Return(int64(1), nil)
 	keys := key.New(time.Hour, usedRepo, unusedRepo)
 
-	c := New(keys)
+	c := New(defaultPool(keys))
 
 	actual, err := c.GenerateKey(ctx, &pb.GenerateKeyRequest{})
 	assert.Nil(t, actual)
 	assert.ErrorContains(t, err, testErr.Error())
 }
+
+func TestController_GenerateKey_Exhausted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := key.NewMockClaimer(ctrl)
+	ctx := context.Background()
+	claimer.EXPECT().Claim(ctx, time.Hour).Return("", errbrick.ErrNotFound).Times(2)
+	c := New(defaultPool(key.New(time.Hour, nil, nil, key.WithClaimer(claimer), key.WithRetry(key.Retry{Attempts: 2}))))
+
+	actual, err := c.GenerateKey(ctx, &pb.GenerateKeyRequest{})
+	assert.Nil(t, actual)
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.Equal(t, exhaustedRetryDelay, retryInfo.GetRetryDelay().AsDuration())
+}
+
+func TestController_GenerateKey_DeadlineExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := key.NewMockClaimer(ctrl)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	claimer.EXPECT().Claim(ctx, time.Hour).Return("", errbrick.ErrNotFound).AnyTimes()
+	c := New(defaultPool(key.New(time.Hour, nil, nil, key.WithClaimer(claimer), key.WithRetry(key.Retry{Attempts: 5, Delay: time.Second}))))
+
+	_, err := c.GenerateKey(ctx, &pb.GenerateKeyRequest{})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestController_GenerateKey_Unavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := key.NewMockClaimer(ctrl)
+	ctx := context.Background()
+	claimer.EXPECT().Claim(ctx, time.Hour).Return("", errors.New("connection refused"))
+	c := New(defaultPool(key.New(time.Hour, nil, nil, key.WithClaimer(claimer))))
+
+	_, err := c.GenerateKey(ctx, &pb.GenerateKeyRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestStatusErr(t *testing.T) {
+	tests := []struct {
+		err      error
+		name     string
+		expected codes.Code
+	}{
+		{name: "invalid data", err: fmt.Errorf("%w: bad ttl", errbrick.ErrInvalidData), expected: codes.InvalidArgument},
+		{name: "conflict", err: fmt.Errorf("%w: key already used", errbrick.ErrConflict), expected: codes.AlreadyExists},
+		{name: "not found", err: fmt.Errorf("%w: key is not used", errbrick.ErrNotFound), expected: codes.NotFound},
+		{name: "exhausted", err: fmt.Errorf("%w after 5 attempts", key.ErrExhausted), expected: codes.ResourceExhausted},
+		{name: "deadline exceeded", err: fmt.Errorf("failed claim key: %w", context.DeadlineExceeded), expected: codes.DeadlineExceeded},
+		{name: "canceled", err: context.Canceled, expected: codes.Canceled},
+		{name: "status", err: status.Error(codes.Aborted, "test"), expected: codes.Aborted},
+		{name: "backend", err: errors.New("connection refused"), expected: codes.Unavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, status.Code(statusErr(tt.err)))
+		})
+	}
+}
+
+func TestController_GenerateKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := key.NewMockClaimer(ctrl)
+	ctx := context.Background()
+
+	claimer.EXPECT().ClaimN(ctx, int64(2), gomock.Any()).Return([]string{"k1", "k2"}, nil)
+	c := New(defaultPool(key.New(time.Hour, nil, nil, key.WithClaimer(claimer))))
+
+	actual, err := c.GenerateKeys(ctx, &pb.GenerateKeysRequest{Count: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, pb.GenerateKeysResponse_STATUS_COMPLETE, actual.GetStatus())
+	assert.Len(t, actual.GetKeys(), 2)
+	for _, k := range actual.GetKeys() {
+		assert.NotEmpty(t, k.GetVal())
+		assert.NotZero(t, k.GetExpireTime().AsTime())
+	}
+}
+
+func TestController_GenerateKeys_Partial(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := key.NewMockClaimer(ctrl)
+	ctx := context.Background()
+
+	claimer.EXPECT().ClaimN(ctx, int64(3), gomock.Any()).Return([]string{"k1"}, nil)
+	claimer.EXPECT().ClaimN(ctx, int64(2), gomock.Any()).Return(nil, errbrick.ErrNotFound)
+	c := New(defaultPool(key.New(time.Hour, nil, nil, key.WithClaimer(claimer))))
+
+	actual, err := c.GenerateKeys(ctx, &pb.GenerateKeysRequest{Count: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, pb.GenerateKeysResponse_STATUS_PARTIAL, actual.GetStatus())
+	assert.Len(t, actual.GetKeys(), 1)
+}
+
+func TestController_GenerateKeys_InvalidCount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := key.NewMockClaimer(ctrl)
+	c := New(defaultPool(key.New(time.Hour, nil, nil, key.WithClaimer(claimer), key.WithMaxBatchSize(5))))
+
+	actual, err := c.GenerateKeys(context.Background(), &pb.GenerateKeysRequest{Count: 6})
+	assert.Nil(t, actual)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestController_ReserveKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	unusedRepo := key.NewMockUnusedKeysRepository(ctrl)
+	alias := "spring-sale"
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Exists(ctx, alias).Return(false, nil)
+	usedRepo.EXPECT().Store(ctx, alias, time.Hour).Return(true, nil)
+	unusedRepo.EXPECT().Delete(ctx, alias).Return(false, nil)
+	c := New(defaultPool(key.New(time.Hour, usedRepo, unusedRepo)))
+
+	actual, err := c.ReserveKey(ctx, &pb.ReserveKeyRequest{Val: alias})
+	assert.NoError(t, err)
+	assert.Equal(t, alias, actual.GetKey().GetVal())
+	assert.NotZero(t, actual.GetKey().GetExpireTime().AsTime())
+}
+
+func TestController_ReserveKey_AlreadyExists(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	unusedRepo := key.NewMockUnusedKeysRepository(ctrl)
+	alias := "spring-sale"
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Exists(ctx, alias).Return(true, nil)
+	c := New(defaultPool(key.New(time.Hour, usedRepo, unusedRepo)))
+
+	actual, err := c.ReserveKey(ctx, &pb.ReserveKeyRequest{Val: alias})
+	assert.Nil(t, actual)
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+}
+
+func TestController_ReserveKey_InvalidArgument(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := New(defaultPool(key.New(time.Hour, key.NewMockUsedKeysRepository(ctrl), key.NewMockUnusedKeysRepository(ctrl))))
+
+	actual, err := c.ReserveKey(context.Background(), &pb.ReserveKeyRequest{Val: "sale!"})
+	assert.Nil(t, actual)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestController_GenerateKey_TTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := key.NewMockClaimer(ctrl)
+	ctx := context.Background()
+
+	claimer.EXPECT().Claim(ctx, 2*time.Hour).Return("testKey1", nil)
+	c := New(defaultPool(key.New(time.Hour, nil, nil, key.WithClaimer(claimer))))
+
+	actual, err := c.GenerateKey(ctx, &pb.GenerateKeyRequest{Ttl: durationpb.New(2 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Equal(t, "testKey1", actual.GetKey().GetVal())
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), actual.GetKey().GetExpireTime().AsTime(), time.Second)
+}
+
+func TestController_GenerateKey_InvalidTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := key.NewMockClaimer(ctrl)
+	c := New(defaultPool(key.New(time.Hour, nil, nil, key.WithClaimer(claimer), key.WithTTLBounds(time.Minute, 24*time.Hour))))
+
+	for _, ttl := range []time.Duration{0, time.Second, 25 * time.Hour} {
+		actual, err := c.GenerateKey(context.Background(), &pb.GenerateKeyRequest{Ttl: durationpb.New(ttl)})
+		assert.Nil(t, actual)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), ttl)
+	}
+}
+
+func TestController_ReleaseKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	unusedRepo := key.NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Delete(ctx, testKey).Return(true, nil)
+	c := New(defaultPool(key.New(time.Hour, usedRepo, unusedRepo)))
+
+	actual, err := c.ReleaseKey(ctx, &pb.ReleaseKeyRequest{Val: testKey})
+	assert.NoError(t, err)
+	assert.NotNil(t, actual)
+}
+
+func TestController_ReleaseKey_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	unusedRepo := key.NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Delete(ctx, testKey).Return(false, nil)
+	c := New(defaultPool(key.New(time.Hour, usedRepo, unusedRepo)))
+
+	actual, err := c.ReleaseKey(ctx, &pb.ReleaseKeyRequest{Val: testKey})
+	assert.Nil(t, actual)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestController_ExtendKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	unusedRepo := key.NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	expAt := timestamppb.New(time.Now().Add(48 * time.Hour))
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Extend(ctx, testKey, expAt.AsTime()).Return(true, nil)
+	c := New(defaultPool(key.New(time.Hour, usedRepo, unusedRepo)))
+
+	actual, err := c.ExtendKey(ctx, &pb.ExtendKeyRequest{Val: testKey, NewExpireTime: expAt})
+	assert.NoError(t, err)
+	assert.Equal(t, testKey, actual.GetKey().GetVal())
+	assert.Equal(t, expAt.AsTime(), actual.GetKey().GetExpireTime().AsTime())
+}
+
+func TestController_ExtendKey_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	unusedRepo := key.NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	expAt := timestamppb.New(time.Now().Add(48 * time.Hour))
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Extend(ctx, testKey, expAt.AsTime()).Return(false, nil)
+	c := New(defaultPool(key.New(time.Hour, usedRepo, unusedRepo)))
+
+	actual, err := c.ExtendKey(ctx, &pb.ExtendKeyRequest{Val: testKey, NewExpireTime: expAt})
+	assert.Nil(t, actual)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestController_ExtendKey_InvalidArgument(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := New(defaultPool(key.New(time.Hour, key.NewMockUsedKeysRepository(ctrl), key.NewMockUnusedKeysRepository(ctrl))))
+
+	for _, expAt := range []*timestamppb.Timestamp{nil, timestamppb.New(time.Now().Add(-time.Hour))} {
+		actual, err := c.ExtendKey(context.Background(), &pb.ExtendKeyRequest{Val: "testKey1", NewExpireTime: expAt})
+		assert.Nil(t, actual)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestController_GenerateKey_RequireConfirmation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	unusedRepo := key.NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	ctx := context.Background()
+
+	unusedRepo.EXPECT().LoadAndDelete(ctx).Return(testKey, nil)
+	// the key is held until it's confirmed
+	usedRepo.EXPECT().Store(ctx, testKey, time.Minute).Return(true, nil)
+	c := New(defaultPool(key.New(time.Hour, usedRepo, unusedRepo, key.WithHoldTimeout(time.Minute))))
+
+	actual, err := c.GenerateKey(ctx, &pb.GenerateKeyRequest{RequireConfirmation: true})
+	require.NoError(t, err)
+	assert.Equal(t, testKey, actual.GetKey().GetVal())
+	assert.WithinDuration(t, time.Now().Add(time.Hour), actual.GetKey().GetExpireTime().AsTime(), time.Second)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), actual.GetConfirmDeadline().AsTime(), time.Second)
+}
+
+func TestController_ConfirmKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	unusedRepo := key.NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	expAt := timestamppb.New(time.Now().Add(time.Hour))
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Extend(ctx, testKey, expAt.AsTime()).Return(true, nil)
+	c := New(defaultPool(key.New(time.Hour, usedRepo, unusedRepo)))
+
+	actual, err := c.ConfirmKey(ctx, &pb.ConfirmKeyRequest{Val: testKey, ExpireTime: expAt})
+	require.NoError(t, err)
+	assert.Equal(t, testKey, actual.GetKey().GetVal())
+	assert.Equal(t, expAt.AsTime(), actual.GetKey().GetExpireTime().AsTime())
+}
+
+func TestController_ConfirmKey_NotHeld(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	unusedRepo := key.NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	expAt := timestamppb.New(time.Now().Add(time.Hour))
+	ctx := context.Background()
+
+	// the key isn't used and isn't held by the instance
+	usedRepo.EXPECT().Extend(ctx, testKey, expAt.AsTime()).Return(false, nil)
+	c := New(defaultPool(key.New(time.Hour, usedRepo, unusedRepo)))
+
+	actual, err := c.ConfirmKey(ctx, &pb.ConfirmKeyRequest{Val: testKey, ExpireTime: expAt})
+	assert.Nil(t, actual)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestController_ConfirmKey_InvalidArgument(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := New(defaultPool(key.New(time.Hour, key.NewMockUsedKeysRepository(ctrl), key.NewMockUnusedKeysRepository(ctrl))))
+
+	for _, expAt := range []*timestamppb.Timestamp{nil, timestamppb.New(time.Now().Add(-time.Hour))} {
+		actual, err := c.ConfirmKey(context.Background(), &pb.ConfirmKeyRequest{Val: "testKey1", ExpireTime: expAt})
+		assert.Nil(t, actual)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestController_GenerateKey_Metadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	unusedRepo := key.NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	ctx := context.Background()
+
+	unusedRepo.EXPECT().LoadAndDelete(ctx).Return(testKey, nil)
+	usedRepo.EXPECT().Store(ctx, testKey, gomock.Any()).Return(true, nil)
+	usedRepo.EXPECT().Annotate(ctx, testKey, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, md map[string]string) (bool, error) {
+			assert.Equal(t, "c1", md["client_id"])
+			assert.Equal(t, "t1", md["tenant"])
+			assert.NotEmpty(t, md["issued_at"])
+			return true, nil
+		})
+	c := New(defaultPool(key.New(time.Hour, usedRepo, unusedRepo)))
+
+	actual, err := c.GenerateKey(ctx, &pb.GenerateKeyRequest{Metadata: &pb.KeyMetadata{ClientId: "c1", Tenant: "t1"}})
+	require.NoError(t, err)
+	assert.Equal(t, testKey, actual.GetKey().GetVal())
+}
+
+func TestController_LookupKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	testKey := "testKey1"
+	issuedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	expAt := time.Now().Add(time.Hour)
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Lookup(ctx, testKey).Return(map[string]string{
+		"client_id": "c1",
+		"trace_id":  "trace1",
+		"issued_at": issuedAt.Format(time.RFC3339Nano),
+	}, expAt, nil)
+	c := New(defaultPool(key.New(time.Hour, usedRepo, nil)))
+
+	actual, err := c.LookupKey(ctx, &pb.LookupKeyRequest{Val: testKey})
+	require.NoError(t, err)
+	assert.Equal(t, testKey, actual.GetKey().GetVal())
+	assert.True(t, expAt.Equal(actual.GetKey().GetExpireTime().AsTime()))
+	assert.Equal(t, "c1", actual.GetMetadata().GetClientId())
+	assert.Equal(t, "trace1", actual.GetMetadata().GetTraceId())
+	assert.Empty(t, actual.GetMetadata().GetTenant())
+	assert.Equal(t, issuedAt, actual.GetIssueTime().AsTime())
+}
+
+func TestController_LookupKey_WithoutMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	testKey := "testKey1"
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Lookup(ctx, testKey).Return(nil, time.Now().Add(time.Hour), nil)
+	c := New(defaultPool(key.New(time.Hour, usedRepo, nil)))
+
+	actual, err := c.LookupKey(ctx, &pb.LookupKeyRequest{Val: testKey})
+	require.NoError(t, err)
+	assert.Equal(t, testKey, actual.GetKey().GetVal())
+	assert.Nil(t, actual.GetIssueTime())
+}
+
+func TestController_LookupKey_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	testKey := "testKey1"
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Lookup(ctx, testKey).Return(nil, time.Time{}, fmt.Errorf("%w: key is not used", errbrick.ErrNotFound))
+	c := New(defaultPool(key.New(time.Hour, usedRepo, nil)))
+
+	actual, err := c.LookupKey(ctx, &pb.LookupKeyRequest{Val: testKey})
+	assert.Nil(t, actual)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestController_UnknownPool(t *testing.T) {
+	c := New(defaultPool(key.New(time.Hour, nil, nil)))
+	ctx := context.Background()
+
+	_, err := c.GenerateKey(ctx, &pb.GenerateKeyRequest{Pool: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = c.GenerateKeys(ctx, &pb.GenerateKeysRequest{Count: 1, Pool: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = c.ReserveKey(ctx, &pb.ReserveKeyRequest{Val: "vanity", Pool: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = c.ReleaseKey(ctx, &pb.ReleaseKeyRequest{Val: "testKey1", Pool: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = c.ExtendKey(ctx, &pb.ExtendKeyRequest{Val: "testKey1", NewExpireTime: timestamppb.Now(), Pool: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = c.GetStats(ctx, &pb.GetStatsRequest{Pool: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestController_GenerateKey_Pool(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	defaultClaimer := key.NewMockClaimer(ctrl)
+	smsClaimer := key.NewMockClaimer(ctrl)
+	ctx := context.Background()
+	smsClaimer.EXPECT().Claim(ctx, time.Hour).Return("sms1", nil)
+
+	c := New(key.NewPools(map[string]*key.Keys{
+		key.DefaultPool: key.New(time.Hour, nil, nil, key.WithClaimer(defaultClaimer)),
+		"sms":           key.New(time.Hour, nil, nil, key.WithClaimer(smsClaimer)),
+	}))
+
+	actual, err := c.GenerateKey(ctx, &pb.GenerateKeyRequest{Pool: "sms"})
+	assert.NoError(t, err)
+	assert.Equal(t, "sms1", actual.GetKey().GetVal())
+}
+
+func TestController_GetStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	unusedRepo := key.NewMockUnusedKeysRepository(ctrl)
+	claimer := key.NewMockClaimer(ctrl)
+	ctx := context.Background()
+	claimer.EXPECT().Claim(ctx, time.Hour).Return("testKey1", nil)
+	unusedRepo.EXPECT().Size(ctx).Return(int64(100), nil)
+	usedRepo.EXPECT().Count(ctx).Return(int64(5), nil)
+	keys := key.New(time.Hour, usedRepo, unusedRepo, key.WithClaimer(claimer), key.WithUsage(key.NewUsage()))
+	_, err := keys.Use(ctx, 0, key.Metadata{})
+	assert.NoError(t, err)
+
+	c := New(defaultPool(keys))
+
+	actual, err := c.GetStats(ctx, &pb.GetStatsRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), actual.GetUnusedKeys())
+	assert.Equal(t, int64(5), actual.GetUsedKeys())
+	assert.Len(t, actual.GetIssueRates(), 3)
+	assert.Equal(t, time.Minute, actual.GetIssueRates()[0].GetWindow().AsDuration())
+	assert.Positive(t, actual.GetIssueRates()[0].GetKeysPerSecond())
+	assert.Positive(t, actual.GetTimeUntilEmpty().AsDuration())
+}
+
+func TestController_GetStats_Err(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	unusedRepo := key.NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+	unusedRepo.EXPECT().Size(ctx).Return(int64(0), errors.New("test err"))
+
+	c := New(defaultPool(key.New(time.Hour, nil, unusedRepo)))
+
+	actual, err := c.GetStats(ctx, &pb.GetStatsRequest{})
+	assert.Nil(t, actual)
+	assert.Error(t, err)
+}
+
+// defaultPool returns Pools with the only default pool.
+func defaultPool(k *key.Keys) *key.Pools {
+	return key.NewPools(map[string]*key.Keys{key.DefaultPool: k})
+}
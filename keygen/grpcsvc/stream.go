@@ -0,0 +1,146 @@
+package grpcsvc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/demeero/bricks/slogbrick"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/demeero/pocket-link/proto/gen/go/pocketlink/keygen/v1beta1"
+
+	"github.com/demeero/pocket-link/keygen/key"
+)
+
+// streamRetryDelay is how long the stream waits for new free keys when they run out.
+const streamRetryDelay = 500 * time.Millisecond
+
+func (s *Service) StreamKeys(stream pb.KeygenService_StreamKeysServer) error {
+	ctx := stream.Context()
+	req, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	keys, err := s.pools.Get(req.GetPool())
+	if err != nil {
+		return statusErr(err)
+	}
+	// the window is claimed with a single UseN, so it can't exceed the maximum batch size
+	if window := int64(req.GetWindow()); window == 0 || window > keys.MaxBatchSize() {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("window must be in range [1, %d]: %d", keys.MaxBatchSize(), window))
+	}
+
+	f := &feed{keys: keys, stream: stream, credits: int64(req.GetWindow())}
+	// keys that the client hasn't acknowledged can't be used by it - they go back to free keys
+	defer f.returnUnacked(ctx)
+
+	acks := make(chan uint32)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case acks <- req.GetAck():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var retry <-chan time.Time
+	for {
+		if f.credits > 0 && retry == nil {
+			if err := f.send(ctx); err != nil {
+				return err
+			}
+			if f.credits > 0 {
+				// free keys ran out - the rest of the window is sent when they are generated
+				retry = time.After(streamRetryDelay)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-retry:
+			retry = nil
+		case n := <-acks:
+			if err := f.ack(n); err != nil {
+				return err
+			}
+		case err := <-recvErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// feed sends keys to the stream within the window of unacknowledged keys.
+type feed struct {
+	keys   *key.Keys
+	stream pb.KeygenService_StreamKeysServer
+	// unacked are sent keys in the order they were sent.
+	unacked []string
+	// credits is a number of keys that can be sent before the next acknowledgement.
+	credits int64
+}
+
+// send claims up to credits keys and sends them. Claimed keys that can't be sent are returned to free keys.
+func (f *feed) send(ctx context.Context) error {
+	claimed, err := f.keys.UseN(ctx, f.credits)
+	if err != nil {
+		return statusErr(err)
+	}
+	if len(claimed) == 0 {
+		return nil
+	}
+	resp := &pb.StreamKeysResponse{Keys: make([]*pb.Key, 0, len(claimed))}
+	vals := make([]string, 0, len(claimed))
+	for _, k := range claimed {
+		resp.Keys = append(resp.Keys, toPBKey(k))
+		vals = append(vals, k.Val)
+	}
+	if err := f.stream.Send(resp); err != nil {
+		f.giveBack(ctx, vals)
+		return err
+	}
+	f.unacked = append(f.unacked, vals...)
+	f.credits -= int64(len(claimed))
+	return nil
+}
+
+// ack acknowledges n oldest sent keys and allows to send n more keys.
+func (f *feed) ack(n uint32) error {
+	if int(n) > len(f.unacked) {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("ack exceeds unacknowledged keys: %d > %d", n, len(f.unacked)))
+	}
+	f.unacked = f.unacked[n:]
+	f.credits += int64(n)
+	return nil
+}
+
+func (f *feed) returnUnacked(ctx context.Context) {
+	if len(f.unacked) > 0 {
+		f.giveBack(ctx, f.unacked)
+	}
+}
+
+// giveBack returns keys to free keys. The stream context is usually done at this point - the keys must be returned anyway.
+func (f *feed) giveBack(ctx context.Context, vals []string) {
+	if err := f.keys.Return(context.WithoutCancel(ctx), vals...); err != nil {
+		slogbrick.FromCtx(ctx).Error("failed return streamed keys", slog.Int("count", len(vals)), slog.Any("err", err))
+	}
+}
@@ -0,0 +1,181 @@
+package grpcsvc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/demeero/bricks/errbrick"
+	pb "github.com/demeero/pocket-link/proto/gen/go/pocketlink/keygen/v1beta1"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/demeero/pocket-link/keygen/key"
+)
+
+func TestController_StreamKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	unusedRepo := key.NewMockUnusedKeysRepository(ctrl)
+	claimer := key.NewMockClaimer(ctrl)
+	gomock.InOrder(
+		claimer.EXPECT().ClaimN(gomock.Any(), int64(2), time.Hour).Return([]string{"k1", "k2"}, nil),
+		claimer.EXPECT().ClaimN(gomock.Any(), int64(1), time.Hour).Return([]string{"k3"}, nil),
+	)
+	// k2 and k3 aren't acknowledged when the stream ends
+	usedRepo.EXPECT().Delete(gomock.Any(), "k2").Return(true, nil)
+	usedRepo.EXPECT().Delete(gomock.Any(), "k3").Return(true, nil)
+	unusedRepo.EXPECT().Store(gomock.Any(), "k2", "k3").Return(int64(2), nil)
+	c := New(defaultPool(key.New(time.Hour, usedRepo, unusedRepo, key.WithClaimer(claimer))))
+
+	stream := newFakeStream()
+	done := make(chan error)
+	go func() { done <- c.StreamKeys(stream) }()
+
+	stream.reqs <- &pb.StreamKeysRequest{Window: 2}
+	assert.Equal(t, []string{"k1", "k2"}, vals(<-stream.sent))
+	stream.reqs <- &pb.StreamKeysRequest{Ack: 1}
+	assert.Equal(t, []string{"k3"}, vals(<-stream.sent))
+	close(stream.reqs)
+
+	require.NoError(t, <-done)
+}
+
+func TestController_StreamKeys_NoFreeKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := key.NewMockClaimer(ctrl)
+	gomock.InOrder(
+		claimer.EXPECT().ClaimN(gomock.Any(), int64(2), time.Hour).Return([]string{"k1"}, nil),
+		claimer.EXPECT().ClaimN(gomock.Any(), int64(1), time.Hour).Return(nil, errbrick.ErrNotFound),
+		// the rest of the window is sent when new keys are generated
+		claimer.EXPECT().ClaimN(gomock.Any(), int64(1), time.Hour).Return([]string{"k2"}, nil),
+	)
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	usedRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(false, nil).Times(2)
+	c := New(defaultPool(key.New(time.Hour, usedRepo, nil, key.WithClaimer(claimer))))
+
+	stream := newFakeStream()
+	done := make(chan error)
+	go func() { done <- c.StreamKeys(stream) }()
+
+	stream.reqs <- &pb.StreamKeysRequest{Window: 2}
+	assert.Equal(t, []string{"k1"}, vals(<-stream.sent))
+	assert.Equal(t, []string{"k2"}, vals(<-stream.sent))
+	close(stream.reqs)
+
+	require.NoError(t, <-done)
+}
+
+func TestController_StreamKeys_InvalidAck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	unusedRepo := key.NewMockUnusedKeysRepository(ctrl)
+	claimer := key.NewMockClaimer(ctrl)
+	claimer.EXPECT().ClaimN(gomock.Any(), int64(1), time.Hour).Return([]string{"k1"}, nil)
+	usedRepo.EXPECT().Delete(gomock.Any(), "k1").Return(true, nil)
+	unusedRepo.EXPECT().Store(gomock.Any(), "k1").Return(int64(1), nil)
+	c := New(defaultPool(key.New(time.Hour, usedRepo, unusedRepo, key.WithClaimer(claimer))))
+
+	stream := newFakeStream()
+	done := make(chan error)
+	go func() { done <- c.StreamKeys(stream) }()
+
+	stream.reqs <- &pb.StreamKeysRequest{Window: 1}
+	<-stream.sent
+	stream.reqs <- &pb.StreamKeysRequest{Ack: 2}
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(<-done))
+}
+
+func TestController_StreamKeys_SendErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := key.NewMockUsedKeysRepository(ctrl)
+	unusedRepo := key.NewMockUnusedKeysRepository(ctrl)
+	claimer := key.NewMockClaimer(ctrl)
+	claimer.EXPECT().ClaimN(gomock.Any(), int64(1), time.Hour).Return([]string{"k1"}, nil)
+	// the key isn't sent
+	usedRepo.EXPECT().Delete(gomock.Any(), "k1").Return(true, nil)
+	unusedRepo.EXPECT().Store(gomock.Any(), "k1").Return(int64(1), nil)
+	c := New(defaultPool(key.New(time.Hour, usedRepo, unusedRepo, key.WithClaimer(claimer))))
+
+	stream := newFakeStream()
+	stream.sendErr = errors.New("test err")
+	stream.reqs <- &pb.StreamKeysRequest{Window: 1}
+
+	assert.ErrorIs(t, c.StreamKeys(stream), stream.sendErr)
+}
+
+func TestController_StreamKeys_InvalidArgument(t *testing.T) {
+	c := New(defaultPool(key.New(time.Hour, nil, nil)))
+
+	stream := newFakeStream()
+	stream.reqs <- &pb.StreamKeysRequest{}
+	assert.Equal(t, codes.InvalidArgument, status.Code(c.StreamKeys(stream)))
+
+	stream = newFakeStream()
+	stream.reqs <- &pb.StreamKeysRequest{Window: key.DefaultMaxBatchSize + 1}
+	assert.Equal(t, codes.InvalidArgument, status.Code(c.StreamKeys(stream)))
+
+	stream = newFakeStream()
+	stream.reqs <- &pb.StreamKeysRequest{Window: 1, Pool: "unknown"}
+	assert.Equal(t, codes.NotFound, status.Code(c.StreamKeys(stream)))
+}
+
+// fakeStream is a server stream of StreamKeys. Closing reqs closes the client side of the stream.
+type fakeStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	sendErr error
+	reqs    chan *pb.StreamKeysRequest
+	sent    chan *pb.StreamKeysResponse
+}
+
+func newFakeStream() *fakeStream {
+	return &fakeStream{
+		ctx:  context.Background(),
+		reqs: make(chan *pb.StreamKeysRequest, 1),
+		sent: make(chan *pb.StreamKeysResponse),
+	}
+}
+
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeStream) Send(resp *pb.StreamKeysResponse) error {
+	if s.sendErr != nil {
+		return s.sendErr
+	}
+	s.sent <- resp
+	return nil
+}
+
+func (s *fakeStream) Recv() (*pb.StreamKeysRequest, error) {
+	req, ok := <-s.reqs
+	if !ok {
+		return nil, io.EOF
+	}
+	return req, nil
+}
+
+func vals(resp *pb.StreamKeysResponse) []string {
+	result := make([]string, 0, len(resp.GetKeys()))
+	for _, k := range resp.GetKeys() {
+		result = append(result, k.GetVal())
+	}
+	return result
+}
@@ -0,0 +1,166 @@
+package key
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// lookalikes folds leetspeak look-alikes and separators, so "5h1t", "sh-it" and "SHIT" are the same word.
+var lookalikes = strings.NewReplacer(
+	"0", "o",
+	"1", "i",
+	"l", "i",
+	"!", "i",
+	"3", "e",
+	"4", "a",
+	"@", "a",
+	"5", "s",
+	"$", "s",
+	"7", "t",
+	"8", "b",
+	"9", "g",
+	"-", "",
+	"_", "",
+)
+
+// Blocklist checks keys for blocked words (e.g. profanity or trademarks).
+// A nil Blocklist blocks nothing.
+type Blocklist struct {
+	modTime time.Time
+	blocked metric.Int64Counter
+	path    string
+	words   []string
+	mu      sync.RWMutex
+}
+
+// NewBlocklist creates a new Blocklist of the words.
+func NewBlocklist(words ...string) *Blocklist {
+	b := &Blocklist{blocked: blockedCounter()}
+	b.set(words)
+	return b
+}
+
+// LoadBlocklist creates a new Blocklist of the words from the file.
+// The file contains a word per line. Empty lines and lines starting with # are skipped.
+func LoadBlocklist(path string) (*Blocklist, error) {
+	b := &Blocklist{blocked: blockedCounter(), path: path}
+	if _, err := b.reload(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Watch reloads the blocklist from its file every interval if the file has been modified.
+// It blocks until ctx is done. The current words are kept if the file can't be loaded.
+func (b *Blocklist) Watch(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			reloaded, err := b.reload()
+			if err != nil {
+				slog.Error("failed reload blocklist", slog.String("path", b.path), slog.Any("err", err))
+				continue
+			}
+			if reloaded {
+				slog.Info("reloaded blocklist", slog.String("path", b.path))
+			}
+		}
+	}
+}
+
+// Blocked reports whether the key contains any blocked word.
+// The check is case-insensitive and treats leetspeak look-alikes (e.g. 0 and o, 1 and i) as the same letters.
+// Every blocked key is counted in the keygen.keys.blocked metric.
+func (b *Blocklist) Blocked(ctx context.Context, k string) bool {
+	if b == nil {
+		return false
+	}
+	normalized := normalize(k)
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, w := range b.words {
+		if strings.Contains(normalized, w) {
+			b.blocked.Add(ctx, 1)
+			return true
+		}
+	}
+	return false
+}
+
+// reload loads the words from the file if it has been modified since the last load.
+func (b *Blocklist) reload() (bool, error) {
+	info, err := os.Stat(b.path)
+	if err != nil {
+		return false, fmt.Errorf("failed stat blocklist file: %w", err)
+	}
+	b.mu.RLock()
+	modTime := b.modTime
+	b.mu.RUnlock()
+	if info.ModTime().Equal(modTime) {
+		return false, nil
+	}
+
+	f, err := os.Open(b.path) //nolint:gosec // the path is set by the service configuration
+	if err != nil {
+		return false, fmt.Errorf("failed open blocklist file: %w", err)
+	}
+	defer f.Close()
+	var words []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := s.Err(); err != nil {
+		return false, fmt.Errorf("failed read blocklist file: %w", err)
+	}
+
+	b.set(words)
+	b.mu.Lock()
+	b.modTime = info.ModTime()
+	b.mu.Unlock()
+	return true, nil
+}
+
+func (b *Blocklist) set(words []string) {
+	normalized := make([]string, 0, len(words))
+	for _, w := range words {
+		if w = normalize(w); w != "" {
+			normalized = append(normalized, w)
+		}
+	}
+	b.mu.Lock()
+	b.words = normalized
+	b.mu.Unlock()
+}
+
+func normalize(s string) string {
+	return lookalikes.Replace(strings.ToLower(s))
+}
+
+func blockedCounter() metric.Int64Counter {
+	c, err := otel.Meter("github.com/demeero/pocket-link/keygen/key").
+		Int64Counter("keygen.keys.blocked", metric.WithDescription("Number of keys rejected by the blocklist"))
+	if err != nil {
+		slog.Error("failed create blocked keys counter", slog.Any("err", err))
+		return noop.Int64Counter{}
+	}
+	return c
+}
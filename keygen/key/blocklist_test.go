@@ -0,0 +1,63 @@
+package key
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlocklist_Blocked(t *testing.T) {
+	b := NewBlocklist("shit", "Acme")
+	ctx := context.Background()
+
+	for _, k := range []string{"shit", "xxSHITxx", "5h1t", "sh-it", "$HlT", "acme", "4CM3xyz", "zz@cme"} {
+		assert.True(t, b.Blocked(ctx, k), k)
+	}
+	for _, k := range []string{"shot", "sh1p", "ac-mo", "zzzzzz"} {
+		assert.False(t, b.Blocked(ctx, k), k)
+	}
+}
+
+func TestBlocklist_Blocked_Nil(t *testing.T) {
+	var b *Blocklist
+	assert.False(t, b.Blocked(context.Background(), "shit"))
+}
+
+func TestLoadBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# profanity\nshit\n\n  acme  \n"), 0o600))
+
+	b, err := LoadBlocklist(path)
+	require.NoError(t, err)
+	assert.True(t, b.Blocked(context.Background(), "5h1t"))
+	assert.True(t, b.Blocked(context.Background(), "xACMEx"))
+	assert.False(t, b.Blocked(context.Background(), "profanity"))
+}
+
+func TestLoadBlocklist_NotExists(t *testing.T) {
+	b, err := LoadBlocklist(filepath.Join(t.TempDir(), "blocklist.txt"))
+	assert.Error(t, err)
+	assert.Nil(t, b)
+}
+
+func TestBlocklist_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("shit\n"), 0o600))
+	b, err := LoadBlocklist(path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.Watch(ctx, 10*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(path, []byte("acme\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	assert.Eventually(t, func() bool {
+		return b.Blocked(ctx, "acme") && !b.Blocked(ctx, "shit")
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,81 @@
+package key
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/demeero/bricks/errbrick"
+	"github.com/demeero/bricks/slogbrick"
+)
+
+// Claimer moves random keys from unused keys to used keys with their issue time like UsedKeysRepository.Store.
+// A key must be either fully claimed or still free after Claim or ClaimN returns.
+// Claim returns errbrick.ErrNotFound if there are no unused keys
+// and errbrick.ErrConflict if the taken key is already used.
+// ClaimN skips taken keys that are already used, so it can return fewer than n keys.
+// It returns errbrick.ErrNotFound if there are no unused keys.
+//
+//go:generate mockgen -destination=claimer_mock.go -package=key github.com/demeero/pocket-link/keygen/key Claimer
+type Claimer interface {
+	Claim(ctx context.Context, ttl time.Duration) (string, error)
+	ClaimN(ctx context.Context, n int64, ttl time.Duration) ([]string, error)
+}
+
+// repoClaimer is a fallback Claimer for repositories that can't move a key atomically (e.g. used keys live in MongoDB).
+// If the key can't be stored as used, it is returned back to unused keys.
+type repoClaimer struct {
+	used   UsedKeysRepository
+	unused UnusedKeysRepository
+}
+
+func (c *repoClaimer) Claim(ctx context.Context, ttl time.Duration) (string, error) {
+	loadedKey, err := c.unused.LoadAndDelete(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed load key: %w", err)
+	}
+
+	stored, err := c.used.Store(ctx, loadedKey, ttl)
+	if err != nil {
+		c.giveBack(ctx, loadedKey)
+		return "", fmt.Errorf("failed store key: %w", err)
+	}
+	if !stored {
+		return "", fmt.Errorf("%w: key already exist", errbrick.ErrConflict)
+	}
+	return loadedKey, nil
+}
+
+func (c *repoClaimer) ClaimN(ctx context.Context, n int64, ttl time.Duration) ([]string, error) {
+	loadedKeys, err := c.unused.LoadAndDeleteN(ctx, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed load keys: %w", err)
+	}
+
+	claimed := make([]string, 0, len(loadedKeys))
+	for i, k := range loadedKeys {
+		stored, err := c.used.Store(ctx, k, ttl)
+		if err != nil {
+			c.giveBack(ctx, loadedKeys[i:]...)
+			if len(claimed) > 0 {
+				// claimed keys are already used - they can't be lost
+				slogbrick.FromCtx(ctx).Error("failed store key - return claimed keys", slog.Any("err", err))
+				return claimed, nil
+			}
+			return nil, fmt.Errorf("failed store key: %w", err)
+		}
+		if stored {
+			claimed = append(claimed, k)
+		}
+	}
+	return claimed, nil
+}
+
+// giveBack returns keys to unused keys.
+// The caller's context may be already done - the keys must be returned anyway.
+func (c *repoClaimer) giveBack(ctx context.Context, keys ...string) {
+	if _, err := c.unused.Store(context.WithoutCancel(ctx), keys...); err != nil {
+		slogbrick.FromCtx(ctx).Error("failed return keys to unused keys", slog.Any("keys", keys), slog.Any("err", err))
+	}
+}
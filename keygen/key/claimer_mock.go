@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/demeero/pocket-link/keygen/key (interfaces: Claimer)
+
+// Package key is a generated GoMock package.
+package key
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockClaimer is a mock of Claimer interface.
+type MockClaimer struct {
+	ctrl     *gomock.Controller
+	recorder *MockClaimerMockRecorder
+}
+
+// MockClaimerMockRecorder is the mock recorder for MockClaimer.
+type MockClaimerMockRecorder struct {
+	mock *MockClaimer
+}
+
+// NewMockClaimer creates a new mock instance.
+func NewMockClaimer(ctrl *gomock.Controller) *MockClaimer {
+	mock := &MockClaimer{ctrl: ctrl}
+	mock.recorder = &MockClaimerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClaimer) EXPECT() *MockClaimerMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockClaimer) Claim(arg0 context.Context, arg1 time.Duration) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockClaimerMockRecorder) Claim(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockClaimer)(nil).Claim), arg0, arg1)
+}
+
+// ClaimN mocks base method.
+func (m *MockClaimer) ClaimN(arg0 context.Context, arg1 int64, arg2 time.Duration) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimN", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimN indicates an expected call of ClaimN.
+func (mr *MockClaimerMockRecorder) ClaimN(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimN", reflect.TypeOf((*MockClaimer)(nil).ClaimN), arg0, arg1, arg2)
+}
@@ -0,0 +1,168 @@
+package key
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/demeero/bricks/errbrick"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoClaimer_Claim(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	ctx := context.Background()
+
+	unusedRepo.EXPECT().LoadAndDelete(ctx).Return(testKey, nil)
+	usedRepo.EXPECT().Store(ctx, testKey, time.Hour).Return(true, nil)
+	c := &repoClaimer{used: usedRepo, unused: unusedRepo}
+
+	actual, err := c.Claim(ctx, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, testKey, actual)
+}
+
+func TestRepoClaimer_Claim_AlreadyUsed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	ctx := context.Background()
+
+	unusedRepo.EXPECT().LoadAndDelete(ctx).Return(testKey, nil)
+	usedRepo.EXPECT().Store(ctx, testKey, time.Hour).Return(false, nil)
+	c := &repoClaimer{used: usedRepo, unused: unusedRepo}
+
+	actual, err := c.Claim(ctx, time.Hour)
+	assert.ErrorIs(t, err, errbrick.ErrConflict)
+	assert.Empty(t, actual)
+}
+
+func TestRepoClaimer_Claim_StoreErr_ReturnsKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	testErr := errors.New("test err")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	unusedRepo.EXPECT().LoadAndDelete(ctx).Return(testKey, nil)
+	usedRepo.EXPECT().Store(ctx, testKey, time.Hour).DoAndReturn(func(context.Context, string, time.Duration) (bool, error) {
+		// the caller gives up between the two steps
+		cancel()
+		return false, testErr
+	})
+	unusedRepo.EXPECT().Store(gomock.Any(), testKey).DoAndReturn(func(ctx context.Context, _ ...string) (int64, error) {
+		assert.NoError(t, ctx.Err())
+		return 1, nil
+	})
+	c := &repoClaimer{used: usedRepo, unused: unusedRepo}
+
+	actual, err := c.Claim(ctx, time.Hour)
+	assert.ErrorIs(t, err, testErr)
+	assert.Empty(t, actual)
+}
+
+func TestRepoClaimer_Claim_ReturnKeyErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	testErr := errors.New("test err")
+	ctx := context.Background()
+
+	unusedRepo.EXPECT().LoadAndDelete(ctx).Return(testKey, nil)
+	usedRepo.EXPECT().Store(ctx, testKey, time.Hour).Return(false, testErr)
+	unusedRepo.EXPECT().Store(gomock.Any(), testKey).Return(int64(0), errors.New("return err"))
+	c := &repoClaimer{used: usedRepo, unused: unusedRepo}
+
+	actual, err := c.Claim(ctx, time.Hour)
+	assert.ErrorIs(t, err, testErr)
+	assert.Empty(t, actual)
+}
+
+func TestRepoClaimer_ClaimN(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+
+	unusedRepo.EXPECT().LoadAndDeleteN(ctx, int64(3)).Return([]string{"k1", "k2", "k3"}, nil)
+	usedRepo.EXPECT().Store(ctx, "k1", time.Hour).Return(true, nil)
+	usedRepo.EXPECT().Store(ctx, "k2", time.Hour).Return(false, nil)
+	usedRepo.EXPECT().Store(ctx, "k3", time.Hour).Return(true, nil)
+	c := &repoClaimer{used: usedRepo, unused: unusedRepo}
+
+	actual, err := c.ClaimN(ctx, 3, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k1", "k3"}, actual)
+}
+
+func TestRepoClaimer_ClaimN_NoFreeKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+
+	unusedRepo.EXPECT().LoadAndDeleteN(ctx, int64(3)).Return(nil, errbrick.ErrNotFound)
+	c := &repoClaimer{used: usedRepo, unused: unusedRepo}
+
+	actual, err := c.ClaimN(ctx, 3, time.Hour)
+	assert.ErrorIs(t, err, errbrick.ErrNotFound)
+	assert.Empty(t, actual)
+}
+
+func TestRepoClaimer_ClaimN_StoreErr_ReturnsRestKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+
+	unusedRepo.EXPECT().LoadAndDeleteN(ctx, int64(3)).Return([]string{"k1", "k2", "k3"}, nil)
+	usedRepo.EXPECT().Store(ctx, "k1", time.Hour).Return(true, nil)
+	usedRepo.EXPECT().Store(ctx, "k2", time.Hour).Return(false, errors.New("test err"))
+	unusedRepo.EXPECT().Store(gomock.Any(), "k2", "k3").Return(int64(2), nil)
+	c := &repoClaimer{used: usedRepo, unused: unusedRepo}
+
+	actual, err := c.ClaimN(ctx, 3, time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k1"}, actual)
+}
+
+func TestRepoClaimer_ClaimN_StoreErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+	testErr := errors.New("test err")
+
+	unusedRepo.EXPECT().LoadAndDeleteN(ctx, int64(2)).Return([]string{"k1", "k2"}, nil)
+	usedRepo.EXPECT().Store(ctx, "k1", time.Hour).Return(false, testErr)
+	unusedRepo.EXPECT().Store(gomock.Any(), "k1", "k2").Return(int64(2), nil)
+	c := &repoClaimer{used: usedRepo, unused: unusedRepo}
+
+	actual, err := c.ClaimN(ctx, 2, time.Hour)
+	assert.ErrorIs(t, err, testErr)
+	assert.Empty(t, actual)
+}
@@ -0,0 +1,164 @@
+package key
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// demandRelayInterval is a minimum interval between reports of a follower, so a follower that uses keys all the time
+// publishes a report per interval instead of a report per key.
+const demandRelayInterval = 100 * time.Millisecond
+
+// DemandRelay passes demand of followers to the leader, since only the leader runs the generator.
+type DemandRelay interface {
+	// Publish reports keys used by the replica: the number of used keys and whether it found no unused keys.
+	Publish(ctx context.Context, used int64, exhausted bool) error
+	// Subscribe calls fn with reports of replicas until ctx is done.
+	Subscribe(ctx context.Context, fn func(used int64, exhausted bool)) error
+}
+
+// Demand signals the generator to refill unused keys before the scheduled check.
+// Keys reports every used key, so the number of unused keys can be estimated without asking the repository.
+// The generator is woken up when the estimation falls below the low watermark or when there are no unused keys at all.
+// If replicas share unused keys, followers report used keys to the leader by Relay.
+// A nil Demand signals nothing.
+type Demand struct {
+	wake             chan struct{}
+	reports          chan struct{}
+	estimated        atomic.Int64
+	pendingUsed      atomic.Int64
+	lowWatermark     int64
+	relayed          atomic.Bool
+	leading          atomic.Bool
+	pendingExhausted atomic.Bool
+}
+
+// NewDemand creates a new Demand with the low watermark of unused keys.
+func NewDemand(lowWatermark int64) *Demand {
+	return &Demand{
+		wake:         make(chan struct{}, 1),
+		reports:      make(chan struct{}, 1),
+		lowWatermark: lowWatermark,
+	}
+}
+
+// Used reports n used keys.
+func (d *Demand) Used(n int64) {
+	if d == nil {
+		return
+	}
+	if d.follows() {
+		d.pendingUsed.Add(n)
+		notify(d.reports)
+		return
+	}
+	d.use(n)
+}
+
+// Exhausted reports that there are no unused keys.
+func (d *Demand) Exhausted() {
+	if d == nil {
+		return
+	}
+	if d.follows() {
+		d.pendingExhausted.Store(true)
+		notify(d.reports)
+		return
+	}
+	d.exhaust()
+}
+
+// Wake returns a channel that receives when unused keys should be refilled.
+func (d *Demand) Wake() <-chan struct{} {
+	if d == nil {
+		return nil
+	}
+	return d.wake
+}
+
+// Relay publishes demand of the replica while it follows and applies demand of followers while it leads.
+// It blocks until ctx is done.
+func (d *Demand) Relay(ctx context.Context, relay DemandRelay) {
+	d.relayed.Store(true)
+	go func() {
+		err := relay.Subscribe(ctx, func(used int64, exhausted bool) {
+			// reports are only needed by the generator
+			if !d.leading.Load() {
+				return
+			}
+			d.use(used)
+			if exhausted {
+				d.exhaust()
+			}
+		})
+		if err != nil {
+			slog.Error("failed subscribe to demand of followers", slog.Any("err", err))
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.reports:
+		}
+		used, exhausted := d.pendingUsed.Swap(0), d.pendingExhausted.Swap(false)
+		if err := relay.Publish(ctx, used, exhausted); err != nil {
+			slog.Error("failed publish demand to leader", slog.Int64("used", used), slog.Any("err", err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(demandRelayInterval):
+		}
+	}
+}
+
+// setLeading sets whether the generator of the replica runs, so the demand is handled locally.
+func (d *Demand) setLeading(leading bool) {
+	if d == nil {
+		return
+	}
+	d.leading.Store(leading)
+}
+
+// follows reports whether the demand is handled by the leader.
+func (d *Demand) follows() bool {
+	return d.relayed.Load() && !d.leading.Load()
+}
+
+func (d *Demand) use(n int64) {
+	if d.estimated.Add(-n) < d.lowWatermark {
+		notify(d.wake)
+	}
+}
+
+func (d *Demand) exhaust() {
+	d.estimated.Store(0)
+	notify(d.wake)
+}
+
+// setSize sets the actual number of unused keys.
+func (d *Demand) setSize(size int64) {
+	if d == nil {
+		return
+	}
+	d.estimated.Store(size)
+}
+
+// added reports n keys added to unused keys.
+func (d *Demand) added(n int64) {
+	if d == nil {
+		return
+	}
+	d.estimated.Add(n)
+}
+
+// notify sends to the channel without blocking - a pending signal is enough.
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
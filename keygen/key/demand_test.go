@@ -0,0 +1,94 @@
+package key
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDemand_Used(t *testing.T) {
+	d := NewDemand(5)
+	d.setSize(10)
+
+	d.Used(5)
+	assert.Empty(t, d.Wake())
+
+	d.Used(1)
+	assert.Len(t, d.Wake(), 1)
+	// a pending signal is enough
+	d.Used(1)
+	assert.Len(t, d.Wake(), 1)
+}
+
+func TestDemand_Exhausted(t *testing.T) {
+	d := NewDemand(0)
+	d.setSize(10)
+
+	d.Exhausted()
+	assert.Len(t, d.Wake(), 1)
+}
+
+func TestDemand_Nil(t *testing.T) {
+	var d *Demand
+	d.Used(1)
+	d.Exhausted()
+	assert.Nil(t, d.Wake())
+}
+
+func TestDemand_Relay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	relay := &localRelay{}
+	leader, follower := NewDemand(5), NewDemand(5)
+	leader.setSize(10)
+	leader.setLeading(true)
+	go leader.Relay(ctx, relay)
+	go follower.Relay(ctx, relay)
+	require.Eventually(t, func() bool { return relay.subscribers() == 2 }, time.Second, time.Millisecond)
+
+	follower.Used(3)
+	require.Eventually(t, func() bool { return leader.estimated.Load() == 7 }, time.Second, time.Millisecond)
+	assert.Empty(t, leader.Wake())
+
+	follower.Used(3)
+	require.Eventually(t, func() bool { return len(leader.Wake()) == 1 }, time.Second, time.Millisecond)
+	<-leader.Wake()
+
+	follower.Exhausted()
+	require.Eventually(t, func() bool { return len(leader.Wake()) == 1 }, time.Second, time.Millisecond)
+	// the follower doesn't run the generator
+	assert.Empty(t, follower.Wake())
+}
+
+// localRelay passes reports between demands of the same process.
+type localRelay struct {
+	subs []func(int64, bool)
+	mu   sync.Mutex
+}
+
+func (r *localRelay) Publish(_ context.Context, used int64, exhausted bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, fn := range r.subs {
+		fn(used, exhausted)
+	}
+	return nil
+}
+
+func (r *localRelay) Subscribe(ctx context.Context, fn func(int64, bool)) error {
+	r.mu.Lock()
+	r.subs = append(r.subs, fn)
+	r.mu.Unlock()
+	<-ctx.Done()
+	return nil
+}
+
+func (r *localRelay) subscribers() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.subs)
+}
@@ -0,0 +1,79 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/demeero/pocket-link/keygen/key (interfaces: Elector)
+
+// Package key is a generated GoMock package.
+package key
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockElector is a mock of Elector interface.
+type MockElector struct {
+	ctrl     *gomock.Controller
+	recorder *MockElectorMockRecorder
+}
+
+// MockElectorMockRecorder is the mock recorder for MockElector.
+type MockElectorMockRecorder struct {
+	mock *MockElector
+}
+
+// NewMockElector creates a new mock instance.
+func NewMockElector(ctrl *gomock.Controller) *MockElector {
+	mock := &MockElector{ctrl: ctrl}
+	mock.recorder = &MockElectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockElector) EXPECT() *MockElectorMockRecorder {
+	return m.recorder
+}
+
+// Acquire mocks base method.
+func (m *MockElector) Acquire(arg0 context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Acquire", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Acquire indicates an expected call of Acquire.
+func (mr *MockElectorMockRecorder) Acquire(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Acquire", reflect.TypeOf((*MockElector)(nil).Acquire), arg0)
+}
+
+// Release mocks base method.
+func (m *MockElector) Release(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockElectorMockRecorder) Release(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockElector)(nil).Release), arg0)
+}
+
+// Renew mocks base method.
+func (m *MockElector) Renew(arg0 context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Renew", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Renew indicates an expected call of Renew.
+func (mr *MockElectorMockRecorder) Renew(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Renew", reflect.TypeOf((*MockElector)(nil).Renew), arg0)
+}
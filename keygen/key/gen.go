@@ -2,86 +2,263 @@ package key
 
 import (
 	"context"
-	"crypto/rand"
 	"fmt"
 	"log/slog"
-	"math/big"
+	"math"
 	"time"
 )
 
 var letterRunes = []rune("-_1234567890abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
 
+// DefaultGenBatchSize is a default number of keys that are generated in a single batch.
+const DefaultGenBatchSize = 1000
+
+// candidateAttempts is a maximum number of random keys generated per requested candidate,
+// so a batch ends up smaller instead of looping forever if the keyspace has fewer keys than requested.
+const candidateAttempts = 10
+
+// minRateWindow is a minimum period to measure the consumption rate of keys.
+// Shorter periods (e.g. between wake-ups by Demand) make the rate too noisy.
+const minRateWindow = time.Second
+
+// rateSmoothing is a weight of the last measured consumption rate in the smoothed one.
+const rateSmoothing = 0.5
+
 // GeneratorConfig is a configuration for key generator.
 type GeneratorConfig struct {
-	// PredefinedKeysCount is a number of keys that should be generated in advance.
+	// Strategy generates random keys. DefaultStrategy is used if it's nil.
+	Strategy KeyStrategy
+	// Sequence generates keys that are unique by construction instead of Strategy.
+	// Such keys aren't checked against used keys. It's optional.
+	Sequence *SequenceStrategy
+	// Blocklist rejects generated keys with blocked words. Keys aren't checked if it's nil.
+	Blocklist *Blocklist
+	// Demand wakes the generator up before the next check if unused keys run low. It's optional.
+	Demand *Demand
+	// Usage counts collisions of generated keys. It's optional.
+	Usage *Usage
+	// Saturation detects saturation of the keyspace and grows KeyLen. It's optional.
+	Saturation *Saturation
+	// PredefinedKeysCount is a minimum number of keys that should be generated in advance.
 	PredefinedKeysCount uint
-	// Delay is a delay between key generation.
+	// MaxKeysCount is a maximum number of keys that can be generated in advance. It's unlimited if zero.
+	MaxKeysCount uint
+	// BatchSize is a number of keys that are generated in a single batch. DefaultGenBatchSize is used if it's zero.
+	BatchSize uint
+	// Delay is a delay between checks of unused keys.
 	Delay time.Duration
+	// BufferTime is how long unused keys should last at the current consumption rate.
+	// Unused keys are refilled up to the greater of PredefinedKeysCount and the rate multiplied by BufferTime.
+	BufferTime time.Duration
 	// KeyLen is a length of generated keys.
 	KeyLen uint8
 }
 
 // Generate generates keys by specified GeneratorConfig.
+// Unused keys are checked at start, every Delay and whenever Demand wakes the generator up.
 func Generate(ctx context.Context, cfg GeneratorConfig, used UsedKeysRepository, unused UnusedKeysRepository) {
+	if cfg.Strategy == nil {
+		cfg.Strategy = DefaultStrategy
+	}
 	t := time.NewTicker(cfg.Delay)
+	defer t.Stop()
+	// demand of followers is relayed to the replica that generates keys
+	cfg.Demand.setLeading(true)
+	defer cfg.Demand.setLeading(false)
+	// the key length may have been grown by the previous leader
+	cfg.Saturation.load(ctx)
+	c := &consumption{}
 	for {
+		refill(ctx, cfg, c, used, unused)
 		select {
 		case <-ctx.Done():
-			t.Stop()
 			return
 		case <-t.C:
-			size, err := unused.Size(ctx)
-			if err != nil {
-				slog.Error("failed get unused keys size", slog.Any("err", err))
-				continue
-			}
-			if size > int64(cfg.PredefinedKeysCount) {
-				continue
-			}
-			n := cfg.PredefinedKeysCount/10 + 1
-			gen(ctx, int(n), int(cfg.KeyLen), used, unused)
+		case <-cfg.Demand.Wake():
 		}
 	}
 }
 
-func gen(ctx context.Context, n, keyLen int, used UsedKeysRepository, unused UnusedKeysRepository) {
-	for i := 0; i < n; {
-		rndKey, err := randStringRunes(keyLen)
+func refill(ctx context.Context, cfg GeneratorConfig, c *consumption, used UsedKeysRepository, unused UnusedKeysRepository) {
+	size, err := unused.Size(ctx)
+	if err != nil {
+		slog.Error("failed get unused keys size", slog.Any("err", err))
+		return
+	}
+	cfg.Demand.setSize(size)
+	n := refillSize(cfg, size, c.observe(size, time.Now()))
+	if n == 0 {
+		return
+	}
+	stored := gen(ctx, n, cfg, used, unused)
+	c.added(stored)
+	cfg.Demand.added(int64(stored))
+}
+
+// refillSize returns a number of keys that should be generated to fill unused keys up to the target size.
+func refillSize(cfg GeneratorConfig, size int64, rate float64) int {
+	target := int64(cfg.PredefinedKeysCount)
+	if byRate := int64(math.Ceil(rate * cfg.BufferTime.Seconds())); byRate > target {
+		target = byRate
+	}
+	if cfg.MaxKeysCount > 0 && target > int64(cfg.MaxKeysCount) {
+		target = int64(cfg.MaxKeysCount)
+	}
+	if size >= target {
+		return 0
+	}
+	return int(target - size)
+}
+
+// consumption measures the consumption rate of keys by changes of unused keys size.
+type consumption struct {
+	checkedAt time.Time
+	// size is the expected size of unused keys if no keys were used since checkedAt.
+	size int64
+	// rate is the smoothed number of used keys per second.
+	rate float64
+}
+
+// observe takes the actual size of unused keys and returns the consumption rate.
+func (c *consumption) observe(size int64, now time.Time) float64 {
+	if c.checkedAt.IsZero() {
+		c.checkedAt, c.size = now, size
+		return c.rate
+	}
+	elapsed := now.Sub(c.checkedAt)
+	if elapsed < minRateWindow {
+		return c.rate
+	}
+	usedKeys := c.size - size
+	if usedKeys < 0 {
+		// keys were added by another generator
+		usedKeys = 0
+	}
+	c.rate = rateSmoothing*float64(usedKeys)/elapsed.Seconds() + (1-rateSmoothing)*c.rate
+	c.checkedAt, c.size = now, size
+	return c.rate
+}
+
+// added reports n keys added to unused keys.
+func (c *consumption) added(n int) {
+	c.size += int64(n)
+}
+
+// gen generates n keys in batches and returns the number of stored ones - it's less than n if generation fails.
+// Every batch takes one round trip to check used keys and one round trip to store new keys.
+func gen(ctx context.Context, n int, cfg GeneratorConfig, used UsedKeysRepository, unused UnusedKeysRepository) int {
+	batchSize := int(cfg.BatchSize)
+	if batchSize == 0 {
+		batchSize = DefaultGenBatchSize
+	}
+	stored := 0
+	for stored < n && ctx.Err() == nil {
+		added, err := genBatch(ctx, min(n-stored, batchSize), cfg, used, unused)
 		if err != nil {
-			slog.Error("failed get random string", slog.Any("err", err))
+			slog.Error("failed generate keys", slog.Any("err", err))
 			break
 		}
-		existed, err := used.Exists(ctx, rndKey)
-		if err != nil {
-			slog.Error("failed check used key existence", slog.Any("err", err))
+		if added == 0 {
+			// every generated key collides (e.g. the keyspace of the length is saturated and can't grow),
+			// so the next batch is unlikely to do better - the generator tries again on the next check
+			slog.Warn("no new keys are generated - keyspace may be saturated", slog.Int("stored", stored), slog.Int("requested", n))
 			break
 		}
-		if existed {
-			slog.Debug("key already exists in used keys repository - try another one", slog.String("key", rndKey))
+		stored += int(added)
+	}
+	return stored
+}
+
+// genBatch generates up to n keys and returns the number of stored ones.
+// Generated keys that are duplicated or already used or unused are collisions - they are replaced in the next batch.
+func genBatch(ctx context.Context, n int, cfg GeneratorConfig, used UsedKeysRepository, unused UnusedKeysRepository) (int64, error) {
+	keyLen := cfg.Saturation.KeyLen(cfg.KeyLen)
+	if cfg.Sequence != nil {
+		return genSequence(ctx, n, keyLen, cfg, unused)
+	}
+	candidates, duplicates, err := genCandidates(ctx, n, keyLen, cfg)
+	if err != nil {
+		return 0, fmt.Errorf("failed get random string: %w", err)
+	}
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+	existed, err := used.ExistsMany(ctx, candidates)
+	if err != nil {
+		return 0, fmt.Errorf("failed check used keys existence: %w", err)
+	}
+	fresh := make([]string, 0, len(candidates))
+	for i, k := range candidates {
+		if existed[i] {
+			slog.Debug("key already exists in used keys repository - try another one", slog.String("key", k))
 			continue
 		}
-		stored, err := unused.Store(ctx, rndKey)
-		if err != nil {
-			slog.Error("failed store new key", slog.Any("err", err))
-			break
+		fresh = append(fresh, k)
+	}
+	var added int64
+	if len(fresh) > 0 {
+		if added, err = unused.Store(ctx, fresh...); err != nil {
+			return 0, fmt.Errorf("failed store new keys: %w", err)
 		}
-		if stored == 0 {
-			slog.Debug("key already exists in unused keys repository - try another one", slog.String("key", rndKey))
+		slog.Debug("stored new keys", slog.Int64("count", added))
+	}
+	collisions := duplicates + int64(len(candidates)) - added
+	cfg.Usage.collided(collisions)
+	cfg.Saturation.observe(ctx, duplicates+int64(len(candidates)), collisions, keyLen)
+	return added, nil
+}
+
+// genSequence generates up to n keys by the sequence and returns the number of stored ones.
+// Keys with blocked words are skipped and replaced in the next batch.
+// Keys of the sequence are never used before (a reserved one is rejected by Claimer), so they are stored right away.
+func genSequence(ctx context.Context, n int, keyLen uint8, cfg GeneratorConfig, unused UnusedKeysRepository) (int64, error) {
+	keys, err := cfg.Sequence.Keys(ctx, n, keyLen)
+	if err != nil {
+		return 0, fmt.Errorf("failed get sequence keys: %w", err)
+	}
+	allowed := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if cfg.Blocklist.Blocked(ctx, k) {
+			slog.Debug("key contains blocked word - try another one", slog.String("key", k))
 			continue
 		}
-		slog.Debug("stored new key", slog.String("key", rndKey))
-		i++
+		allowed = append(allowed, k)
+	}
+	if len(allowed) == 0 {
+		return 0, nil
+	}
+	added, err := unused.Store(ctx, allowed...)
+	if err != nil {
+		return 0, fmt.Errorf("failed store new keys: %w", err)
 	}
+	slog.Debug("stored new keys", slog.Int64("count", added))
+	return added, nil
 }
 
-func randStringRunes(n int) (string, error) {
-	b := make([]rune, n)
-	for i := range b {
-		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(letterRunes))))
+// genCandidates generates up to n unique random keys of the length without blocked words.
+// It gives up after candidateAttempts random keys per requested one. It also returns the number of generated duplicates.
+func genCandidates(ctx context.Context, n int, keyLen uint8, cfg GeneratorConfig) ([]string, int64, error) {
+	candidates := make([]string, 0, n)
+	seen := make(map[string]struct{}, n)
+	var duplicates int64
+	for attempts := 0; len(candidates) < n && attempts < n*candidateAttempts; attempts++ {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		rndKey, err := cfg.Strategy.Key(int(keyLen))
 		if err != nil {
-			return "", fmt.Errorf("failed pick random letter: %w", err)
+			return nil, 0, err
+		}
+		if cfg.Blocklist.Blocked(ctx, rndKey) {
+			slog.Debug("key contains blocked word - try another one", slog.String("key", rndKey))
+			continue
+		}
+		if _, ok := seen[rndKey]; ok {
+			duplicates++
+			continue
 		}
-		b[i] = letterRunes[idx.Int64()]
+		seen[rndKey] = struct{}{}
+		candidates = append(candidates, rndKey)
 	}
-	return string(b), nil
+	return candidates, duplicates, nil
 }
@@ -2,10 +2,20 @@ package key
 
 import (
 	"context"
+	"errors"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/demeero/bricks/errbrick"
 	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	redisrepo "github.com/demeero/pocket-link/keygen/repository/redis"
 )
 
 func TestGenerate(t *testing.T) {
@@ -15,20 +25,17 @@ func TestGenerate(t *testing.T) {
 	usedRepo := NewMockUsedKeysRepository(ctrl)
 	unusedRepo := NewMockUnusedKeysRepository(ctrl)
 
-	size := 0
 	predefinedKeysCount := 20
-	step := predefinedKeysCount/10 + 1
 	ctx, cancel := context.WithCancel(context.Background())
-	unusedRepo.EXPECT().Size(ctx).DoAndReturn(func(context.Context) (int64, error) {
-		result := size
-		size += step
-		if size >= predefinedKeysCount {
+	gomock.InOrder(
+		unusedRepo.EXPECT().Size(ctx).Return(int64(0), nil),
+		unusedRepo.EXPECT().Size(ctx).DoAndReturn(func(context.Context) (int64, error) {
 			cancel()
-		}
-		return int64(result), nil
-	}).AnyTimes()
-	unusedRepo.EXPECT().Store(ctx, gomock.Any()).Return(int64(1), nil).Times(21)
-	usedRepo.EXPECT().Exists(ctx, gomock.Any()).Return(false, nil).Times(21)
+			return int64(predefinedKeysCount), nil
+		}),
+	)
+	usedRepo.EXPECT().ExistsMany(ctx, gomock.Len(predefinedKeysCount)).Return(make([]bool, predefinedKeysCount), nil)
+	unusedRepo.EXPECT().Store(ctx, gomock.Any()).Return(int64(predefinedKeysCount), nil)
 
 	Generate(ctx, GeneratorConfig{
 		PredefinedKeysCount: uint(predefinedKeysCount),
@@ -36,3 +43,408 @@ func TestGenerate(t *testing.T) {
 		KeyLen:              8,
 	}, usedRepo, unusedRepo)
 }
+
+func TestGenerate_BurstyDemand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	pool := &fakePool{keys: map[string]struct{}{}}
+	unusedRepo.EXPECT().Size(gomock.Any()).DoAndReturn(pool.Size).AnyTimes()
+	unusedRepo.EXPECT().Store(gomock.Any(), gomock.Any()).DoAndReturn(pool.Store).AnyTimes()
+	unusedRepo.EXPECT().LoadAndDelete(gomock.Any()).DoAndReturn(pool.LoadAndDelete).AnyTimes()
+	usedRepo.EXPECT().ExistsMany(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, keys []string) ([]bool, error) {
+		return make([]bool, len(keys)), nil
+	}).AnyTimes()
+	usedRepo.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+
+	demand := NewDemand(5)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// the ticker never fires during the test - unused keys are refilled only by Demand
+		Generate(ctx, GeneratorConfig{
+			PredefinedKeysCount: 10,
+			Delay:               time.Hour,
+			KeyLen:              8,
+			Demand:              demand,
+		}, usedRepo, unusedRepo)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	require.Eventually(t, func() bool {
+		size, _ := pool.Size(ctx)
+		return size == 10
+	}, time.Second, time.Millisecond)
+
+	keys := New(time.Hour, usedRepo, unusedRepo, WithDemand(demand))
+	for burst := 0; burst < 3; burst++ {
+		for i := 0; i < 25; i++ {
+			actual, err := keys.Use(ctx, 0, Metadata{})
+			require.NoError(t, err)
+			assert.NotEmpty(t, actual.Val)
+		}
+	}
+	assert.Greater(t, pool.SizeCalls(), 3)
+}
+
+func TestRefillSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      GeneratorConfig
+		size     int64
+		rate     float64
+		expected int
+	}{
+		{
+			name:     "empty",
+			cfg:      GeneratorConfig{PredefinedKeysCount: 100},
+			expected: 100,
+		},
+		{
+			name:     "full",
+			cfg:      GeneratorConfig{PredefinedKeysCount: 100},
+			size:     100,
+			expected: 0,
+		},
+		{
+			name:     "low rate",
+			cfg:      GeneratorConfig{PredefinedKeysCount: 100, BufferTime: time.Minute},
+			size:     40,
+			rate:     1,
+			expected: 60,
+		},
+		{
+			name:     "high rate",
+			cfg:      GeneratorConfig{PredefinedKeysCount: 100, BufferTime: time.Minute},
+			size:     40,
+			rate:     10,
+			expected: 560,
+		},
+		{
+			name:     "high rate without buffer time",
+			cfg:      GeneratorConfig{PredefinedKeysCount: 100},
+			size:     40,
+			rate:     10,
+			expected: 60,
+		},
+		{
+			name:     "max keys count",
+			cfg:      GeneratorConfig{PredefinedKeysCount: 100, MaxKeysCount: 300, BufferTime: time.Minute},
+			size:     40,
+			rate:     10,
+			expected: 260,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, refillSize(tt.cfg, tt.size, tt.rate))
+		})
+	}
+}
+
+func TestConsumption_Observe(t *testing.T) {
+	c := &consumption{}
+	now := time.Now()
+
+	assert.Zero(t, c.observe(100, now))
+	// too short period - the rate isn't measured
+	assert.Zero(t, c.observe(90, now.Add(100*time.Millisecond)))
+	// 40 keys are used in 2s
+	assert.InDelta(t, 10, c.observe(60, now.Add(2*time.Second)), 0.01)
+	c.added(40)
+	// burst - 100 keys are used in 1s
+	assert.InDelta(t, 55, c.observe(0, now.Add(3*time.Second)), 0.01)
+	// keys were added by another generator
+	assert.InDelta(t, 27.5, c.observe(50, now.Add(4*time.Second)), 0.01)
+}
+
+func TestGen_Blocklist(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+
+	usedRepo.EXPECT().ExistsMany(ctx, []string{"good"}).Return([]bool{false}, nil)
+	unusedRepo.EXPECT().Store(ctx, "good").Return(int64(1), nil)
+
+	gen(ctx, 1, GeneratorConfig{
+		Strategy:  &stubStrategy{keys: []string{"5h1t", "good"}},
+		Blocklist: NewBlocklist("shit"),
+	}, usedRepo, unusedRepo)
+}
+
+func TestGen_Batches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+
+	gomock.InOrder(
+		usedRepo.EXPECT().ExistsMany(ctx, []string{"k1", "k2"}).Return([]bool{false, true}, nil),
+		unusedRepo.EXPECT().Store(ctx, "k1").Return(int64(1), nil),
+		usedRepo.EXPECT().ExistsMany(ctx, []string{"k3", "k4"}).Return([]bool{false, false}, nil),
+		// k3 already exists in unused keys
+		unusedRepo.EXPECT().Store(ctx, "k3", "k4").Return(int64(1), nil),
+		usedRepo.EXPECT().ExistsMany(ctx, []string{"k5"}).Return([]bool{false}, nil),
+		unusedRepo.EXPECT().Store(ctx, "k5").Return(int64(1), nil),
+	)
+
+	usage := NewUsage()
+	actual := gen(ctx, 3, GeneratorConfig{
+		Strategy:  &stubStrategy{keys: []string{"k1", "k1", "k2", "k3", "k4", "k5"}},
+		BatchSize: 2,
+		Usage:     usage,
+	}, usedRepo, unusedRepo)
+	assert.Equal(t, 3, actual)
+	// k1 is generated twice, k2 is used and k3 is unused
+	assert.Equal(t, int64(3), usage.Collisions())
+}
+
+func TestGen_StoreErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+
+	usedRepo.EXPECT().ExistsMany(ctx, []string{"k1", "k2"}).Return([]bool{false, false}, nil)
+	unusedRepo.EXPECT().Store(ctx, "k1", "k2").Return(int64(0), errors.New("test err"))
+
+	actual := gen(ctx, 2, GeneratorConfig{Strategy: &stubStrategy{keys: []string{"k1", "k2"}}}, usedRepo, unusedRepo)
+	assert.Zero(t, actual)
+}
+
+func TestGen_Saturation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+
+	gomock.InOrder(
+		// half of keys of the configured length are used
+		usedRepo.EXPECT().ExistsMany(ctx, gomock.Len(saturationSample)).DoAndReturn(func(_ context.Context, keys []string) ([]bool, error) {
+			result := make([]bool, len(keys))
+			for i := range result {
+				result[i] = i%2 == 0
+			}
+			return result, nil
+		}),
+		unusedRepo.EXPECT().Store(ctx, gomock.Any()).Return(int64(saturationSample/2), nil),
+		usedRepo.EXPECT().ExistsMany(ctx, gomock.Len(saturationSample/2)).Return(make([]bool, saturationSample/2), nil),
+		unusedRepo.EXPECT().Store(ctx, gomock.Any()).Return(int64(saturationSample/2), nil),
+	)
+
+	strategy := &lenStrategy{}
+	saturation := NewSaturation("", 0.1, 3, nil)
+	actual := gen(ctx, saturationSample, GeneratorConfig{
+		Strategy:   strategy,
+		Saturation: saturation,
+		KeyLen:     2,
+	}, usedRepo, unusedRepo)
+	assert.Equal(t, saturationSample, actual)
+	assert.Equal(t, uint8(3), saturation.KeyLen(2))
+	assert.Equal(t, saturationSample, strategy.lens[2])
+	assert.Equal(t, saturationSample/2, strategy.lens[3])
+}
+
+func TestGen_NoNewKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+
+	// all keys are used - the next batch isn't generated
+	usedRepo.EXPECT().ExistsMany(ctx, []string{"k1", "k2"}).Return([]bool{true, true}, nil)
+
+	actual := gen(ctx, 2, GeneratorConfig{Strategy: &stubStrategy{keys: []string{"k1", "k2"}}}, usedRepo, unusedRepo)
+	assert.Zero(t, actual)
+}
+
+func TestGenCandidates_SmallKeyspace(t *testing.T) {
+	// the keyspace has 2 keys only
+	candidates, duplicates, err := genCandidates(context.Background(), 10, 1, GeneratorConfig{Strategy: NewAlphabetStrategy("ab")})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b"}, candidates)
+	assert.Equal(t, int64(10*candidateAttempts-2), duplicates)
+}
+
+func TestGenCandidates_CtxDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := genCandidates(ctx, 10, 5, GeneratorConfig{Strategy: DefaultStrategy})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestGen_Sequence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// used keys aren't checked
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	pool := &fakePool{keys: map[string]struct{}{}}
+	unusedRepo.EXPECT().Store(gomock.Any(), gomock.Any()).DoAndReturn(pool.Store).Times(3)
+
+	actual := gen(context.Background(), 25, GeneratorConfig{
+		Sequence:  NewSequenceStrategy(&memSequence{}, string(letterRunes), []byte("secret")),
+		BatchSize: 10,
+		KeyLen:    4,
+	}, usedRepo, unusedRepo)
+	assert.Equal(t, 25, actual)
+	assert.Len(t, pool.keys, 25)
+}
+
+// lenStrategy returns unique keys and counts requested lengths.
+type lenStrategy struct {
+	lens map[int]int
+	n    int
+}
+
+func (s *lenStrategy) Key(n int) (string, error) {
+	if s.lens == nil {
+		s.lens = map[int]int{}
+	}
+	s.lens[n]++
+	s.n++
+	return strconv.Itoa(s.n), nil
+}
+
+// stubStrategy returns predefined keys one by one.
+type stubStrategy struct {
+	keys []string
+}
+
+func (s *stubStrategy) Key(int) (string, error) {
+	k := s.keys[0]
+	s.keys = s.keys[1:]
+	return k, nil
+}
+
+func BenchmarkGen(b *testing.B) {
+	const keysCount = 10000
+	cfg := GeneratorConfig{Strategy: DefaultStrategy, KeyLen: 10}
+
+	b.Run("one by one", func(b *testing.B) {
+		used, unused := newMiniredisRepos(b)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			genOneByOne(context.Background(), keysCount, cfg, used, unused)
+		}
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		used, unused := newMiniredisRepos(b)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			gen(context.Background(), keysCount, cfg, used, unused)
+		}
+	})
+}
+
+// genOneByOne is the previous implementation of gen that makes two round trips for every key.
+func genOneByOne(ctx context.Context, n int, cfg GeneratorConfig, used UsedKeysRepository, unused UnusedKeysRepository) {
+	for i := 0; i < n; {
+		rndKey, err := cfg.Strategy.Key(int(cfg.KeyLen))
+		if err != nil {
+			return
+		}
+		existed, err := used.Exists(ctx, rndKey)
+		if err != nil {
+			return
+		}
+		if existed {
+			continue
+		}
+		stored, err := unused.Store(ctx, rndKey)
+		if err != nil {
+			return
+		}
+		if stored == 0 {
+			continue
+		}
+		i++
+	}
+}
+
+func newMiniredisRepos(b *testing.B) (*redisrepo.UsedKeys, *redisrepo.UnusedKeys) {
+	b.Helper()
+	mr := miniredis.RunT(b)
+	used := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	unused := redis.NewClient(&redis.Options{Addr: mr.Addr(), DB: 1})
+	return redisrepo.NewUsedKeys(used, ""), redisrepo.NewUnusedKeys(unused, "")
+}
+
+// fakePool keeps unused keys in memory to simulate a real repository with mocks.
+type fakePool struct {
+	keys      map[string]struct{}
+	sizeCalls int
+	mu        sync.Mutex
+}
+
+func (p *fakePool) Size(context.Context) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sizeCalls++
+	return int64(len(p.keys)), nil
+}
+
+func (p *fakePool) SizeCalls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sizeCalls
+}
+
+func (p *fakePool) Store(_ context.Context, keys ...string) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var stored int64
+	for _, k := range keys {
+		if _, ok := p.keys[k]; !ok {
+			p.keys[k] = struct{}{}
+			stored++
+		}
+	}
+	return stored, nil
+}
+
+func (p *fakePool) LoadAndDeleteN(_ context.Context, n int64) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result := make([]string, 0, n)
+	for k := range p.keys {
+		if int64(len(result)) == n {
+			break
+		}
+		delete(p.keys, k)
+		result = append(result, k)
+	}
+	if len(result) == 0 {
+		return nil, errbrick.ErrNotFound
+	}
+	return result, nil
+}
+
+func (p *fakePool) LoadAndDelete(context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for k := range p.keys {
+		delete(p.keys, k)
+		return k, nil
+	}
+	return "", errbrick.ErrNotFound
+}
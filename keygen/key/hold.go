@@ -0,0 +1,163 @@
+package key
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/demeero/bricks/errbrick"
+)
+
+// holdGrace is a margin after the hold timeout before an unconfirmed key is returned,
+// so a confirmation that is in flight at the deadline isn't raced.
+const holdGrace = time.Second
+
+// holds are keys taken by Hold in the order they were taken.
+// They live in memory of the replica - if it stops, unconfirmed keys just expire in used keys.
+type holds struct {
+	entries []hold
+	mu      sync.Mutex
+}
+
+type hold struct {
+	deadline time.Time
+	val      string
+}
+
+func (h *holds) add(val string, deadline time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, hold{val: val, deadline: deadline})
+}
+
+// take removes the hold of the key and reports whether the key was held.
+func (h *holds) take(val string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, e := range h.entries {
+		if e.val == val {
+			h.entries = append(h.entries[:i], h.entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// expired removes and returns the keys held until before t.
+func (h *holds) expired(t time.Time) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := 0
+	for n < len(h.entries) && h.entries[n].deadline.Before(t) {
+		n++
+	}
+	result := make([]string, 0, n)
+	for _, e := range h.entries[:n] {
+		result = append(result, e.val)
+	}
+	h.entries = h.entries[n:]
+	return result
+}
+
+// Hold takes a key like Use, but keeps it used only for the hold timeout until it's confirmed by Confirm
+// (e.g. after the link behind the key is saved). It returns the key that expires after ttl once it's confirmed
+// and the deadline of the confirmation. Unconfirmed keys are returned to unused keys by ReturnUnconfirmed.
+// The metadata is recorded with the key if it's not empty.
+func (k *Keys) Hold(ctx context.Context, ttl time.Duration, md Metadata) (Key, time.Time, error) {
+	ttl, err := k.validateTTL(ttl)
+	if err != nil {
+		return Key{}, time.Time{}, err
+	}
+	now := time.Now()
+	val, err := k.claim(ctx, k.holdTimeout)
+	if err != nil {
+		return Key{}, time.Time{}, err
+	}
+	if err := k.annotate(ctx, val, md, now); err != nil {
+		return Key{}, time.Time{}, err
+	}
+	deadline := now.Add(k.holdTimeout)
+	k.holds.add(val, deadline)
+	return Key{Val: val, ExpiresAt: now.Add(ttl)}, deadline, nil
+}
+
+// Confirm keeps the held key used until expiresAt.
+// If the key isn't confirmed in time, it's taken again unless somebody else uses it, but its metadata is lost.
+// Only keys held by this instance are taken again, since Confirm must not take arbitrary values.
+// It returns errbrick.ErrInvalidData if expiresAt is not in the future or exceeds the maximum TTL,
+// errbrick.ErrNotFound if the key isn't held and errbrick.ErrConflict if the key is used by somebody else.
+func (k *Keys) Confirm(ctx context.Context, val string, expiresAt time.Time) (Key, error) {
+	if err := k.validateExpiresAt(expiresAt); err != nil {
+		return Key{}, err
+	}
+	extended, err := k.used.Extend(ctx, val, expiresAt)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed extend used key: %w", err)
+	}
+	if extended {
+		return Key{Val: val, ExpiresAt: expiresAt}, nil
+	}
+	// the hold has expired - the key is taken again only if it hasn't been returned to unused keys yet
+	if !k.holds.take(val) {
+		return Key{}, fmt.Errorf("%w: key is not held: %s", errbrick.ErrNotFound, val)
+	}
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return Key{}, fmt.Errorf("%w: expiration time has passed: %s", errbrick.ErrInvalidData, expiresAt.Format(time.RFC3339))
+	}
+	stored, err := k.used.Store(ctx, val, ttl)
+	if err != nil {
+		// the key goes back to unused keys by ReturnUnconfirmed unless it's used
+		k.holds.add(val, time.Time{})
+		return Key{}, fmt.Errorf("failed store used key: %w", err)
+	}
+	if !stored {
+		return Key{}, fmt.Errorf("%w: key is used by somebody else: %s", errbrick.ErrConflict, val)
+	}
+	return Key{Val: val, ExpiresAt: expiresAt}, nil
+}
+
+// ReturnUnconfirmed returns keys that weren't confirmed in time to unused keys every interval.
+// It blocks until ctx is done.
+func (k *Keys) ReturnUnconfirmed(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := k.returnUnconfirmed(ctx, time.Now()); err != nil {
+				slog.Error("failed return unconfirmed keys", slog.Any("err", err))
+			}
+		}
+	}
+}
+
+// returnUnconfirmed returns keys held until before now that are no longer used - confirmed keys are still used.
+func (k *Keys) returnUnconfirmed(ctx context.Context, now time.Time) error {
+	vals := k.holds.expired(now.Add(-holdGrace))
+	if len(vals) == 0 {
+		return nil
+	}
+	exist, err := k.used.ExistsMany(ctx, vals)
+	if err != nil {
+		return fmt.Errorf("failed check used keys: %w", err)
+	}
+	unconfirmed := make([]string, 0, len(vals))
+	for i, val := range vals {
+		if !exist[i] {
+			unconfirmed = append(unconfirmed, val)
+		}
+	}
+	if len(unconfirmed) == 0 {
+		return nil
+	}
+	if _, err := k.unused.Store(ctx, unconfirmed...); err != nil {
+		return fmt.Errorf("failed store unused keys: %w", err)
+	}
+	slog.Info("returned unconfirmed keys", slog.Int("count", len(unconfirmed)))
+	return nil
+}
@@ -0,0 +1,196 @@
+package key
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/demeero/bricks/errbrick"
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+
+	boltrepo "github.com/demeero/pocket-link/keygen/repository/bolt"
+	redisrepo "github.com/demeero/pocket-link/keygen/repository/redis"
+)
+
+// TestKeys_Hold_ClientCrash simulates a client that crashes between Hold and Confirm:
+// its key goes back to unused keys after the hold timeout, while the confirmed key stays used.
+func TestKeys_Hold_ClientCrash(t *testing.T) {
+	mr := miniredis.RunT(t)
+	used := redisrepo.NewUsedKeys(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "")
+	unused := redisrepo.NewUnusedKeys(redis.NewClient(&redis.Options{Addr: mr.Addr(), DB: 1}), "")
+	ctx := context.Background()
+	_, err := unused.Store(ctx, "k1", "k2")
+	require.NoError(t, err)
+	keys := New(time.Hour, used, unused, WithHoldTimeout(time.Minute))
+
+	crashed, deadline, err := keys.Hold(ctx, 0, Metadata{})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+	confirmed, _, err := keys.Hold(ctx, 0, Metadata{})
+	require.NoError(t, err)
+	_, err = keys.Confirm(ctx, confirmed.Val, confirmed.ExpiresAt)
+	require.NoError(t, err)
+
+	mr.FastForward(2 * time.Minute)
+	require.NoError(t, keys.returnUnconfirmed(ctx, time.Now().Add(2*time.Minute)))
+
+	size, err := unused.Size(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), size)
+	returned, err := unused.LoadAndDelete(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, crashed.Val, returned)
+	ok, err := used.Exists(ctx, confirmed.Val)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+// TestKeys_Hold_LazyExpiry checks that unconfirmed keys are returned by a repository
+// that keeps expired keys until they are swept.
+func TestKeys_Hold_LazyExpiry(t *testing.T) {
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "keygen.db"), 0o600, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+	used, err := boltrepo.NewUsedKeys(db, "")
+	require.NoError(t, err)
+	unused, err := boltrepo.NewUnusedKeys(db, "")
+	require.NoError(t, err)
+	ctx := context.Background()
+	_, err = unused.Store(ctx, "k1", "k2")
+	require.NoError(t, err)
+	keys := New(time.Hour, used, unused, WithHoldTimeout(10*time.Millisecond))
+
+	crashed, _, err := keys.Hold(ctx, 0, Metadata{})
+	require.NoError(t, err)
+	confirmed, _, err := keys.Hold(ctx, 0, Metadata{})
+	require.NoError(t, err)
+	_, err = keys.Confirm(ctx, confirmed.Val, confirmed.ExpiresAt)
+	require.NoError(t, err)
+
+	// the hold of the crashed client expires, but the sweeper doesn't run
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, keys.returnUnconfirmed(ctx, time.Now().Add(holdGrace)))
+
+	returned, err := unused.LoadAndDeleteN(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{crashed.Val}, returned)
+}
+
+func TestKeys_Hold_NotExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := NewMockClaimer(ctrl)
+	claimer.EXPECT().Claim(gomock.Any(), time.Minute).Return("k1", nil)
+	keys := New(time.Hour, nil, nil, WithClaimer(claimer))
+
+	k, _, err := keys.Hold(context.Background(), 2*time.Hour, Metadata{})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), k.ExpiresAt, time.Second)
+
+	// the hold isn't checked before the deadline
+	require.NoError(t, keys.returnUnconfirmed(context.Background(), time.Now()))
+}
+
+func TestKeys_Hold_InvalidTTL(t *testing.T) {
+	keys := New(time.Hour, nil, nil)
+	_, _, err := keys.Hold(context.Background(), time.Second, Metadata{})
+	assert.ErrorIs(t, err, errbrick.ErrInvalidData)
+}
+
+func TestKeys_Confirm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expiresAt := time.Now().Add(time.Hour)
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	usedRepo.EXPECT().Extend(gomock.Any(), "k1", expiresAt).Return(true, nil)
+	keys := New(time.Hour, usedRepo, nil)
+
+	actual, err := keys.Confirm(context.Background(), "k1", expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, Key{Val: "k1", ExpiresAt: expiresAt}, actual)
+}
+
+func TestKeys_Confirm_HoldExpired_Retake(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expiresAt := time.Now().Add(time.Hour)
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	usedRepo.EXPECT().Extend(gomock.Any(), "k1", expiresAt).Return(false, nil)
+	usedRepo.EXPECT().Store(gomock.Any(), "k1", gomock.Any()).Return(true, nil)
+	keys := New(time.Hour, usedRepo, unusedRepo)
+	keys.holds.add("k1", time.Now().Add(-time.Second))
+
+	actual, err := keys.Confirm(context.Background(), "k1", expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, Key{Val: "k1", ExpiresAt: expiresAt}, actual)
+}
+
+func TestKeys_Confirm_HoldExpired_UsedBySomebodyElse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expiresAt := time.Now().Add(time.Hour)
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	usedRepo.EXPECT().Extend(gomock.Any(), "k1", expiresAt).Return(false, nil)
+	usedRepo.EXPECT().Store(gomock.Any(), "k1", gomock.Any()).Return(false, nil)
+	keys := New(time.Hour, usedRepo, unusedRepo)
+	keys.holds.add("k1", time.Now().Add(-time.Second))
+
+	_, err := keys.Confirm(context.Background(), "k1", expiresAt)
+	assert.ErrorIs(t, err, errbrick.ErrConflict)
+}
+
+func TestKeys_Confirm_NotHeld(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expiresAt := time.Now().Add(time.Hour)
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	usedRepo.EXPECT().Extend(gomock.Any(), "vanity", expiresAt).Return(false, nil)
+	keys := New(time.Hour, usedRepo, NewMockUnusedKeysRepository(ctrl))
+
+	// an arbitrary value isn't taken by confirmation
+	_, err := keys.Confirm(context.Background(), "vanity", expiresAt)
+	assert.ErrorIs(t, err, errbrick.ErrNotFound)
+}
+
+func TestKeys_Confirm_HoldExpired_StoreErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expiresAt := time.Now().Add(time.Hour)
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	usedRepo.EXPECT().Extend(gomock.Any(), "k1", expiresAt).Return(false, nil)
+	usedRepo.EXPECT().Store(gomock.Any(), "k1", gomock.Any()).Return(false, errors.New("test err"))
+	keys := New(time.Hour, usedRepo, unusedRepo)
+	keys.holds.add("k1", time.Now().Add(-time.Second))
+
+	_, err := keys.Confirm(context.Background(), "k1", expiresAt)
+	assert.Error(t, err)
+
+	// the key is still returned to unused keys
+	usedRepo.EXPECT().ExistsMany(gomock.Any(), []string{"k1"}).Return([]bool{false}, nil)
+	unusedRepo.EXPECT().Store(gomock.Any(), "k1").Return(int64(1), nil)
+	require.NoError(t, keys.returnUnconfirmed(context.Background(), time.Now()))
+}
+
+func TestKeys_Confirm_InvalidExpiresAt(t *testing.T) {
+	keys := New(time.Hour, nil, nil)
+	_, err := keys.Confirm(context.Background(), "k1", time.Now().Add(-time.Minute))
+	assert.ErrorIs(t, err, errbrick.ErrInvalidData)
+}
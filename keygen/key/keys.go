@@ -4,13 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
 	"time"
+	"unicode/utf8"
 
 	"github.com/avast/retry-go/v3"
 	"github.com/demeero/bricks/errbrick"
 	"github.com/demeero/bricks/slogbrick"
 )
 
+// ErrExhausted is returned by Use when there are no free keys after all retries.
+var ErrExhausted = errors.New("no free keys")
+
 // Key is a key for short link.
 type Key struct {
 	ExpiresAt time.Time
@@ -22,7 +28,9 @@ type Key struct {
 //go:generate mockgen -destination=unused_keys_mock.go -package=key github.com/demeero/pocket-link/keygen/key UnusedKeysRepository
 type UnusedKeysRepository interface {
 	LoadAndDelete(context.Context) (string, error)
+	LoadAndDeleteN(ctx context.Context, n int64) ([]string, error)
 	Store(context.Context, ...string) (int64, error)
+	Delete(context.Context, string) (bool, error)
 	Size(ctx context.Context) (int64, error)
 }
 
@@ -30,47 +38,261 @@ type UnusedKeysRepository interface {
 //
 //go:generate mockgen -destination=used_keys_mock.go -package=key github.com/demeero/pocket-link/keygen/key UsedKeysRepository
 type UsedKeysRepository interface {
+	// Store stores the key with its issue time in metadata (see Lookup), so every used key has it.
+	// It returns false if the key is already used.
 	Store(ctx context.Context, key string, ttl time.Duration) (bool, error)
 	Exists(context.Context, string) (bool, error)
+	// ExistsMany checks existence of every key in one round trip. The result is in the order of keys.
+	// Expired keys don't exist even if the repository hasn't deleted them yet - unconfirmed holds rely on it.
+	ExistsMany(ctx context.Context, keys []string) ([]bool, error)
+	Delete(context.Context, string) (bool, error)
+	Extend(ctx context.Context, key string, expiresAt time.Time) (bool, error)
+	// Count returns the number of used keys that haven't expired.
+	Count(ctx context.Context) (int64, error)
+	// Annotate replaces metadata of the used key without changing its expiration time.
+	// It returns false if the key is not used.
+	Annotate(ctx context.Context, key string, md map[string]string) (bool, error)
+	// Lookup returns metadata and the expiration time of the used key. Metadata is nil if it's not recorded.
+	// It returns errbrick.ErrNotFound if the key is not used.
+	Lookup(ctx context.Context, key string) (map[string]string, time.Time, error)
+}
+
+const (
+	// DefaultMaxBatchSize is a default maximum number of keys that can be used at once.
+	DefaultMaxBatchSize = 1000
+	// DefaultMinReservedLen is a default minimum length of reserved key.
+	DefaultMinReservedLen = 4
+	// DefaultMaxReservedLen is a default maximum length of reserved key.
+	DefaultMaxReservedLen = 32
+	// DefaultMinTTL is a default minimum TTL that can be requested for a key.
+	DefaultMinTTL = time.Minute
+	// DefaultMaxTTL is a default maximum TTL that can be requested for a key.
+	DefaultMaxTTL = 365 * 24 * time.Hour
+	// DefaultHoldTimeout is a default time a held key waits for confirmation.
+	DefaultHoldTimeout = time.Minute
+	// DefaultUsedCountMaxAge is a default time the number of used keys is cached for by Stats.
+	DefaultUsedCountMaxAge = time.Minute
+)
+
+// Retry is a policy of retrying Use when the taken key is already used or there are no free keys.
+type Retry struct {
+	// Attempts is a maximum number of attempts including the first one.
+	Attempts uint
+	// Delay is a delay before the first retry. It doubles with every next retry.
+	Delay time.Duration
+	// MaxDelay caps the delay between retries. The delay isn't capped if it's zero.
+	MaxDelay time.Duration
+	// MaxJitter is a maximum random time added to every delay, so concurrent callers don't retry at the same moment.
+	MaxJitter time.Duration
+}
+
+// DefaultRetry is a default policy of retrying Use. It gives up in about a second.
+var DefaultRetry = Retry{
+	Attempts:  5,
+	Delay:     50 * time.Millisecond,
+	MaxDelay:  500 * time.Millisecond,
+	MaxJitter: 50 * time.Millisecond,
 }
 
 // Keys is a service for generating keys.
 type Keys struct {
-	used   UsedKeysRepository
-	unused UnusedKeysRepository
-	ttl    time.Duration
+	used              UsedKeysRepository
+	unused            UnusedKeysRepository
+	claimer           Claimer
+	blocklist         *Blocklist
+	demand            *Demand
+	usage             *Usage
+	prefetch          *prefetcher
+	alphabet          []rune
+	usedCount         usedCount
+	holds             holds
+	retry             Retry
+	ttl               time.Duration
+	minTTL            time.Duration
+	maxTTL            time.Duration
+	holdTimeout       time.Duration
+	usedCountMaxAge   time.Duration
+	maxBatchSize      int64
+	prefetchSize      int64
+	minReservedLen    int
+	maxReservedLen    int
+	prefetchThreshold int
+}
+
+// Option is an optional configuration of Keys.
+type Option func(*Keys)
+
+// WithClaimer sets the Claimer that moves keys from unused keys to used keys.
+// By default, keys are moved by the repositories with returning the key back to unused keys on failure.
+func WithClaimer(c Claimer) Option {
+	return func(k *Keys) {
+		k.claimer = c
+	}
+}
+
+// WithBlocklist sets the Blocklist that rejects reserved keys with blocked words.
+func WithBlocklist(b *Blocklist) Option {
+	return func(k *Keys) {
+		k.blocklist = b
+	}
+}
+
+// WithDemand sets the Demand that is notified about used keys to wake the generator up.
+func WithDemand(d *Demand) Option {
+	return func(k *Keys) {
+		k.demand = d
+	}
+}
+
+// WithUsage sets the Usage that tracks issued keys for Stats.
+func WithUsage(u *Usage) Option {
+	return func(k *Keys) {
+		k.usage = u
+	}
+}
+
+// WithPrefetch enables the in-memory buffer of unused keys, so Use takes a single round trip to store the key as used.
+// The buffer takes size keys at once and it's refilled in the background when it has less than threshold keys.
+// Buffered keys are returned to unused keys by Close.
+func WithPrefetch(size int64, threshold int) Option {
+	return func(k *Keys) {
+		k.prefetchSize = size
+		k.prefetchThreshold = threshold
+	}
+}
+
+// WithMaxBatchSize sets the maximum number of keys that can be used at once by UseN.
+func WithMaxBatchSize(n int64) Option {
+	return func(k *Keys) {
+		k.maxBatchSize = n
+	}
+}
+
+// WithReservedLen sets the length limits of reserved keys.
+func WithReservedLen(minLen, maxLen int) Option {
+	return func(k *Keys) {
+		k.minReservedLen = minLen
+		k.maxReservedLen = maxLen
+	}
+}
+
+// WithAlphabet sets chars that reserved keys can consist of (e.g. the alphabet of the key strategy of the pool),
+// so a reserved key looks like a generated one. By default, chars of DefaultStrategy are allowed.
+func WithAlphabet(alphabet string) Option {
+	return func(k *Keys) {
+		k.alphabet = []rune(alphabet)
+	}
+}
+
+// WithRetry sets the policy of retrying Use.
+func WithRetry(r Retry) Option {
+	return func(k *Keys) {
+		k.retry = r
+	}
+}
+
+// WithHoldTimeout sets the time a key taken by Hold waits for confirmation.
+func WithHoldTimeout(d time.Duration) Option {
+	return func(k *Keys) {
+		k.holdTimeout = d
+	}
+}
+
+// WithUsedCountMaxAge sets the time the number of used keys is cached for by Stats,
+// since counting used keys may scan the whole repository. The number isn't cached if it's zero.
+func WithUsedCountMaxAge(d time.Duration) Option {
+	return func(k *Keys) {
+		k.usedCountMaxAge = d
+	}
+}
+
+// WithTTLBounds sets the bounds of TTL that can be requested for a key.
+func WithTTLBounds(minTTL, maxTTL time.Duration) Option {
+	return func(k *Keys) {
+		k.minTTL = minTTL
+		k.maxTTL = maxTTL
+	}
 }
 
 // New creates a new Keys.
-func New(ttl time.Duration, used UsedKeysRepository, unused UnusedKeysRepository) *Keys {
-	return &Keys{
-		ttl:    ttl,
-		used:   used,
-		unused: unused,
+func New(ttl time.Duration, used UsedKeysRepository, unused UnusedKeysRepository, opts ...Option) *Keys {
+	k := &Keys{
+		ttl:             ttl,
+		minTTL:          DefaultMinTTL,
+		maxTTL:          DefaultMaxTTL,
+		used:            used,
+		unused:          unused,
+		claimer:         &repoClaimer{used: used, unused: unused},
+		maxBatchSize:    DefaultMaxBatchSize,
+		minReservedLen:  DefaultMinReservedLen,
+		maxReservedLen:  DefaultMaxReservedLen,
+		retry:           DefaultRetry,
+		holdTimeout:     DefaultHoldTimeout,
+		usedCountMaxAge: DefaultUsedCountMaxAge,
+		alphabet:        letterRunes,
+	}
+	for _, opt := range opts {
+		opt(k)
+	}
+	if k.prefetchSize > 0 {
+		k.prefetch = newPrefetcher(used, unused, k.claimer, k.prefetchSize, k.prefetchThreshold)
+		k.claimer = k.prefetch
+	}
+	return k
+}
+
+// Close returns buffered keys to unused keys if the prefetch buffer is enabled.
+// Keys must not be used after Close.
+func (k *Keys) Close(ctx context.Context) error {
+	if k.prefetch == nil {
+		return nil
 	}
+	return k.prefetch.Close(ctx)
 }
 
-// Use returns a key for short link.
-func (k *Keys) Use(ctx context.Context) (Key, error) {
-	var result Key
+// Use returns a key for short link that expires after the given ttl.
+// If ttl is zero, the default TTL is used. The metadata is recorded with the key if it's not empty (see Lookup).
+// If the taken key is already used or there are no free keys, Use retries by the Retry policy.
+// It returns errbrick.ErrInvalidData if ttl is out of the configured bounds
+// and ErrExhausted if there are still no free keys after all retries.
+func (k *Keys) Use(ctx context.Context, ttl time.Duration, md Metadata) (Key, error) {
+	ttl, err := k.validateTTL(ttl)
+	if err != nil {
+		return Key{}, err
+	}
+	now := time.Now()
+	val, err := k.claim(ctx, ttl)
+	if err != nil {
+		return Key{}, err
+	}
+	if err := k.annotate(ctx, val, md, now); err != nil {
+		return Key{}, err
+	}
+	return Key{Val: val, ExpiresAt: now.Add(ttl)}, nil
+}
 
-	job := func() error {
-		loadedKey, err := k.unused.LoadAndDelete(ctx)
-		if err != nil {
-			return fmt.Errorf("failed load key: %w", err)
-		}
+// validateTTL returns the default TTL if ttl is zero.
+// It returns errbrick.ErrInvalidData if ttl is out of the configured bounds.
+func (k *Keys) validateTTL(ttl time.Duration) (time.Duration, error) {
+	if ttl == 0 {
+		return k.ttl, nil
+	}
+	if ttl < k.minTTL || ttl > k.maxTTL {
+		return 0, fmt.Errorf("%w: ttl must be in range [%s, %s]: %s", errbrick.ErrInvalidData, k.minTTL, k.maxTTL, ttl)
+	}
+	return ttl, nil
+}
 
-		expiresAt := time.Now().Add(k.ttl)
-		stored, err := k.used.Store(ctx, loadedKey, k.ttl)
+// claim moves a free key to used keys for ttl with retries by the Retry policy.
+func (k *Keys) claim(ctx context.Context, ttl time.Duration) (string, error) {
+	var result string
+
+	job := func() error {
+		loadedKey, err := k.claimer.Claim(ctx, ttl)
 		if err != nil {
-			return fmt.Errorf("failed store key: %w", err)
-		}
-		if !stored {
-			return fmt.Errorf("%w: key already exist", errbrick.ErrConflict)
+			return fmt.Errorf("failed claim key: %w", err)
 		}
-
-		result.ExpiresAt = expiresAt
-		result.Val = loadedKey
+		result = loadedKey
 		return nil
 	}
 
@@ -81,18 +303,184 @@ func (k *Keys) Use(ctx context.Context) (Key, error) {
 		}
 		if errors.Is(err, errbrick.ErrNotFound) {
 			slogbrick.FromCtx(ctx).Info("no free keys - retry")
+			k.demand.Exhausted()
 			return true
 		}
 		return false
 	}
 
+	delayType := retry.BackOffDelay
+	if k.retry.MaxJitter > 0 {
+		delayType = retry.CombineDelay(retry.BackOffDelay, retry.RandomDelay)
+	}
+	attempts := max(k.retry.Attempts, 1)
 	err := retry.Do(job,
 		retry.RetryIf(retryCond),
 		retry.Context(ctx),
 		retry.LastErrorOnly(true),
+		retry.Attempts(attempts),
+		retry.Delay(k.retry.Delay),
+		retry.MaxDelay(k.retry.MaxDelay),
+		retry.MaxJitter(k.retry.MaxJitter),
+		retry.DelayType(delayType),
 	)
+	if errors.Is(err, errbrick.ErrNotFound) || errors.Is(err, errbrick.ErrConflict) {
+		return "", fmt.Errorf("%w after %d attempts: %s", ErrExhausted, attempts, err)
+	}
 	if err != nil {
-		return Key{}, err
+		return "", err
 	}
+	k.demand.Used(1)
+	k.usage.issue(1, time.Now())
 	return result, nil
 }
+
+// MaxBatchSize returns the maximum number of keys that can be used at once by UseN.
+func (k *Keys) MaxBatchSize() int64 {
+	return k.maxBatchSize
+}
+
+// UseN returns up to n keys for short links.
+// If there are not enough free keys, it returns only the keys that were available.
+// It returns errbrick.ErrInvalidData if n is not positive or exceeds the configured maximum.
+func (k *Keys) UseN(ctx context.Context, n int64) ([]Key, error) {
+	if n <= 0 || n > k.maxBatchSize {
+		return nil, fmt.Errorf("%w: number of keys must be in range [1, %d]: %d", errbrick.ErrInvalidData, k.maxBatchSize, n)
+	}
+
+	expiresAt := time.Now().Add(k.ttl)
+	result := make([]Key, 0, n)
+	for int64(len(result)) < n {
+		claimed, err := k.claimer.ClaimN(ctx, n-int64(len(result)), k.ttl)
+		if errors.Is(err, errbrick.ErrNotFound) {
+			slogbrick.FromCtx(ctx).Info("no more free keys - return partial batch", slog.Int("size", len(result)))
+			k.demand.Exhausted()
+			break
+		}
+		if err != nil && len(result) == 0 {
+			return nil, fmt.Errorf("failed claim keys: %w", err)
+		}
+		for _, c := range claimed {
+			result = append(result, Key{Val: c, ExpiresAt: expiresAt})
+		}
+		if err != nil {
+			// keys that are already claimed can't be returned back - give them to the caller
+			slogbrick.FromCtx(ctx).Error("failed claim keys - return partial batch", slog.Int("size", len(result)), slog.Any("err", err))
+			break
+		}
+	}
+	k.demand.Used(int64(len(result)))
+	k.usage.issue(int64(len(result)), time.Now())
+	return result, nil
+}
+
+// Reserve stores the requested value (e.g. vanity alias) as used key.
+// It returns errbrick.ErrInvalidData if the value has unsupported length or chars or contains a blocked word
+// and errbrick.ErrConflict if the value is already used.
+func (k *Keys) Reserve(ctx context.Context, val string) (Key, error) {
+	if err := k.validateReserved(val); err != nil {
+		return Key{}, err
+	}
+	if k.blocklist.Blocked(ctx, val) {
+		return Key{}, fmt.Errorf("%w: key contains blocked word: %s", errbrick.ErrInvalidData, val)
+	}
+
+	existed, err := k.used.Exists(ctx, val)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed check used key existence: %w", err)
+	}
+	if existed {
+		return Key{}, fmt.Errorf("%w: key already used: %s", errbrick.ErrConflict, val)
+	}
+
+	expiresAt := time.Now().Add(k.ttl)
+	stored, err := k.used.Store(ctx, val, k.ttl)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed store key: %w", err)
+	}
+	if !stored {
+		return Key{}, fmt.Errorf("%w: key already used: %s", errbrick.ErrConflict, val)
+	}
+
+	// if the key stays in unused keys, it will be skipped as already used one when somebody tries to use it
+	if _, err := k.unused.Delete(ctx, val); err != nil {
+		slogbrick.FromCtx(ctx).Error("failed delete reserved key from unused keys", slog.String("key", val), slog.Any("err", err))
+	}
+	return Key{Val: val, ExpiresAt: expiresAt}, nil
+}
+
+func (k *Keys) validateReserved(val string) error {
+	l := utf8.RuneCountInString(val)
+	if l < k.minReservedLen || l > k.maxReservedLen {
+		return fmt.Errorf("%w: key length must be in range [%d, %d]: %d", errbrick.ErrInvalidData, k.minReservedLen, k.maxReservedLen, l)
+	}
+	for _, r := range val {
+		if !slices.Contains(k.alphabet, r) {
+			return fmt.Errorf("%w: key contains unsupported char: %q", errbrick.ErrInvalidData, r)
+		}
+	}
+	return nil
+}
+
+// Return moves issued keys that were never used back to unused keys (e.g. keys of a broken stream).
+// Keys that are no longer used (e.g. released or expired) aren't returned.
+func (k *Keys) Return(ctx context.Context, vals ...string) error {
+	returned := make([]string, 0, len(vals))
+	for _, val := range vals {
+		deleted, err := k.used.Delete(ctx, val)
+		if err != nil {
+			return fmt.Errorf("failed delete used key: %w", err)
+		}
+		if deleted {
+			returned = append(returned, val)
+		}
+	}
+	if len(returned) == 0 {
+		return nil
+	}
+	if _, err := k.unused.Store(ctx, returned...); err != nil {
+		return fmt.Errorf("failed store unused keys: %w", err)
+	}
+	return nil
+}
+
+// Release deletes the key from used keys before its expiration (e.g. the link behind the key was not saved).
+// The released key is not returned to unused keys - it can be generated again.
+// It returns errbrick.ErrNotFound if the key is not used.
+func (k *Keys) Release(ctx context.Context, val string) error {
+	deleted, err := k.used.Delete(ctx, val)
+	if err != nil {
+		return fmt.Errorf("failed delete used key: %w", err)
+	}
+	if !deleted {
+		return fmt.Errorf("%w: key is not used: %s", errbrick.ErrNotFound, val)
+	}
+	return nil
+}
+
+// Extend sets a new expiration time of the used key.
+// It returns errbrick.ErrInvalidData if expiresAt is not in the future or exceeds the maximum TTL
+// and errbrick.ErrNotFound if the key is not used (e.g. it has already expired).
+func (k *Keys) Extend(ctx context.Context, val string, expiresAt time.Time) (Key, error) {
+	if err := k.validateExpiresAt(expiresAt); err != nil {
+		return Key{}, err
+	}
+	extended, err := k.used.Extend(ctx, val, expiresAt)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed extend used key: %w", err)
+	}
+	if !extended {
+		return Key{}, fmt.Errorf("%w: key is not used: %s", errbrick.ErrNotFound, val)
+	}
+	return Key{Val: val, ExpiresAt: expiresAt}, nil
+}
+
+// validateExpiresAt returns errbrick.ErrInvalidData if the expiration time isn't in the future or exceeds the maximum TTL.
+func (k *Keys) validateExpiresAt(expiresAt time.Time) error {
+	now := time.Now()
+	if !expiresAt.After(now) || expiresAt.After(now.Add(k.maxTTL)) {
+		return fmt.Errorf("%w: expiration time must be in range (%s, %s]: %s",
+			errbrick.ErrInvalidData, now.Format(time.RFC3339), now.Add(k.maxTTL).Format(time.RFC3339), expiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/demeero/bricks/errbrick"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestKeys_Use(t *testing.T) {
@@ -24,7 +25,7 @@ func TestKeys_Use(t *testing.T) {
 	usedRepo.EXPECT().Store(ctx, testKey, gomock.Any()).Return(true, nil)
 	keys := New(time.Hour, usedRepo, unusedRepo)
 
-	actual, err := keys.Use(ctx)
+	actual, err := keys.Use(ctx, 0, Metadata{})
 	assert.Equal(t, testKey, actual.Val)
 	assert.NotZero(t, actual.ExpiresAt)
 	assert.NoError(t, err)
@@ -42,7 +43,7 @@ func TestKeys_Use_LoadAndDeleteUnexpectedErr(t *testing.T) {
 	unusedRepo.EXPECT().LoadAndDelete(ctx).Return("", testErr)
 	keys := New(time.Hour, usedRepo, unusedRepo)
 
-	actual, err := keys.Use(ctx)
+	actual, err := keys.Use(ctx, 0, Metadata{})
 	assert.Zero(t, actual)
 	assert.ErrorContains(t, err, testErr.Error())
 }
@@ -59,9 +60,10 @@ func TestKeys_Use_StoreUnexpectedErr(t *testing.T) {
 
 	unusedRepo.EXPECT().LoadAndDelete(ctx).Return(testKey, nil)
 	usedRepo.EXPECT().Store(ctx, testKey, gomock.Any()).Return(false, testErr)
+	unusedRepo.EXPECT().Store(gomock.Any(), testKey).Return(int64(1), nil)
 	keys := New(time.Hour, usedRepo, unusedRepo)
 
-	actual, err := keys.Use(ctx)
+	actual, err := keys.Use(ctx, 0, Metadata{})
 	assert.Zero(t, actual)
 	assert.Error(t, err, testErr.Error())
 }
@@ -80,7 +82,7 @@ func TestKeys_Use_NoFreeKeys_Retry(t *testing.T) {
 	usedRepo.EXPECT().Store(ctx, testKey, gomock.Any()).Return(true, nil)
 	keys := New(time.Hour, usedRepo, unusedRepo)
 
-	actual, err := keys.Use(ctx)
+	actual, err := keys.Use(ctx, 0, Metadata{})
 	assert.Equal(t, testKey, actual.Val)
 	assert.NotZero(t, actual.ExpiresAt)
 	assert.NoError(t, err)
@@ -102,12 +104,55 @@ func TestKeys_Use_KeyAlreadyUsed_Retry(t *testing.T) {
 	usedRepo.EXPECT().Store(ctx, testKey2, gomock.Any()).Return(true, nil)
 	keys := New(time.Hour, usedRepo, unusedRepo)
 
-	actual, err := keys.Use(ctx)
+	actual, err := keys.Use(ctx, 0, Metadata{})
 	assert.Equal(t, testKey2, actual.Val)
 	assert.NotZero(t, actual.ExpiresAt)
 	assert.NoError(t, err)
 }
 
+func TestKeys_Use_Exhausted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+
+	unusedRepo.EXPECT().LoadAndDelete(ctx).Return("", errbrick.ErrNotFound).Times(2)
+	unusedRepo.EXPECT().LoadAndDelete(ctx).Return("testKey1", nil)
+	// the last taken key is already used
+	usedRepo.EXPECT().Store(ctx, "testKey1", gomock.Any()).Return(false, nil)
+	keys := New(time.Hour, usedRepo, unusedRepo, WithRetry(Retry{Attempts: 3, Delay: time.Millisecond}))
+
+	actual, err := keys.Use(ctx, 0, Metadata{})
+	assert.Zero(t, actual)
+	assert.ErrorIs(t, err, ErrExhausted)
+	assert.NotErrorIs(t, err, errbrick.ErrConflict)
+}
+
+func TestKeys_Use_Backoff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+
+	var calls []time.Time
+	unusedRepo.EXPECT().LoadAndDelete(ctx).DoAndReturn(func(context.Context) (string, error) {
+		calls = append(calls, time.Now())
+		return "", errbrick.ErrNotFound
+	}).Times(4)
+	keys := New(time.Hour, nil, unusedRepo, WithRetry(Retry{Attempts: 4, Delay: 10 * time.Millisecond, MaxDelay: 20 * time.Millisecond}))
+
+	_, err := keys.Use(ctx, 0, Metadata{})
+	assert.ErrorIs(t, err, ErrExhausted)
+	// delays are 10ms, 20ms and 20ms (capped 40ms)
+	assert.GreaterOrEqual(t, calls[1].Sub(calls[0]), 10*time.Millisecond)
+	assert.GreaterOrEqual(t, calls[2].Sub(calls[1]), 20*time.Millisecond)
+	assert.GreaterOrEqual(t, calls[3].Sub(calls[2]), 20*time.Millisecond)
+	assert.Less(t, calls[3].Sub(calls[2]), 35*time.Millisecond)
+}
+
 func TestKeys_Use_CancelCtx(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -122,7 +167,427 @@ func TestKeys_Use_CancelCtx(t *testing.T) {
 	})
 	keys := New(time.Hour, usedRepo, unusedRepo)
 
-	actual, err := keys.Use(ctx)
+	actual, err := keys.Use(ctx, 0, Metadata{})
 	assert.Zero(t, actual)
 	assert.EqualError(t, err, context.Canceled.Error())
 }
+
+func TestKeys_Use_WithClaimer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	claimer := NewMockClaimer(ctrl)
+	testKey := "testKey1"
+	ctx := context.Background()
+
+	claimer.EXPECT().Claim(ctx, time.Hour).Return("", errbrick.ErrConflict)
+	claimer.EXPECT().Claim(ctx, time.Hour).Return(testKey, nil)
+	keys := New(time.Hour, usedRepo, unusedRepo, WithClaimer(claimer))
+
+	actual, err := keys.Use(ctx, 0, Metadata{})
+	assert.Equal(t, testKey, actual.Val)
+	assert.NotZero(t, actual.ExpiresAt)
+	assert.NoError(t, err)
+}
+
+func TestKeys_UseN(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := NewMockClaimer(ctrl)
+	ctx := context.Background()
+
+	claimer.EXPECT().ClaimN(ctx, int64(3), time.Hour).Return([]string{"k1", "k2"}, nil)
+	claimer.EXPECT().ClaimN(ctx, int64(1), time.Hour).Return([]string{"k3"}, nil)
+	keys := New(time.Hour, nil, nil, WithClaimer(claimer))
+
+	actual, err := keys.UseN(ctx, 3)
+	assert.NoError(t, err)
+	assert.Len(t, actual, 3)
+	for i, k := range []string{"k1", "k2", "k3"} {
+		assert.Equal(t, k, actual[i].Val)
+		assert.NotZero(t, actual[i].ExpiresAt)
+	}
+}
+
+func TestKeys_UseN_Partial(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := NewMockClaimer(ctrl)
+	ctx := context.Background()
+
+	claimer.EXPECT().ClaimN(ctx, int64(3), time.Hour).Return([]string{"k1"}, nil)
+	claimer.EXPECT().ClaimN(ctx, int64(2), time.Hour).Return(nil, errbrick.ErrNotFound)
+	keys := New(time.Hour, nil, nil, WithClaimer(claimer))
+
+	actual, err := keys.UseN(ctx, 3)
+	assert.NoError(t, err)
+	assert.Len(t, actual, 1)
+	assert.Equal(t, "k1", actual[0].Val)
+}
+
+func TestKeys_UseN_NoFreeKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := NewMockClaimer(ctrl)
+	ctx := context.Background()
+
+	claimer.EXPECT().ClaimN(ctx, int64(3), time.Hour).Return(nil, errbrick.ErrNotFound)
+	keys := New(time.Hour, nil, nil, WithClaimer(claimer))
+
+	actual, err := keys.UseN(ctx, 3)
+	assert.NoError(t, err)
+	assert.Empty(t, actual)
+}
+
+func TestKeys_UseN_InvalidCount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := NewMockClaimer(ctrl)
+	keys := New(time.Hour, nil, nil, WithClaimer(claimer), WithMaxBatchSize(10))
+
+	for _, n := range []int64{0, -1, 11} {
+		actual, err := keys.UseN(context.Background(), n)
+		assert.ErrorIs(t, err, errbrick.ErrInvalidData)
+		assert.Nil(t, actual)
+	}
+}
+
+func TestKeys_UseN_ClaimErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := NewMockClaimer(ctrl)
+	ctx := context.Background()
+	testErr := errors.New("test err")
+
+	claimer.EXPECT().ClaimN(ctx, int64(3), time.Hour).Return(nil, testErr)
+	keys := New(time.Hour, nil, nil, WithClaimer(claimer))
+
+	actual, err := keys.UseN(ctx, 3)
+	assert.ErrorIs(t, err, testErr)
+	assert.Nil(t, actual)
+}
+
+func TestKeys_UseN_ClaimErrAfterPartial(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := NewMockClaimer(ctrl)
+	ctx := context.Background()
+
+	claimer.EXPECT().ClaimN(ctx, int64(3), time.Hour).Return([]string{"k1", "k2"}, nil)
+	claimer.EXPECT().ClaimN(ctx, int64(1), time.Hour).Return(nil, errors.New("test err"))
+	keys := New(time.Hour, nil, nil, WithClaimer(claimer))
+
+	actual, err := keys.UseN(ctx, 3)
+	assert.NoError(t, err)
+	assert.Len(t, actual, 2)
+}
+
+func TestKeys_Reserve(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	alias := "spring-sale"
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Exists(ctx, alias).Return(false, nil)
+	usedRepo.EXPECT().Store(ctx, alias, time.Hour).Return(true, nil)
+	unusedRepo.EXPECT().Delete(ctx, alias).Return(true, nil)
+	keys := New(time.Hour, usedRepo, unusedRepo)
+
+	actual, err := keys.Reserve(ctx, alias)
+	assert.NoError(t, err)
+	assert.Equal(t, alias, actual.Val)
+	assert.NotZero(t, actual.ExpiresAt)
+}
+
+func TestKeys_Reserve_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	keys := New(time.Hour, usedRepo, unusedRepo, WithReservedLen(3, 8))
+
+	for _, alias := range []string{"ab", "spring-sale", "sale!", "sale/1", "распродажа"} {
+		actual, err := keys.Reserve(context.Background(), alias)
+		assert.ErrorIs(t, err, errbrick.ErrInvalidData, alias)
+		assert.Zero(t, actual)
+	}
+}
+
+func TestKeys_Reserve_Alphabet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	keys := New(time.Hour, usedRepo, unusedRepo, WithAlphabet("0123456789abcdefghijklmnopqrstuvwxyz"))
+
+	// the lowercase pool never generates upper case letters, '-' and '_'
+	for _, alias := range []string{"Sale", "spring-sale", "spring_sale"} {
+		actual, err := keys.Reserve(context.Background(), alias)
+		assert.ErrorIs(t, err, errbrick.ErrInvalidData, alias)
+		assert.Zero(t, actual)
+	}
+
+	usedRepo.EXPECT().Exists(gomock.Any(), "sale2024").Return(false, nil)
+	usedRepo.EXPECT().Store(gomock.Any(), "sale2024", time.Hour).Return(true, nil)
+	unusedRepo.EXPECT().Delete(gomock.Any(), "sale2024").Return(false, nil)
+	actual, err := keys.Reserve(context.Background(), "sale2024")
+	require.NoError(t, err)
+	assert.Equal(t, "sale2024", actual.Val)
+}
+
+func TestKeys_Reserve_Blocked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	keys := New(time.Hour, NewMockUsedKeysRepository(ctrl), NewMockUnusedKeysRepository(ctrl), WithBlocklist(NewBlocklist("shit")))
+
+	actual, err := keys.Reserve(context.Background(), "bull5h1t")
+	assert.ErrorIs(t, err, errbrick.ErrInvalidData)
+	assert.Zero(t, actual)
+}
+
+func TestKeys_Reserve_AlreadyUsed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	alias := "spring-sale"
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Exists(ctx, alias).Return(true, nil)
+	keys := New(time.Hour, usedRepo, unusedRepo)
+
+	actual, err := keys.Reserve(ctx, alias)
+	assert.ErrorIs(t, err, errbrick.ErrConflict)
+	assert.Zero(t, actual)
+}
+
+func TestKeys_Reserve_StoreConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	alias := "spring-sale"
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Exists(ctx, alias).Return(false, nil)
+	usedRepo.EXPECT().Store(ctx, alias, time.Hour).Return(false, nil)
+	keys := New(time.Hour, usedRepo, unusedRepo)
+
+	actual, err := keys.Reserve(ctx, alias)
+	assert.ErrorIs(t, err, errbrick.ErrConflict)
+	assert.Zero(t, actual)
+}
+
+func TestKeys_Reserve_DeleteUnusedErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	alias := "spring-sale"
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Exists(ctx, alias).Return(false, nil)
+	usedRepo.EXPECT().Store(ctx, alias, time.Hour).Return(true, nil)
+	unusedRepo.EXPECT().Delete(ctx, alias).Return(false, errors.New("test err"))
+	keys := New(time.Hour, usedRepo, unusedRepo)
+
+	actual, err := keys.Reserve(ctx, alias)
+	assert.NoError(t, err)
+	assert.Equal(t, alias, actual.Val)
+}
+
+func TestKeys_Use_CustomTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := NewMockClaimer(ctrl)
+	testKey := "testKey1"
+	ctx := context.Background()
+
+	claimer.EXPECT().Claim(ctx, 10*time.Minute).Return(testKey, nil)
+	keys := New(time.Hour, nil, nil, WithClaimer(claimer))
+
+	actual, err := keys.Use(ctx, 10*time.Minute, Metadata{})
+	assert.NoError(t, err)
+	assert.Equal(t, testKey, actual.Val)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), actual.ExpiresAt, time.Second)
+}
+
+func TestKeys_Use_TTLOutOfBounds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := NewMockClaimer(ctrl)
+	keys := New(time.Hour, nil, nil, WithClaimer(claimer), WithTTLBounds(time.Minute, 24*time.Hour))
+
+	for _, ttl := range []time.Duration{time.Second, 25 * time.Hour, -time.Hour} {
+		actual, err := keys.Use(context.Background(), ttl, Metadata{})
+		assert.ErrorIs(t, err, errbrick.ErrInvalidData, ttl)
+		assert.Zero(t, actual)
+	}
+}
+
+func TestKeys_Return(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Delete(ctx, "k1").Return(true, nil)
+	// k2 has already expired
+	usedRepo.EXPECT().Delete(ctx, "k2").Return(false, nil)
+	usedRepo.EXPECT().Delete(ctx, "k3").Return(true, nil)
+	unusedRepo.EXPECT().Store(ctx, "k1", "k3").Return(int64(2), nil)
+	keys := New(time.Hour, usedRepo, unusedRepo)
+
+	assert.NoError(t, keys.Return(ctx, "k1", "k2", "k3"))
+}
+
+func TestKeys_Return_DeleteErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Delete(ctx, "k1").Return(false, errors.New("test err"))
+	keys := New(time.Hour, usedRepo, unusedRepo)
+
+	assert.Error(t, keys.Return(ctx, "k1", "k2"))
+}
+
+func TestKeys_Release(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Delete(ctx, testKey).Return(true, nil)
+	keys := New(time.Hour, usedRepo, unusedRepo)
+
+	assert.NoError(t, keys.Release(ctx, testKey))
+}
+
+func TestKeys_Release_NotUsed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Delete(ctx, testKey).Return(false, nil)
+	keys := New(time.Hour, usedRepo, unusedRepo)
+
+	assert.ErrorIs(t, keys.Release(ctx, testKey), errbrick.ErrNotFound)
+}
+
+func TestKeys_Release_DeleteErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	ctx := context.Background()
+	testErr := errors.New("test err")
+
+	usedRepo.EXPECT().Delete(ctx, testKey).Return(false, testErr)
+	keys := New(time.Hour, usedRepo, unusedRepo)
+
+	assert.ErrorIs(t, keys.Release(ctx, testKey), testErr)
+}
+
+func TestKeys_Extend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	expAt := time.Now().Add(48 * time.Hour)
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Extend(ctx, testKey, expAt).Return(true, nil)
+	keys := New(time.Hour, usedRepo, unusedRepo)
+
+	actual, err := keys.Extend(ctx, testKey, expAt)
+	assert.NoError(t, err)
+	assert.Equal(t, Key{Val: testKey, ExpiresAt: expAt}, actual)
+}
+
+func TestKeys_Extend_Expired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	expAt := time.Now().Add(48 * time.Hour)
+	ctx := context.Background()
+
+	usedRepo.EXPECT().Extend(ctx, testKey, expAt).Return(false, nil)
+	keys := New(time.Hour, usedRepo, unusedRepo)
+
+	actual, err := keys.Extend(ctx, testKey, expAt)
+	assert.ErrorIs(t, err, errbrick.ErrNotFound)
+	assert.Zero(t, actual)
+}
+
+func TestKeys_Extend_InvalidExpireTime(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	keys := New(time.Hour, usedRepo, unusedRepo, WithTTLBounds(time.Minute, 24*time.Hour))
+
+	for _, expAt := range []time.Time{time.Now().Add(-time.Minute), time.Now().Add(25 * time.Hour)} {
+		actual, err := keys.Extend(context.Background(), "testKey1", expAt)
+		assert.ErrorIs(t, err, errbrick.ErrInvalidData)
+		assert.Zero(t, actual)
+	}
+}
+
+func TestKeys_Extend_Err(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	testKey := "testKey1"
+	expAt := time.Now().Add(48 * time.Hour)
+	ctx := context.Background()
+	testErr := errors.New("test err")
+
+	usedRepo.EXPECT().Extend(ctx, testKey, expAt).Return(false, testErr)
+	keys := New(time.Hour, usedRepo, unusedRepo)
+
+	actual, err := keys.Extend(ctx, testKey, expAt)
+	assert.ErrorIs(t, err, testErr)
+	assert.Zero(t, actual)
+}
@@ -0,0 +1,95 @@
+package key
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// Elector elects a single leader among replicas (e.g. with a lease in Redis).
+//
+//go:generate mockgen -destination=elector_mock.go -package=key github.com/demeero/pocket-link/keygen/key Elector
+type Elector interface {
+	// Acquire tries to become the leader. It returns false if another replica is the leader.
+	Acquire(ctx context.Context) (bool, error)
+	// Renew prolongs the leadership. It returns false if the leadership is lost.
+	Renew(ctx context.Context) (bool, error)
+	// Release gives the leadership up.
+	Release(ctx context.Context) error
+}
+
+// Lead runs fn while this replica is the leader, so only one replica runs fn at a time.
+// Followers try to become the leader every interval and the leader renews its leadership every interval.
+// The context of fn is canceled when the leadership is lost. Lead blocks until ctx is done.
+func Lead(ctx context.Context, e Elector, interval time.Duration, fn func(ctx context.Context)) {
+	leader := leaderCounter()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		acquired, err := e.Acquire(ctx)
+		if err != nil {
+			slog.Error("failed acquire leadership", slog.Any("err", err))
+		}
+		if acquired {
+			lead(ctx, e, t, leader, fn)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// lead runs fn until the leadership is lost, fn returns or ctx is done.
+func lead(ctx context.Context, e Elector, t *time.Ticker, leader metric.Int64UpDownCounter, fn func(ctx context.Context)) {
+	slog.Info("became leader")
+	leader.Add(ctx, 1)
+	leaderCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(leaderCtx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+		// the leadership must be given up even if ctx is done
+		if err := e.Release(context.WithoutCancel(ctx)); err != nil {
+			slog.Error("failed release leadership", slog.Any("err", err))
+		}
+		leader.Add(context.WithoutCancel(ctx), -1)
+		slog.Info("lost leadership")
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		case <-t.C:
+			renewed, err := e.Renew(ctx)
+			if err != nil {
+				slog.Error("failed renew leadership", slog.Any("err", err))
+				return
+			}
+			if !renewed {
+				return
+			}
+		}
+	}
+}
+
+func leaderCounter() metric.Int64UpDownCounter {
+	c, err := otel.Meter("github.com/demeero/pocket-link/keygen/key").
+		Int64UpDownCounter("keygen.leader", metric.WithDescription("1 if the replica is the leader that generates keys, 0 otherwise"))
+	if err != nil {
+		slog.Error("failed create leader counter", slog.Any("err", err))
+		return noop.Int64UpDownCounter{}
+	}
+	return c
+}
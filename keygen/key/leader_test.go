@@ -0,0 +1,103 @@
+package key
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	redisrepo "github.com/demeero/pocket-link/keygen/repository/redis"
+)
+
+func TestLead_LostLeadership(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := NewMockElector(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	released := make(chan struct{})
+	gomock.InOrder(
+		e.EXPECT().Acquire(ctx).Return(true, nil),
+		e.EXPECT().Renew(ctx).Return(true, nil),
+		e.EXPECT().Renew(ctx).Return(false, nil),
+		e.EXPECT().Release(gomock.Any()).DoAndReturn(func(context.Context) error {
+			close(released)
+			return nil
+		}),
+		e.EXPECT().Acquire(ctx).DoAndReturn(func(context.Context) (bool, error) {
+			cancel()
+			return false, nil
+		}).MinTimes(1),
+	)
+
+	var leaderCtxDone atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Lead(ctx, e, 10*time.Millisecond, func(ctx context.Context) {
+			<-ctx.Done()
+			leaderCtxDone.Store(true)
+		})
+	}()
+
+	<-released
+	assert.True(t, leaderCtxDone.Load(), "fn must be stopped before the leadership is released")
+	<-done
+}
+
+func TestLead_Handover(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	var leader atomic.Value
+	leader.Store("")
+	run := func(ctx context.Context, name string, interval time.Duration) chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			Lead(ctx, redisrepo.NewLease(client, "lease", time.Second), interval, func(ctx context.Context) {
+				leader.Store(name)
+				<-ctx.Done()
+			})
+		}()
+		return done
+	}
+
+	// replica1 doesn't renew the lease during the test (e.g. it's stuck)
+	ctx1, cancel1 := context.WithCancel(ctx)
+	done1 := run(ctx1, "replica1", time.Hour)
+	assert.Eventually(t, func() bool { return leader.Load() == "replica1" }, time.Second, time.Millisecond)
+
+	ctx2, cancel2 := context.WithCancel(ctx)
+	done2 := run(ctx2, "replica2", 10*time.Millisecond)
+	defer func() {
+		cancel2()
+		<-done2
+	}()
+	// the lease expires before replica1 renews it - replica2 takes it over
+	mr.FastForward(2 * time.Second)
+	assert.Eventually(t, func() bool { return leader.Load() == "replica2" }, time.Second, time.Millisecond)
+
+	// replica1 shuts down - it must not release the lease that is held by replica2
+	cancel1()
+	<-done1
+	ctx3, cancel3 := context.WithCancel(ctx)
+	done3 := run(ctx3, "replica3", 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, "replica2", leader.Load())
+
+	// replica2 shuts down and releases the lease - replica3 takes it over
+	defer func() {
+		cancel3()
+		<-done3
+	}()
+	cancel2()
+	<-done2
+	assert.Eventually(t, func() bool { return leader.Load() == "replica3" }, time.Second, time.Millisecond)
+}
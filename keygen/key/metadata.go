@@ -0,0 +1,91 @@
+package key
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/demeero/bricks/slogbrick"
+)
+
+// Names of metadata fields in used keys repositories.
+const (
+	mdClientID = "client_id"
+	mdTenant   = "tenant"
+	mdTraceID  = "trace_id"
+	mdIssuedAt = "issued_at"
+)
+
+// Metadata describes the caller a key is issued to, so the key can be traced back to it (e.g. to investigate abuse).
+type Metadata struct {
+	ClientID string
+	Tenant   string
+	TraceID  string
+}
+
+// IsZero reports whether the metadata is empty.
+func (m Metadata) IsZero() bool {
+	return m == Metadata{}
+}
+
+// KeyInfo is a used key with metadata of its issue.
+type KeyInfo struct {
+	// IssuedAt is a time the key was issued at. It's zero if the key was stored before issue times were recorded.
+	IssuedAt time.Time
+	Metadata Metadata
+	Key
+}
+
+// toMap returns the metadata with the issue time as stored by used keys repositories. Empty fields are omitted.
+func (m Metadata) toMap(issuedAt time.Time) map[string]string {
+	result := map[string]string{mdIssuedAt: issuedAt.UTC().Format(time.RFC3339Nano)}
+	for name, v := range map[string]string{mdClientID: m.ClientID, mdTenant: m.Tenant, mdTraceID: m.TraceID} {
+		if v != "" {
+			result[name] = v
+		}
+	}
+	return result
+}
+
+// annotate records the metadata of the issued key along with its issue time, which is already recorded on store.
+// If the metadata can't be recorded, the key is returned to unused keys, so a key is never issued without the metadata that was requested.
+func (k *Keys) annotate(ctx context.Context, val string, md Metadata, issuedAt time.Time) error {
+	if md.IsZero() {
+		return nil
+	}
+	annotated, err := k.used.Annotate(ctx, val, md.toMap(issuedAt))
+	if err == nil && annotated {
+		return nil
+	}
+	if err == nil {
+		err = fmt.Errorf("key is not used anymore: %s", val)
+	}
+	if err := k.Return(context.WithoutCancel(ctx), val); err != nil {
+		slogbrick.FromCtx(ctx).Error("failed return key without metadata", slog.String("key", val), slog.Any("err", err))
+	}
+	return fmt.Errorf("failed record key metadata: %w", err)
+}
+
+// Lookup returns the used key with metadata of its issue.
+// It returns errbrick.ErrNotFound if the key is not used (e.g. it has already expired).
+func (k *Keys) Lookup(ctx context.Context, val string) (KeyInfo, error) {
+	md, expiresAt, err := k.used.Lookup(ctx, val)
+	if err != nil {
+		return KeyInfo{}, fmt.Errorf("failed lookup used key: %w", err)
+	}
+	result := KeyInfo{
+		Key: Key{Val: val, ExpiresAt: expiresAt},
+		Metadata: Metadata{
+			ClientID: md[mdClientID],
+			Tenant:   md[mdTenant],
+			TraceID:  md[mdTraceID],
+		},
+	}
+	if issuedAt, ok := md[mdIssuedAt]; ok {
+		if result.IssuedAt, err = time.Parse(time.RFC3339Nano, issuedAt); err != nil {
+			slogbrick.FromCtx(ctx).Error("failed parse issue time of key", slog.String("key", val), slog.Any("err", err))
+		}
+	}
+	return result, nil
+}
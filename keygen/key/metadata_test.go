@@ -0,0 +1,80 @@
+package key
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/demeero/bricks/errbrick"
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	redisrepo "github.com/demeero/pocket-link/keygen/repository/redis"
+)
+
+func TestKeys_Lookup(t *testing.T) {
+	mr := miniredis.RunT(t)
+	used := redisrepo.NewUsedKeys(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "")
+	unused := redisrepo.NewUnusedKeys(redis.NewClient(&redis.Options{Addr: mr.Addr(), DB: 1}), "")
+	ctx := context.Background()
+	_, err := unused.Store(ctx, "k1", "k2", "k3")
+	require.NoError(t, err)
+	keys := New(time.Hour, used, unused)
+
+	md := Metadata{ClientID: "c1", Tenant: "t1", TraceID: "trace1"}
+	annotated, err := keys.Use(ctx, 0, md)
+	require.NoError(t, err)
+	plain, err := keys.Use(ctx, 0, Metadata{})
+	require.NoError(t, err)
+	batch, err := keys.UseN(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	info, err := keys.Lookup(ctx, annotated.Val)
+	require.NoError(t, err)
+	assert.Equal(t, annotated.Val, info.Val)
+	assert.Equal(t, md, info.Metadata)
+	assert.WithinDuration(t, time.Now(), info.IssuedAt, time.Second)
+	assert.WithinDuration(t, annotated.ExpiresAt, info.ExpiresAt, time.Second)
+
+	// the issue time is recorded on store even without metadata
+	for _, k := range []Key{plain, batch[0]} {
+		info, err = keys.Lookup(ctx, k.Val)
+		require.NoError(t, err)
+		assert.True(t, info.Metadata.IsZero())
+		assert.WithinDuration(t, time.Now(), info.IssuedAt, time.Second)
+	}
+
+	_, err = keys.Lookup(ctx, "k4")
+	assert.ErrorIs(t, err, errbrick.ErrNotFound)
+}
+
+func TestKeys_Use_AnnotateErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := NewMockClaimer(ctrl)
+	claimer.EXPECT().Claim(gomock.Any(), time.Hour).Return("k1", nil)
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	usedRepo.EXPECT().Annotate(gomock.Any(), "k1", gomock.Any()).Return(false, errors.New("test err"))
+	usedRepo.EXPECT().Delete(gomock.Any(), "k1").Return(true, nil)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	unusedRepo.EXPECT().Store(gomock.Any(), "k1").Return(int64(1), nil)
+	keys := New(time.Hour, usedRepo, unusedRepo, WithClaimer(claimer))
+
+	// the key isn't issued without the requested metadata, so it goes back to unused keys
+	_, err := keys.Use(context.Background(), 0, Metadata{ClientID: "c1"})
+	assert.Error(t, err)
+}
+
+func TestMetadata_toMap(t *testing.T) {
+	issuedAt := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	assert.Equal(t, map[string]string{
+		mdClientID: "c1",
+		mdIssuedAt: "2024-01-02T03:04:05.000000006Z",
+	}, Metadata{ClientID: "c1"}.toMap(issuedAt))
+}
@@ -0,0 +1,47 @@
+package key
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/demeero/bricks/errbrick"
+)
+
+// DefaultPool is a name of the pool that is used if a pool isn't specified.
+const DefaultPool = "default"
+
+// Pools is a set of named key pools.
+// Every pool has its own unused keys and its own namespace of used keys.
+type Pools struct {
+	pools map[string]*Keys
+}
+
+// NewPools creates a new Pools from Keys by pool names.
+func NewPools(pools map[string]*Keys) *Pools {
+	return &Pools{pools: pools}
+}
+
+// Get returns Keys of the pool. Empty name means DefaultPool.
+// It returns errbrick.ErrNotFound if the pool doesn't exist.
+func (p *Pools) Get(name string) (*Keys, error) {
+	if name == "" {
+		name = DefaultPool
+	}
+	k, ok := p.pools[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: pool %s", errbrick.ErrNotFound, name)
+	}
+	return k, nil
+}
+
+// Close closes Keys of all pools.
+func (p *Pools) Close(ctx context.Context) error {
+	var errs []error
+	for name, k := range p.pools {
+		if err := k.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed close pool %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,57 @@
+package key
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/demeero/bricks/errbrick"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPools_Get(t *testing.T) {
+	defaultKeys := New(time.Hour, nil, nil)
+	smsKeys := New(time.Hour, nil, nil)
+	pools := NewPools(map[string]*Keys{DefaultPool: defaultKeys, "sms": smsKeys})
+
+	actual, err := pools.Get("sms")
+	require.NoError(t, err)
+	assert.Same(t, smsKeys, actual)
+
+	actual, err = pools.Get(DefaultPool)
+	require.NoError(t, err)
+	assert.Same(t, defaultKeys, actual)
+
+	actual, err = pools.Get("")
+	require.NoError(t, err)
+	assert.Same(t, defaultKeys, actual)
+}
+
+func TestPools_Get_NotFound(t *testing.T) {
+	pools := NewPools(map[string]*Keys{DefaultPool: New(time.Hour, nil, nil)})
+
+	actual, err := pools.Get("unknown")
+	assert.ErrorIs(t, err, errbrick.ErrNotFound)
+	assert.Nil(t, actual)
+}
+
+func TestPools_Close(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	ctx := context.Background()
+	unusedRepo.EXPECT().LoadAndDeleteN(ctx, int64(2)).Return([]string{"k1", "k2"}, nil)
+	usedRepo.EXPECT().Store(ctx, gomock.Any(), time.Hour).Return(true, nil)
+	unusedRepo.EXPECT().Store(ctx, gomock.Any()).Return(int64(0), errbrick.ErrNotFound)
+
+	keys := New(time.Hour, usedRepo, unusedRepo, WithPrefetch(2, 0))
+	_, err := keys.Use(ctx, 0, Metadata{})
+	require.NoError(t, err)
+
+	pools := NewPools(map[string]*Keys{DefaultPool: keys, "sms": New(time.Hour, usedRepo, unusedRepo)})
+	assert.Error(t, pools.Close(ctx))
+}
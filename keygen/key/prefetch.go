@@ -0,0 +1,164 @@
+package key
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/demeero/bricks/errbrick"
+	"github.com/demeero/bricks/slogbrick"
+)
+
+// errPrefetchClosed is returned by prefetcher after it's closed.
+var errPrefetchClosed = errors.New("prefetch buffer is closed")
+
+// prefetcher is a Claimer that takes unused keys in batches and keeps them in memory,
+// so claiming a key takes a single round trip to store it as used.
+// The buffer is refilled in the background when it falls below the threshold.
+// ClaimN is delegated to the next Claimer since batches are already claimed in a single round trip.
+type prefetcher struct {
+	used   UsedKeysRepository
+	unused UnusedKeysRepository
+	next   Claimer
+	// loaded is closed when the synchronous load of keys is done. It's nil if keys aren't loaded.
+	loaded    chan struct{}
+	keys      []string
+	wg        sync.WaitGroup
+	batchSize int64
+	threshold int
+	mu        sync.Mutex
+	refilling bool
+	closed    bool
+}
+
+func newPrefetcher(used UsedKeysRepository, unused UnusedKeysRepository, next Claimer, batchSize int64, threshold int) *prefetcher {
+	return &prefetcher{
+		used:      used,
+		unused:    unused,
+		next:      next,
+		batchSize: batchSize,
+		threshold: threshold,
+	}
+}
+
+func (p *prefetcher) Claim(ctx context.Context, ttl time.Duration) (string, error) {
+	k, err := p.take(ctx)
+	if err != nil {
+		return "", err
+	}
+	stored, err := p.used.Store(ctx, k, ttl)
+	if err != nil {
+		p.putBack(ctx, k)
+		return "", fmt.Errorf("failed store key: %w", err)
+	}
+	if !stored {
+		// the key was reserved while it was buffered
+		return "", fmt.Errorf("%w: key already exist", errbrick.ErrConflict)
+	}
+	return k, nil
+}
+
+func (p *prefetcher) ClaimN(ctx context.Context, n int64, ttl time.Duration) ([]string, error) {
+	return p.next.ClaimN(ctx, n, ttl)
+}
+
+// Close stops refilling and returns buffered keys to unused keys.
+func (p *prefetcher) Close(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.wg.Wait()
+
+	p.mu.Lock()
+	keys := p.keys
+	p.keys = nil
+	p.mu.Unlock()
+	if len(keys) == 0 {
+		return nil
+	}
+	if _, err := p.unused.Store(ctx, keys...); err != nil {
+		return fmt.Errorf("failed return buffered keys to unused keys: %w", err)
+	}
+	return nil
+}
+
+// take returns a buffered key. If the buffer is empty, it's refilled synchronously without holding the lock,
+// so a single caller loads keys while others wait for them.
+func (p *prefetcher) take(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	for len(p.keys) == 0 {
+		if p.closed {
+			p.mu.Unlock()
+			return "", errPrefetchClosed
+		}
+		if p.loaded != nil {
+			loaded := p.loaded
+			p.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return "", fmt.Errorf("failed wait for loaded keys: %w", ctx.Err())
+			case <-loaded:
+			}
+			p.mu.Lock()
+			continue
+		}
+		p.loaded = make(chan struct{})
+		p.wg.Add(1)
+		p.mu.Unlock()
+		keys, err := p.unused.LoadAndDeleteN(ctx, p.batchSize)
+		p.mu.Lock()
+		// keys are appended even if the buffer is closed - Close returns them after the load is done
+		p.keys = append(p.keys, keys...)
+		close(p.loaded)
+		p.loaded = nil
+		p.wg.Done()
+		if err == nil && len(keys) == 0 {
+			err = errbrick.ErrNotFound
+		}
+		if err != nil {
+			p.mu.Unlock()
+			return "", fmt.Errorf("failed load keys: %w", err)
+		}
+	}
+	k := p.keys[len(p.keys)-1]
+	p.keys = p.keys[:len(p.keys)-1]
+	// no refill starts once the buffer is closed - Close may be already waiting for refills
+	if len(p.keys) < p.threshold && !p.refilling && !p.closed {
+		p.refilling = true
+		p.wg.Add(1)
+		// the refill outlives the request that has triggered it
+		go p.refill(context.WithoutCancel(ctx))
+	}
+	p.mu.Unlock()
+	return k, nil
+}
+
+func (p *prefetcher) refill(ctx context.Context) {
+	defer p.wg.Done()
+	keys, err := p.unused.LoadAndDeleteN(ctx, p.batchSize)
+	if err != nil && !errors.Is(err, errbrick.ErrNotFound) {
+		slogbrick.FromCtx(ctx).Error("failed refill prefetch buffer", slog.Any("err", err))
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refilling = false
+	// keys are appended even if the buffer is closed - Close returns them after the refill is done
+	p.keys = append(p.keys, keys...)
+}
+
+// putBack returns the key that hasn't been claimed to the buffer or to unused keys if the buffer is closed.
+func (p *prefetcher) putBack(ctx context.Context, k string) {
+	p.mu.Lock()
+	if !p.closed {
+		p.keys = append(p.keys, k)
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+	if _, err := p.unused.Store(context.WithoutCancel(ctx), k); err != nil {
+		slogbrick.FromCtx(ctx).Error("failed return key to unused keys", slog.String("key", k), slog.Any("err", err))
+	}
+}
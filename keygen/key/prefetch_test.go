@@ -0,0 +1,192 @@
+package key
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeys_Use_Prefetch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+
+	unusedRepo.EXPECT().LoadAndDeleteN(ctx, int64(3)).Return([]string{"k1", "k2", "k3"}, nil)
+	usedRepo.EXPECT().Store(ctx, gomock.Any(), time.Hour).Return(true, nil).Times(3)
+	keys := New(time.Hour, usedRepo, unusedRepo, WithPrefetch(3, 0))
+
+	var actual []string
+	for i := 0; i < 3; i++ {
+		k, err := keys.Use(ctx, 0, Metadata{})
+		require.NoError(t, err)
+		actual = append(actual, k.Val)
+	}
+	assert.ElementsMatch(t, []string{"k1", "k2", "k3"}, actual)
+	assert.NoError(t, keys.Close(ctx))
+}
+
+func TestKeys_Use_Prefetch_Refill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+
+	gomock.InOrder(
+		unusedRepo.EXPECT().LoadAndDeleteN(ctx, int64(3)).Return([]string{"k1", "k2", "k3"}, nil),
+		// the buffer falls below the threshold - it's refilled in the background
+		unusedRepo.EXPECT().LoadAndDeleteN(gomock.Any(), int64(3)).Return([]string{"k4", "k5", "k6"}, nil),
+	)
+	usedRepo.EXPECT().Store(ctx, gomock.Any(), time.Hour).Return(true, nil).Times(2)
+	var returned []string
+	unusedRepo.EXPECT().Store(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, k ...string) (int64, error) {
+		returned = k
+		return int64(len(k)), nil
+	})
+	keys := New(time.Hour, usedRepo, unusedRepo, WithPrefetch(3, 2))
+
+	for i := 0; i < 2; i++ {
+		_, err := keys.Use(ctx, 0, Metadata{})
+		require.NoError(t, err)
+	}
+
+	// all buffered keys go back to unused keys
+	require.NoError(t, keys.Close(ctx))
+	assert.ElementsMatch(t, []string{"k1", "k4", "k5", "k6"}, returned)
+
+	_, err := keys.Use(ctx, 0, Metadata{})
+	assert.ErrorIs(t, err, errPrefetchClosed)
+}
+
+func TestPrefetcher_Take_Closed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the buffer is closed while Close waits for loads, so a buffered key is taken without a refill
+	p := newPrefetcher(NewMockUsedKeysRepository(ctrl), NewMockUnusedKeysRepository(ctrl), nil, 3, 2)
+	p.keys = []string{"k1"}
+	p.closed = true
+
+	k, err := p.take(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "k1", k)
+	p.wg.Wait()
+	assert.False(t, p.refilling)
+}
+
+func TestKeys_Use_Prefetch_StoreErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+
+	unusedRepo.EXPECT().LoadAndDeleteN(ctx, int64(1)).Return([]string{"k1"}, nil)
+	testErr := errors.New("test err")
+	usedRepo.EXPECT().Store(ctx, "k1", time.Hour).Return(false, testErr)
+	// the key is put back to the buffer and returned to unused keys on close
+	unusedRepo.EXPECT().Store(ctx, "k1").Return(int64(1), nil)
+	keys := New(time.Hour, usedRepo, unusedRepo, WithPrefetch(1, 0))
+
+	_, err := keys.Use(ctx, 0, Metadata{})
+	assert.ErrorIs(t, err, testErr)
+	assert.NoError(t, keys.Close(ctx))
+}
+
+func TestKeys_Use_Prefetch_Concurrent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const keysCount = 1000
+	pool := &fakePool{keys: make(map[string]struct{}, keysCount)}
+	for i := 0; i < keysCount; i++ {
+		pool.keys[fmt.Sprintf("k%d", i)] = struct{}{}
+	}
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	unusedRepo.EXPECT().LoadAndDeleteN(gomock.Any(), gomock.Any()).DoAndReturn(pool.LoadAndDeleteN).AnyTimes()
+	unusedRepo.EXPECT().Store(gomock.Any(), gomock.Any()).DoAndReturn(pool.Store).AnyTimes()
+	usedRepo.EXPECT().Store(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+	keys := New(time.Hour, usedRepo, unusedRepo, WithPrefetch(50, 20))
+
+	var mu sync.Mutex
+	used := make(map[string]struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 30; j++ {
+				k, err := keys.Use(context.Background(), 0, Metadata{})
+				if !assert.NoError(t, err) {
+					return
+				}
+				mu.Lock()
+				used[k.Val] = struct{}{}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, keys.Close(context.Background()))
+
+	// every key is either used once or back in unused keys - nothing is lost
+	assert.Len(t, used, 300)
+	size, err := pool.Size(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(keysCount-300), size)
+}
+
+func TestKeys_Use_Prefetch_SingleLoad(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	loading, release := make(chan struct{}), make(chan struct{})
+	unusedRepo.EXPECT().LoadAndDeleteN(gomock.Any(), int64(3)).DoAndReturn(func(context.Context, int64) ([]string, error) {
+		close(loading)
+		<-release
+		return []string{"k1", "k2", "k3"}, nil
+	})
+	usedRepo.EXPECT().Store(gomock.Any(), gomock.Any(), time.Hour).Return(true, nil).Times(2)
+	unusedRepo.EXPECT().Store(gomock.Any(), "k1").Return(int64(1), nil)
+	keys := New(time.Hour, usedRepo, unusedRepo, WithPrefetch(3, 0))
+
+	loaded := make(chan string)
+	go func() {
+		k, err := keys.Use(context.Background(), 0, Metadata{})
+		assert.NoError(t, err)
+		loaded <- k.Val
+	}()
+	<-loading
+
+	// the lock isn't held during the load, so a waiting caller gives up with its context
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := keys.Use(ctx, 0, Metadata{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	waited := make(chan string)
+	go func() {
+		k, err := keys.Use(context.Background(), 0, Metadata{})
+		assert.NoError(t, err)
+		waited <- k.Val
+	}()
+	close(release)
+	// the waiting caller takes a key of the same load
+	assert.ElementsMatch(t, []string{"k2", "k3"}, []string{<-loaded, <-waited})
+	assert.NoError(t, keys.Close(context.Background()))
+}
@@ -0,0 +1,127 @@
+package key
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// saturationSample is a minimum number of generated keys the collision ratio is measured over.
+// Smaller samples (e.g. a refill of a few keys) make the ratio too noisy.
+const saturationSample = DefaultGenBatchSize
+
+// KeyLenRepository keeps the grown key length of the pool, so it survives restarts and changes of the leader.
+type KeyLenRepository interface {
+	// Load returns the stored key length or zero if it's not stored.
+	Load(ctx context.Context) (uint8, error)
+	// Store stores the key length unless a longer one is already stored, so the length never shrinks.
+	Store(ctx context.Context, keyLen uint8) error
+}
+
+// Saturation detects saturation of the keyspace by the collision ratio of generated keys.
+// The more keys of the current length are used, the more often generated keys collide with them.
+// When the ratio passes the threshold, Saturation logs a warning, counts it in the keygen.keys.saturated metric
+// and grows the key length by one up to the maximum length.
+// Keys of shorter lengths stay valid - keys are never checked against the generated length.
+// The grown length is stored in the KeyLenRepository and loaded when the generator starts (e.g. on the elected leader).
+// A nil Saturation detects nothing.
+type Saturation struct {
+	saturated  metric.Int64Counter
+	repo       KeyLenRepository
+	pool       string
+	threshold  float64
+	generated  int64
+	collisions int64
+	mu         sync.Mutex
+	keyLen     uint8
+	maxKeyLen  uint8
+}
+
+// NewSaturation creates a new Saturation of the pool with the collision ratio threshold (e.g. 0.1 for 10%).
+// The key length isn't grown above maxKeyLen, so it's never grown if maxKeyLen is zero.
+// The grown length is kept in memory only if repo is nil.
+func NewSaturation(pool string, threshold float64, maxKeyLen uint8, repo KeyLenRepository) *Saturation {
+	return &Saturation{
+		saturated: saturatedCounter(),
+		repo:      repo,
+		pool:      pool,
+		threshold: threshold,
+		maxKeyLen: maxKeyLen,
+	}
+}
+
+// load loads the stored key length that has been grown by the previous leader. It isn't loaded above maxKeyLen.
+func (s *Saturation) load(ctx context.Context) {
+	if s == nil || s.repo == nil {
+		return
+	}
+	keyLen, err := s.repo.Load(ctx)
+	if err != nil {
+		slog.Error("failed load grown key length", slog.String("pool", s.pool), slog.Any("err", err))
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keyLen = max(s.keyLen, min(keyLen, s.maxKeyLen))
+}
+
+// KeyLen returns the length of generated keys - the configured one or the grown one if it's longer.
+func (s *Saturation) KeyLen(configured uint8) uint8 {
+	if s == nil {
+		return configured
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return max(configured, s.keyLen)
+}
+
+// observe reports collisions among keys generated with the key length.
+// The ratio is checked once enough keys are generated and the key length is grown if it passes the threshold.
+func (s *Saturation) observe(ctx context.Context, generated, collisions int64, keyLen uint8) {
+	if s == nil || generated == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generated += generated
+	s.collisions += collisions
+	if s.generated < saturationSample {
+		return
+	}
+	ratio := float64(s.collisions) / float64(s.generated)
+	s.generated, s.collisions = 0, 0
+	if ratio <= s.threshold {
+		return
+	}
+	slog.Warn("keyspace is saturated - generated keys collide too often",
+		slog.String("pool", s.pool), slog.Float64("ratio", ratio), slog.Int("key_len", int(keyLen)))
+	s.saturated.Add(context.Background(), 1,
+		metric.WithAttributes(attribute.String("pool", s.pool), attribute.Int("key_len", int(keyLen))))
+	if keyLen >= s.maxKeyLen {
+		return
+	}
+	s.keyLen = keyLen + 1
+	slog.Info("key length is grown", slog.String("pool", s.pool), slog.Int("key_len", int(s.keyLen)))
+	if s.repo == nil {
+		return
+	}
+	// the length stays grown in memory even if it isn't stored - the next leader grows it again
+	if err := s.repo.Store(ctx, s.keyLen); err != nil {
+		slog.Error("failed store grown key length", slog.String("pool", s.pool), slog.Any("err", err))
+	}
+}
+
+func saturatedCounter() metric.Int64Counter {
+	c, err := otel.Meter("github.com/demeero/pocket-link/keygen/key").
+		Int64Counter("keygen.keys.saturated", metric.WithDescription("Number of times the collision ratio of generated keys passed the threshold"))
+	if err != nil {
+		slog.Error("failed create saturated keyspace counter", slog.Any("err", err))
+		return noop.Int64Counter{}
+	}
+	return c
+}
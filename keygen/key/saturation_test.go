@@ -0,0 +1,71 @@
+package key
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	redisrepo "github.com/demeero/pocket-link/keygen/repository/redis"
+)
+
+func TestSaturation_Observe(t *testing.T) {
+	s := NewSaturation("", 0.1, 7, nil)
+
+	// the sample is too small to measure the ratio
+	s.observe(context.Background(), saturationSample/2, saturationSample/2, 6)
+	assert.Equal(t, uint8(6), s.KeyLen(6))
+
+	// the ratio of the whole sample is 0.55
+	s.observe(context.Background(), saturationSample/2, saturationSample/20, 6)
+	assert.Equal(t, uint8(7), s.KeyLen(6))
+
+	// the ratio is below the threshold
+	s.observe(context.Background(), saturationSample, saturationSample/10, 7)
+	assert.Equal(t, uint8(7), s.KeyLen(6))
+
+	// the max length is reached
+	s.observe(context.Background(), saturationSample, saturationSample, 7)
+	assert.Equal(t, uint8(7), s.KeyLen(6))
+
+	// the configured length is longer than the grown one
+	assert.Equal(t, uint8(8), s.KeyLen(8))
+}
+
+func TestSaturation_Persisted(t *testing.T) {
+	mr := miniredis.RunT(t)
+	repo := redisrepo.NewKeyLen(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "")
+	ctx := context.Background()
+
+	s := NewSaturation("", 0.1, 8, repo)
+	s.observe(ctx, saturationSample, saturationSample, 6)
+	stored, err := repo.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(7), stored)
+
+	// the next leader continues with the grown length
+	next := NewSaturation("", 0.1, 8, repo)
+	assert.Equal(t, uint8(6), next.KeyLen(6))
+	next.load(ctx)
+	assert.Equal(t, uint8(7), next.KeyLen(6))
+
+	// the stored length isn't loaded above the maximum
+	capped := NewSaturation("", 0.1, 6, repo)
+	capped.load(ctx)
+	assert.Equal(t, uint8(6), capped.KeyLen(6))
+}
+
+func TestSaturation_NoGrowth(t *testing.T) {
+	s := NewSaturation("", 0.1, 0, nil)
+	s.observe(context.Background(), saturationSample, saturationSample, 6)
+	assert.Equal(t, uint8(6), s.KeyLen(6))
+}
+
+func TestSaturation_Nil(t *testing.T) {
+	var s *Saturation
+	s.observe(context.Background(), saturationSample, saturationSample, 6)
+	assert.Equal(t, uint8(6), s.KeyLen(6))
+}
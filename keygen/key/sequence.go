@@ -0,0 +1,130 @@
+package key
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"math/bits"
+)
+
+// feistelRounds is a number of rounds of the Feistel network.
+// Keys of consecutive values look unrelated after a few rounds - the rest is a safety margin.
+const feistelRounds = 8
+
+// maxSequenceDomain caps the number of keys of a single length, so values of the sequence fit into int64.
+const maxSequenceDomain = 1 << 62
+
+// Sequence reserves values of a monotonically increasing sequence shared by all replicas.
+type Sequence interface {
+	// Next reserves n next values and returns the first of them. The first value of a new sequence is zero.
+	Next(ctx context.Context, n int64) (int64, error)
+}
+
+// SequenceStrategy generates keys that are unique by construction, so they aren't checked against used keys.
+// Every key is a value of the Sequence passed through a Feistel network keyed by the secret
+// and encoded with the alphabet, so consecutive keys look random.
+// The secret and the alphabet must never change for the pool - otherwise new keys may repeat old ones.
+// Keys of different lengths never repeat each other, since they are encoded into exactly the requested length.
+type SequenceStrategy struct {
+	seq      Sequence
+	alphabet []rune
+	secret   []byte
+}
+
+// NewSequenceStrategy creates a new SequenceStrategy.
+func NewSequenceStrategy(seq Sequence, alphabet string, secret []byte) *SequenceStrategy {
+	return &SequenceStrategy{seq: seq, alphabet: []rune(alphabet), secret: secret}
+}
+
+// Keys reserves n values of the sequence and returns n keys of length keyLen.
+// It returns an error if the values are out of the keyspace of the length.
+func (s *SequenceStrategy) Keys(ctx context.Context, n int, keyLen uint8) ([]string, error) {
+	domain := keyspace(len(s.alphabet), keyLen)
+	first, err := s.seq.Next(ctx, int64(n))
+	if err != nil {
+		return nil, fmt.Errorf("failed reserve sequence values: %w", err)
+	}
+	if first < 0 || uint64(first)+uint64(n) > domain {
+		return nil, fmt.Errorf("keyspace of length %d is exhausted by the sequence: %d", keyLen, first)
+	}
+	f := newFeistel(s.secret, domain)
+	result := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		result = append(result, s.encode(f.permute(uint64(first)+uint64(i)), keyLen))
+	}
+	return result, nil
+}
+
+// encode encodes v with the alphabet into exactly keyLen chars.
+func (s *SequenceStrategy) encode(v uint64, keyLen uint8) string {
+	b := make([]rune, keyLen)
+	base := uint64(len(s.alphabet))
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = s.alphabet[v%base]
+		v /= base
+	}
+	return string(b)
+}
+
+// keyspace returns the number of keys of length keyLen with the alphabet of size base, up to maxSequenceDomain.
+func keyspace(base int, keyLen uint8) uint64 {
+	result := uint64(1)
+	for i := uint8(0); i < keyLen; i++ {
+		hi, lo := bits.Mul64(result, uint64(base))
+		if hi != 0 || lo >= maxSequenceDomain {
+			return maxSequenceDomain
+		}
+		result = lo
+	}
+	return result
+}
+
+// feistel is a keyed bijective permutation of [0, domain).
+// The network permutes numbers of an even number of bits, so values out of the domain are permuted again
+// (cycle walking) until they get back into it.
+type feistel struct {
+	mac      hash.Hash
+	domain   uint64
+	mask     uint64
+	halfBits uint
+}
+
+func newFeistel(secret []byte, domain uint64) *feistel {
+	halfBits := uint(max((bits.Len64(domain-1)+1)/2, 1))
+	return &feistel{
+		mac:      hmac.New(sha256.New, secret),
+		domain:   domain,
+		mask:     1<<halfBits - 1,
+		halfBits: halfBits,
+	}
+}
+
+// permute returns the image of x. x must be in the domain.
+func (f *feistel) permute(x uint64) uint64 {
+	for {
+		x = f.encrypt(x)
+		if x < f.domain {
+			return x
+		}
+	}
+}
+
+func (f *feistel) encrypt(x uint64) uint64 {
+	l, r := x>>f.halfBits, x&f.mask
+	for i := 0; i < feistelRounds; i++ {
+		l, r = r, l^f.round(i, r)
+	}
+	return l<<f.halfBits | r
+}
+
+func (f *feistel) round(i int, r uint64) uint64 {
+	var buf [9]byte
+	buf[0] = byte(i)
+	binary.BigEndian.PutUint64(buf[1:], r)
+	f.mac.Reset()
+	f.mac.Write(buf[:])
+	return binary.BigEndian.Uint64(f.mac.Sum(nil)) & f.mask
+}
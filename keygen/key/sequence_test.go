@@ -0,0 +1,123 @@
+package key
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeistel_Bijection(t *testing.T) {
+	// every domain is permuted exhaustively, including ones that need cycle walking
+	for _, domain := range []uint64{1, 2, 3, 64, 1000, 4096, 64 * 64 * 64} {
+		f := newFeistel([]byte("secret"), domain)
+		seen := make([]bool, domain)
+		for x := uint64(0); x < domain; x++ {
+			y := f.permute(x)
+			require.Less(t, y, domain)
+			require.Falsef(t, seen[y], "duplicate image %d in domain %d", y, domain)
+			seen[y] = true
+		}
+	}
+}
+
+func TestFeistel_Injective(t *testing.T) {
+	f := newFeistel([]byte("secret"), keyspace(len(letterRunes), 10))
+	prop := func(x, y uint64) bool {
+		x, y = x%f.domain, y%f.domain
+		return x == y || f.permute(x) != f.permute(y)
+	}
+	require.NoError(t, quick.Check(prop, &quick.Config{MaxCount: 10000}))
+}
+
+func TestSequenceStrategy_Unique(t *testing.T) {
+	prop := func(secret []byte, first uint32, keyLen uint8) bool {
+		keyLen = keyLen%8 + 4
+		// values of the sequence fit into the keyspace of the length
+		seq := &memSequence{next: int64(uint64(first) % (keyspace(len(letterRunes), keyLen) - 1000))}
+		s := NewSequenceStrategy(seq, string(letterRunes), secret)
+		seen := make(map[string]struct{})
+		for i := 0; i < 5; i++ {
+			keys, err := s.Keys(context.Background(), 200, keyLen)
+			if err != nil {
+				return false
+			}
+			for _, k := range keys {
+				if _, ok := seen[k]; ok || len([]rune(k)) != int(keyLen) {
+					return false
+				}
+				seen[k] = struct{}{}
+			}
+		}
+		return true
+	}
+	require.NoError(t, quick.Check(prop, nil))
+}
+
+func TestSequenceStrategy_WholeKeyspace(t *testing.T) {
+	const alphabet = "abc"
+	s := NewSequenceStrategy(&memSequence{}, alphabet, []byte("secret"))
+	keys, err := s.Keys(context.Background(), 27, 3)
+	require.NoError(t, err)
+	seen := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		require.Len(t, k, 3)
+		require.Equal(t, "", strings.Trim(k, alphabet))
+		seen[k] = struct{}{}
+	}
+	assert.Len(t, seen, 27)
+	assert.NotEqual(t, []string{"aaa", "aab", "aac"}, keys[:3], "keys must not follow the sequence")
+
+	_, err = s.Keys(context.Background(), 1, 3)
+	assert.ErrorContains(t, err, "exhausted")
+
+	// the next length has its own keyspace
+	keys, err = s.Keys(context.Background(), 1, 4)
+	require.NoError(t, err)
+	assert.Len(t, keys[0], 4)
+}
+
+func TestSequenceStrategy_Secret(t *testing.T) {
+	k1, err := NewSequenceStrategy(&memSequence{}, string(letterRunes), []byte("s1")).Keys(context.Background(), 10, 8)
+	require.NoError(t, err)
+	k2, err := NewSequenceStrategy(&memSequence{}, string(letterRunes), []byte("s2")).Keys(context.Background(), 10, 8)
+	require.NoError(t, err)
+	assert.NotEqual(t, k1, k2)
+}
+
+func TestSequenceStrategy_SeqErr(t *testing.T) {
+	s := NewSequenceStrategy(&memSequence{err: errors.New("test err")}, string(letterRunes), []byte("secret"))
+	_, err := s.Keys(context.Background(), 1, 8)
+	assert.Error(t, err)
+}
+
+func TestKeyspace(t *testing.T) {
+	assert.Equal(t, uint64(1), keyspace(64, 0))
+	assert.Equal(t, uint64(64*64), keyspace(64, 2))
+	assert.Equal(t, uint64(1)<<60, keyspace(64, 10))
+	assert.Equal(t, uint64(maxSequenceDomain), keyspace(64, 11))
+	assert.Equal(t, uint64(maxSequenceDomain), keyspace(64, 255))
+}
+
+// memSequence is an in-memory Sequence.
+type memSequence struct {
+	err  error
+	next int64
+	mu   sync.Mutex
+}
+
+func (s *memSequence) Next(_ context.Context, n int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+	first := s.next
+	s.next += n
+	return first, nil
+}
@@ -0,0 +1,236 @@
+package key
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// usageWindow is the longest window the issue rate is measured over.
+const usageWindow = 15 * time.Minute
+
+// timeUntilEmptyWindow is the window of the issue rate that estimates when unused keys run out.
+// It's long enough to smooth bursts and short enough to follow the current load.
+const timeUntilEmptyWindow = 5 * time.Minute
+
+// issueRateWindows are windows the issue rate is reported over.
+var issueRateWindows = []time.Duration{time.Minute, timeUntilEmptyWindow, usageWindow}
+
+// Stats are statistics of a key pool.
+type Stats struct {
+	// IssueRates are rates of keys issued by this replica over recent windows.
+	IssueRates []IssueRate
+	// UnusedKeys is a number of unused keys.
+	UnusedKeys int64
+	// UsedKeys is a number of used keys that haven't expired yet.
+	UsedKeys int64
+	// Collisions is a number of generated keys that were already used or unused.
+	// It's counted by the generator of this replica.
+	Collisions int64
+	// TimeUntilEmpty is an estimated time until unused keys run out at the current issue rate of this replica.
+	// Unused keys are shared by replicas, so it's overestimated if other replicas issue keys too.
+	// It's zero if no keys are issued by this replica.
+	TimeUntilEmpty time.Duration
+}
+
+// IssueRate is a number of keys issued per second over the window.
+type IssueRate struct {
+	Window    time.Duration
+	PerSecond float64
+}
+
+// Usage tracks issued keys and generation collisions of a pool.
+// It's shared by Keys and the generator of the pool. A nil Usage tracks nothing.
+type Usage struct {
+	startedAt  time.Time
+	collisions atomic.Int64
+	// issued is a ring of numbers of keys issued every second.
+	issued [int(usageWindow / time.Second)]int64
+	// last is the Unix second of the newest bucket in issued.
+	last int64
+	mu   sync.Mutex
+}
+
+// NewUsage creates a new Usage.
+func NewUsage() *Usage {
+	return &Usage{startedAt: time.Now()}
+}
+
+// Collisions returns the number of generated keys that were already used or unused.
+func (u *Usage) Collisions() int64 {
+	if u == nil {
+		return 0
+	}
+	return u.collisions.Load()
+}
+
+// collided reports n generated keys that were already used or unused.
+func (u *Usage) collided(n int64) {
+	if u == nil || n == 0 {
+		return
+	}
+	u.collisions.Add(n)
+}
+
+// issue reports n keys issued at now.
+func (u *Usage) issue(n int64, now time.Time) {
+	if u == nil || n == 0 {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	sec := now.Unix()
+	u.advance(sec)
+	u.issued[sec%int64(len(u.issued))] += n
+}
+
+// rate returns the number of keys issued per second over the window before now.
+// The window is shortened to the lifetime of Usage, so the rate isn't underestimated right after start.
+func (u *Usage) rate(window time.Duration, now time.Time) float64 {
+	if u == nil {
+		return 0
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	sec := now.Unix()
+	u.advance(sec)
+	seconds := min(int64(window/time.Second), int64(len(u.issued)))
+	var issued int64
+	for s := sec - seconds + 1; s <= sec; s++ {
+		issued += u.issued[s%int64(len(u.issued))]
+	}
+	elapsed := min(window, now.Sub(u.startedAt))
+	if elapsed < time.Second {
+		elapsed = time.Second
+	}
+	return float64(issued) / elapsed.Seconds()
+}
+
+// advance moves the ring to the second sec and resets the buckets of seconds that have passed since the last one.
+func (u *Usage) advance(sec int64) {
+	if sec <= u.last {
+		return
+	}
+	for s := max(u.last+1, sec-int64(len(u.issued))+1); s <= sec; s++ {
+		u.issued[s%int64(len(u.issued))] = 0
+	}
+	u.last = sec
+}
+
+// Stats returns statistics of the pool.
+func (k *Keys) Stats(ctx context.Context) (Stats, error) {
+	unused, err := k.unused.Size(ctx)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed get unused keys size: %w", err)
+	}
+	used, err := k.countUsed(ctx)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed count used keys: %w", err)
+	}
+	now := time.Now()
+	result := Stats{
+		UnusedKeys: unused,
+		UsedKeys:   used,
+		Collisions: k.usage.Collisions(),
+		IssueRates: make([]IssueRate, 0, len(issueRateWindows)),
+	}
+	for _, w := range issueRateWindows {
+		result.IssueRates = append(result.IssueRates, IssueRate{Window: w, PerSecond: k.usage.rate(w, now)})
+	}
+	if rate := k.usage.rate(timeUntilEmptyWindow, now); rate > 0 {
+		result.TimeUntilEmpty = time.Duration(float64(unused) / rate * float64(time.Second))
+	}
+	return result, nil
+}
+
+// usedCount is the number of used keys cached by Stats.
+type usedCount struct {
+	countedAt time.Time
+	val       int64
+	mu        sync.Mutex
+}
+
+// countUsed returns the number of used keys that was counted within the max age or counts them again.
+// Concurrent callers wait for a single count instead of counting at the same time.
+func (k *Keys) countUsed(ctx context.Context) (int64, error) {
+	k.usedCount.mu.Lock()
+	defer k.usedCount.mu.Unlock()
+	if !k.usedCount.countedAt.IsZero() && time.Since(k.usedCount.countedAt) < k.usedCountMaxAge {
+		return k.usedCount.val, nil
+	}
+	val, err := k.used.Count(ctx)
+	if err != nil {
+		return 0, err
+	}
+	k.usedCount.val = val
+	k.usedCount.countedAt = time.Now()
+	return val, nil
+}
+
+// Stats returns statistics of the pool. Empty name means DefaultPool.
+// It returns errbrick.ErrNotFound if the pool doesn't exist.
+func (p *Pools) Stats(ctx context.Context, name string) (Stats, error) {
+	k, err := p.Get(name)
+	if err != nil {
+		return Stats{}, err
+	}
+	return k.Stats(ctx)
+}
+
+// ObserveStats exports statistics of all pools as OTEL gauges with the pool attribute.
+// Statistics are collected on every export, so it costs the same as a Stats call per pool
+// (used keys are counted once per the max age of the count).
+func (p *Pools) ObserveStats() error {
+	meter := otel.Meter("github.com/demeero/pocket-link/keygen/key")
+	unused, err := meter.Int64ObservableGauge("keygen.keys.unused", metric.WithDescription("Number of unused keys"))
+	if err != nil {
+		return err
+	}
+	used, err := meter.Int64ObservableGauge("keygen.keys.used", metric.WithDescription("Number of used keys that haven't expired"))
+	if err != nil {
+		return err
+	}
+	collisions, err := meter.Int64ObservableGauge("keygen.keys.collisions",
+		metric.WithDescription("Number of generated keys that were already used or unused"))
+	if err != nil {
+		return err
+	}
+	issueRate, err := meter.Float64ObservableGauge("keygen.keys.issue_rate",
+		metric.WithDescription("Number of keys issued by the replica per second over the window"), metric.WithUnit("{key}/s"))
+	if err != nil {
+		return err
+	}
+	untilEmpty, err := meter.Float64ObservableGauge("keygen.keys.time_until_empty",
+		metric.WithDescription("Estimated time until unused keys run out at the current issue rate of the replica"), metric.WithUnit("s"))
+	if err != nil {
+		return err
+	}
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		for name, k := range p.pools {
+			stats, err := k.Stats(ctx)
+			if err != nil {
+				slog.Error("failed collect pool stats", slog.String("pool", name), slog.Any("err", err))
+				continue
+			}
+			pool := metric.WithAttributes(attribute.String("pool", name))
+			o.ObserveInt64(unused, stats.UnusedKeys, pool)
+			o.ObserveInt64(used, stats.UsedKeys, pool)
+			o.ObserveInt64(collisions, stats.Collisions, pool)
+			for _, r := range stats.IssueRates {
+				o.ObserveFloat64(issueRate, r.PerSecond, metric.WithAttributes(attribute.String("pool", name), attribute.String("window", r.Window.String())))
+			}
+			if stats.TimeUntilEmpty > 0 {
+				o.ObserveFloat64(untilEmpty, stats.TimeUntilEmpty.Seconds(), pool)
+			}
+		}
+		return nil
+	}, unused, used, collisions, issueRate, untilEmpty)
+	return err
+}
@@ -0,0 +1,143 @@
+package key
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsage_Rate(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	u := &Usage{startedAt: start}
+
+	u.issue(10, start)
+	u.issue(20, start.Add(30*time.Second))
+	// the window is shortened to the lifetime of Usage
+	assert.InDelta(t, 1.0, u.rate(time.Minute, start.Add(30*time.Second)), 0.001)
+
+	now := start.Add(2 * time.Minute)
+	assert.Zero(t, u.rate(time.Minute, now))
+	assert.InDelta(t, 0.25, u.rate(5*time.Minute, now), 0.001)
+
+	// the bucket of start is reused after the longest window
+	now = start.Add(usageWindow)
+	u.issue(9, now)
+	assert.InDelta(t, 29/usageWindow.Seconds(), u.rate(usageWindow, now), 0.001)
+	assert.InDelta(t, 0.15, u.rate(time.Minute, now), 0.001)
+
+	// nothing is left after a long pause
+	assert.Zero(t, u.rate(usageWindow, now.Add(time.Hour)))
+}
+
+func TestUsage_Nil(t *testing.T) {
+	var u *Usage
+	u.issue(1, time.Now())
+	u.collided(1)
+	assert.Zero(t, u.rate(time.Minute, time.Now()))
+	assert.Zero(t, u.Collisions())
+}
+
+func TestKeys_Stats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+	unusedRepo.EXPECT().Size(ctx).Return(int64(600), nil)
+	usedRepo.EXPECT().Count(ctx).Return(int64(42), nil)
+
+	u := NewUsage()
+	u.startedAt = time.Now().Add(-time.Hour)
+	// 300 keys in the last 5 minutes - 1 key per second
+	u.issue(300, time.Now())
+	u.collided(7)
+	k := New(time.Hour, usedRepo, unusedRepo, WithUsage(u))
+
+	actual, err := k.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(600), actual.UnusedKeys)
+	assert.Equal(t, int64(42), actual.UsedKeys)
+	assert.Equal(t, int64(7), actual.Collisions)
+	require.Len(t, actual.IssueRates, 3)
+	assert.Equal(t, IssueRate{Window: time.Minute, PerSecond: 5}, actual.IssueRates[0])
+	assert.Equal(t, IssueRate{Window: 5 * time.Minute, PerSecond: 1}, actual.IssueRates[1])
+	assert.Equal(t, 10*time.Minute, actual.TimeUntilEmpty)
+}
+
+func TestKeys_Stats_NoIssued(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+	unusedRepo.EXPECT().Size(ctx).Return(int64(600), nil)
+	usedRepo.EXPECT().Count(ctx).Return(int64(0), nil)
+	k := New(time.Hour, usedRepo, unusedRepo)
+
+	actual, err := k.Stats(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, actual.TimeUntilEmpty)
+}
+
+func TestKeys_Stats_CountErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+	testErr := errors.New("test err")
+	unusedRepo.EXPECT().Size(ctx).Return(int64(600), nil)
+	usedRepo.EXPECT().Count(ctx).Return(int64(0), testErr)
+	k := New(time.Hour, usedRepo, unusedRepo)
+
+	_, err := k.Stats(ctx)
+	assert.ErrorIs(t, err, testErr)
+}
+
+func TestKeys_Stats_UsedCountCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	usedRepo := NewMockUsedKeysRepository(ctrl)
+	unusedRepo := NewMockUnusedKeysRepository(ctrl)
+	ctx := context.Background()
+	unusedRepo.EXPECT().Size(ctx).Return(int64(600), nil).Times(3)
+	// used keys are counted once per the max age
+	usedRepo.EXPECT().Count(ctx).Return(int64(42), nil)
+	usedRepo.EXPECT().Count(ctx).Return(int64(43), nil)
+	k := New(time.Hour, usedRepo, unusedRepo, WithUsedCountMaxAge(time.Minute))
+
+	for _, expected := range []int64{42, 42} {
+		actual, err := k.Stats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual.UsedKeys)
+	}
+
+	k.usedCount.countedAt = time.Now().Add(-time.Minute)
+	actual, err := k.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(43), actual.UsedKeys)
+}
+
+func TestKeys_UseN_Usage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	claimer := NewMockClaimer(ctrl)
+	ctx := context.Background()
+	claimer.EXPECT().ClaimN(ctx, int64(3), time.Hour).Return([]string{"k1", "k2", "k3"}, nil)
+	u := NewUsage()
+	k := New(time.Hour, nil, nil, WithClaimer(claimer), WithUsage(u))
+
+	_, err := k.UseN(ctx, 3)
+	require.NoError(t, err)
+	assert.InDelta(t, 3.0, u.rate(time.Minute, time.Now()), 0.001)
+}
@@ -0,0 +1,78 @@
+package key
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// KeyStrategy generates random keys.
+type KeyStrategy interface {
+	// Key returns a new random key of length n.
+	Key(n int) (string, error)
+}
+
+// Names of built-in key strategies.
+const (
+	StrategyDefault   = "default"
+	StrategyBase62    = "base62"
+	StrategyCrockford = "crockford32"
+	StrategyLowercase = "lowercase"
+)
+
+var (
+	// DefaultStrategy uses digits, lower and upper case letters, '-' and '_'.
+	DefaultStrategy KeyStrategy = NewAlphabetStrategy(string(letterRunes))
+	// Base62Strategy uses digits, lower and upper case letters.
+	Base62Strategy KeyStrategy = NewAlphabetStrategy("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	// CrockfordStrategy uses Crockford's Base32 alphabet.
+	// It excludes I, L, O and U, so keys don't have letters that can be confused with 0, 1 or each other.
+	CrockfordStrategy KeyStrategy = NewAlphabetStrategy("0123456789ABCDEFGHJKMNPQRSTVWXYZ")
+	// LowercaseStrategy uses digits and lower case letters.
+	LowercaseStrategy KeyStrategy = NewAlphabetStrategy("0123456789abcdefghijklmnopqrstuvwxyz")
+)
+
+// StrategyByName returns the built-in KeyStrategy by its name.
+// Empty name means DefaultStrategy.
+func StrategyByName(name string) (KeyStrategy, error) {
+	switch name {
+	case "", StrategyDefault:
+		return DefaultStrategy, nil
+	case StrategyBase62:
+		return Base62Strategy, nil
+	case StrategyCrockford:
+		return CrockfordStrategy, nil
+	case StrategyLowercase:
+		return LowercaseStrategy, nil
+	default:
+		return nil, fmt.Errorf("unsupported key strategy: %s", name)
+	}
+}
+
+// AlphabetStrategy generates keys of chars picked uniformly at random from the alphabet.
+type AlphabetStrategy struct {
+	alphabet []rune
+}
+
+// NewAlphabetStrategy creates a new AlphabetStrategy.
+func NewAlphabetStrategy(alphabet string) *AlphabetStrategy {
+	return &AlphabetStrategy{alphabet: []rune(alphabet)}
+}
+
+// Alphabet returns chars of keys.
+func (s *AlphabetStrategy) Alphabet() string {
+	return string(s.alphabet)
+}
+
+func (s *AlphabetStrategy) Key(n int) (string, error) {
+	b := make([]rune, n)
+	size := big.NewInt(int64(len(s.alphabet)))
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", fmt.Errorf("failed pick random letter: %w", err)
+		}
+		b[i] = s.alphabet[idx.Int64()]
+	}
+	return string(b), nil
+}
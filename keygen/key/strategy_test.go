@@ -0,0 +1,91 @@
+package key
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrategies(t *testing.T) {
+	tests := []struct {
+		strategy KeyStrategy
+		name     string
+		alphabet string
+	}{
+		{
+			name:     StrategyDefault,
+			strategy: DefaultStrategy,
+			alphabet: string(letterRunes),
+		},
+		{
+			name:     StrategyBase62,
+			strategy: Base62Strategy,
+			alphabet: "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ",
+		},
+		{
+			name:     StrategyCrockford,
+			strategy: CrockfordStrategy,
+			alphabet: "0123456789ABCDEFGHJKMNPQRSTVWXYZ",
+		},
+		{
+			name:     StrategyLowercase,
+			strategy: LowercaseStrategy,
+			alphabet: "0123456789abcdefghijklmnopqrstuvwxyz",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const (
+				keysCount = 10000
+				keyLen    = 10
+			)
+			keys := make(map[string]struct{}, keysCount)
+			freq := make(map[rune]int, len(tt.alphabet))
+			for i := 0; i < keysCount; i++ {
+				k, err := tt.strategy.Key(keyLen)
+				require.NoError(t, err)
+				require.Len(t, []rune(k), keyLen)
+				for _, r := range k {
+					require.Truef(t, strings.ContainsRune(tt.alphabet, r), "unexpected char %q in key %s", r, k)
+					freq[r]++
+				}
+				keys[k] = struct{}{}
+			}
+
+			assert.Len(t, keys, keysCount, "keys must be unique")
+
+			// every char is expected keysCount*keyLen/len(alphabet) times - allow 25% deviation
+			assert.Len(t, freq, len(tt.alphabet), "every char of the alphabet must be used")
+			expected := float64(keysCount*keyLen) / float64(len(tt.alphabet))
+			for r, n := range freq {
+				assert.InDeltaf(t, expected, float64(n), expected*0.25, "char %q is used %d times", r, n)
+			}
+		})
+	}
+}
+
+func TestStrategyByName(t *testing.T) {
+	tests := []struct {
+		expected KeyStrategy
+		name     string
+	}{
+		{name: "", expected: DefaultStrategy},
+		{name: StrategyDefault, expected: DefaultStrategy},
+		{name: StrategyBase62, expected: Base62Strategy},
+		{name: StrategyCrockford, expected: CrockfordStrategy},
+		{name: StrategyLowercase, expected: LowercaseStrategy},
+	}
+	for _, tt := range tests {
+		actual, err := StrategyByName(tt.name)
+		require.NoError(t, err)
+		assert.Same(t, tt.expected, actual)
+	}
+}
+
+func TestStrategyByName_Unsupported(t *testing.T) {
+	actual, err := StrategyByName("unknown")
+	assert.Error(t, err)
+	assert.Nil(t, actual)
+}
@@ -34,6 +34,21 @@ func (m *MockUnusedKeysRepository) EXPECT() *MockUnusedKeysRepositoryMockRecorde
 	return m.recorder
 }
 
+// Delete mocks base method.
+func (m *MockUnusedKeysRepository) Delete(arg0 context.Context, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUnusedKeysRepositoryMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUnusedKeysRepository)(nil).Delete), arg0, arg1)
+}
+
 // LoadAndDelete mocks base method.
 func (m *MockUnusedKeysRepository) LoadAndDelete(arg0 context.Context) (string, error) {
 	m.ctrl.T.Helper()
@@ -49,6 +64,21 @@ func (mr *MockUnusedKeysRepositoryMockRecorder) LoadAndDelete(arg0 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadAndDelete", reflect.TypeOf((*MockUnusedKeysRepository)(nil).LoadAndDelete), arg0)
 }
 
+// LoadAndDeleteN mocks base method.
+func (m *MockUnusedKeysRepository) LoadAndDeleteN(arg0 context.Context, arg1 int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadAndDeleteN", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadAndDeleteN indicates an expected call of LoadAndDeleteN.
+func (mr *MockUnusedKeysRepositoryMockRecorder) LoadAndDeleteN(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadAndDeleteN", reflect.TypeOf((*MockUnusedKeysRepository)(nil).LoadAndDeleteN), arg0, arg1)
+}
+
 // Size mocks base method.
 func (m *MockUnusedKeysRepository) Size(arg0 context.Context) (int64, error) {
 	m.ctrl.T.Helper()
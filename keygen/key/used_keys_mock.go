@@ -35,6 +35,51 @@ func (m *MockUsedKeysRepository) EXPECT() *MockUsedKeysRepositoryMockRecorder {
 	return m.recorder
 }
 
+// Annotate mocks base method.
+func (m *MockUsedKeysRepository) Annotate(arg0 context.Context, arg1 string, arg2 map[string]string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Annotate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Annotate indicates an expected call of Annotate.
+func (mr *MockUsedKeysRepositoryMockRecorder) Annotate(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Annotate", reflect.TypeOf((*MockUsedKeysRepository)(nil).Annotate), arg0, arg1, arg2)
+}
+
+// Count mocks base method.
+func (m *MockUsedKeysRepository) Count(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Count", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Count indicates an expected call of Count.
+func (mr *MockUsedKeysRepositoryMockRecorder) Count(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockUsedKeysRepository)(nil).Count), arg0)
+}
+
+// Delete mocks base method.
+func (m *MockUsedKeysRepository) Delete(arg0 context.Context, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUsedKeysRepositoryMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUsedKeysRepository)(nil).Delete), arg0, arg1)
+}
+
 // Exists mocks base method.
 func (m *MockUsedKeysRepository) Exists(arg0 context.Context, arg1 string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +95,52 @@ func (mr *MockUsedKeysRepositoryMockRecorder) Exists(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exists", reflect.TypeOf((*MockUsedKeysRepository)(nil).Exists), arg0, arg1)
 }
 
+// ExistsMany mocks base method.
+func (m *MockUsedKeysRepository) ExistsMany(arg0 context.Context, arg1 []string) ([]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExistsMany", arg0, arg1)
+	ret0, _ := ret[0].([]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExistsMany indicates an expected call of ExistsMany.
+func (mr *MockUsedKeysRepositoryMockRecorder) ExistsMany(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExistsMany", reflect.TypeOf((*MockUsedKeysRepository)(nil).ExistsMany), arg0, arg1)
+}
+
+// Extend mocks base method.
+func (m *MockUsedKeysRepository) Extend(arg0 context.Context, arg1 string, arg2 time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Extend", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Extend indicates an expected call of Extend.
+func (mr *MockUsedKeysRepositoryMockRecorder) Extend(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Extend", reflect.TypeOf((*MockUsedKeysRepository)(nil).Extend), arg0, arg1, arg2)
+}
+
+// Lookup mocks base method.
+func (m *MockUsedKeysRepository) Lookup(arg0 context.Context, arg1 string) (map[string]string, time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lookup", arg0, arg1)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(time.Time)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Lookup indicates an expected call of Lookup.
+func (mr *MockUsedKeysRepositoryMockRecorder) Lookup(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lookup", reflect.TypeOf((*MockUsedKeysRepository)(nil).Lookup), arg0, arg1)
+}
+
 // Store mocks base method.
 func (m *MockUsedKeysRepository) Store(arg0 context.Context, arg1 string, arg2 time.Duration) (bool, error) {
 	m.ctrl.T.Helper()
@@ -2,12 +2,14 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"log/slog"
 	"net"
 	"os"
 	"os/signal"
+	"sync"
 
 	"github.com/demeero/bricks/configbrick"
 	"github.com/demeero/bricks/grpcbrick"
@@ -15,14 +17,18 @@ import (
 	"github.com/demeero/bricks/slogbrick"
 	"github.com/demeero/pocket-link/keygen/grpcsvc"
 	"github.com/demeero/pocket-link/keygen/key"
+	boltrepo "github.com/demeero/pocket-link/keygen/repository/bolt"
 	mongorepo "github.com/demeero/pocket-link/keygen/repository/mongo"
+	postgresrepo "github.com/demeero/pocket-link/keygen/repository/postgres"
 	redisrepo "github.com/demeero/pocket-link/keygen/repository/redis"
 	pb "github.com/demeero/pocket-link/proto/gen/go/pocketlink/keygen/v1beta1"
 	"github.com/grafana/pyroscope-go"
 	grpcrecovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
+	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/joho/godotenv"
 	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
+	"go.etcd.io/bbolt"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
@@ -47,6 +53,16 @@ func main() {
 		JSON:      cfg.Log.JSON,
 	})
 
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+		err := runAudit(ctx, cfg)
+		cancel()
+		if err != nil {
+			log.Fatal("failed audit", err)
+		}
+		return
+	}
+
 	stopProfiling := profiling(cfg)
 
 	ctx := context.Background()
@@ -84,26 +100,42 @@ func main() {
 		log.Fatalf("failed init metrics: %s", err)
 	}
 
-	usedRepo, err := createUsedKeysRepo(cfg)
+	boltDB, err := openBolt(cfg)
+	if err != nil {
+		log.Fatal("failed open embedded database", err)
+	}
+	newUsedRepo, err := usedKeysRepoFactory(ctx, cfg, boltDB)
 	if err != nil {
 		log.Fatal("failed create used keys repository", err)
 	}
-	unusedRepo := createUnusedKeysRepo(cfg.RedisUnusedKeys)
+	unused, err := createUnusedKeysStorage(cfg, boltDB)
+	if err != nil {
+		log.Fatal("failed create unused keys repository", err)
+	}
 
 	defer cancel()
 
-	genCfg := key.GeneratorConfig{
-		PredefinedKeysCount: cfg.Generator.PredefinedKeysCount,
-		Delay:               cfg.Generator.Delay,
-		KeyLen:              cfg.Generator.KeyLen,
+	pools, err := createPools(ctx, cfg, newUsedRepo, unused)
+	if err != nil {
+		log.Fatal("failed create key pools", err)
 	}
-	go key.Generate(ctx, genCfg, usedRepo, unusedRepo)
 
-	grpcSrvShutdown := grpcServ(cfg.GRPC, key.New(cfg.Keys.TTL, usedRepo, unusedRepo))
+	grpcSrvShutdown := grpcServ(cfg.GRPC, pools)
 
 	<-ctx.Done()
 	slog.Info("shutting down")
 	grpcSrvShutdown()
+	closeCtx, closeCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	if err := pools.Close(closeCtx); err != nil {
+		slog.Error("failed close key pools", slog.Any("err", err))
+	}
+	closeCancel()
+	if boltDB != nil {
+		// after the pools are closed, since they return buffered keys to unused keys
+		if err := boltDB.Close(); err != nil {
+			slog.Error("failed close embedded database", slog.Any("err", err))
+		}
+	}
 	if err := meterShutdown(context.Background()); err != nil {
 		slog.Error("failed shutdown meter provider", slog.Any("err", err))
 	}
@@ -113,7 +145,7 @@ func main() {
 	stopProfiling()
 }
 
-func grpcServ(cfg configbrick.GRPC, k *key.Keys) func() {
+func grpcServ(cfg configbrick.GRPC, pools *key.Pools) func() {
 	interceptors := []grpc.UnaryServerInterceptor{
 		grpcrecovery.UnaryServerInterceptor(),
 		grpcbrick.SlogCtxUnaryServerInterceptor(true),
@@ -123,11 +155,12 @@ func grpcServ(cfg configbrick.GRPC, k *key.Keys) func() {
 			return info.FullMethod == "/grpc.health.v1.Health/Check"
 		}))
 	}
-	grpcSrv := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()), grpc.ChainUnaryInterceptor(interceptors...))
+	grpcSrv := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()), grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(grpcrecovery.StreamServerInterceptor()))
 	if cfg.EnableReflection {
 		reflection.Register(grpcSrv)
 	}
-	pb.RegisterKeygenServiceServer(grpcSrv, grpcsvc.New(k))
+	pb.RegisterKeygenServiceServer(grpcSrv, grpcsvc.New(pools))
 	healthSrv := health.NewServer()
 	grpc_health_v1.RegisterHealthServer(grpcSrv, healthSrv)
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
@@ -147,22 +180,152 @@ func grpcServ(cfg configbrick.GRPC, k *key.Keys) func() {
 	}
 }
 
-func createUnusedKeysRepo(cfg configbrick.Redis) *redisrepo.UnusedKeys {
-	client := redis.NewClient(&redis.Options{
-		Addr:     cfg.Addr,
-		DB:       cfg.DB,
-		Password: cfg.Password,
-	})
+// unusedKeysStorage is a storage of unused keys of all pools.
+type unusedKeysStorage struct {
+	// rds is a client of Redis with unused keys. It's nil if unused keys aren't stored in Redis.
+	rds redis.Cmdable
+	// elector elects the replica that generates keys. It's nil if the storage is owned by a single replica.
+	elector key.Elector
+	// newRepos creates repositories of the pool namespace: the one that serves keys and the one that the generator stores keys to.
+	newRepos func(ns string) (key.UnusedKeysRepository, key.UnusedKeysRepository, error)
+	// newRelay creates a relay of demand of the pool namespace. It's nil if the storage is owned by a single replica.
+	newRelay func(ns string) key.DemandRelay
+	// newKeyLen creates a repository of the key length grown by the generator of the pool namespace.
+	newKeyLen func(ns string) (key.KeyLenRepository, error)
+}
+
+func createUnusedKeysStorage(cfg config, boltDB *bbolt.DB) (unusedKeysStorage, error) {
+	switch cfg.UnusedKeysRepositoryType {
+	case UnusedKeysRepositoryTypeBolt:
+		return unusedKeysStorage{
+			newRepos: func(ns string) (key.UnusedKeysRepository, key.UnusedKeysRepository, error) {
+				repo, err := boltrepo.NewUnusedKeys(boltDB, ns)
+				return repo, repo, err
+			},
+			newKeyLen: func(ns string) (key.KeyLenRepository, error) {
+				return boltrepo.NewKeyLen(boltDB, ns)
+			},
+		}, nil
+	case "", UnusedKeysRepositoryTypeRedis:
+		client := createRedisClient(cfg.RedisUnusedKeys, "unused keys")
+		lease := redisrepo.NewLease(client, redisrepo.GeneratorLeaseName, cfg.Leader.LeaseTTL)
+		return unusedKeysStorage{
+			rds:     client,
+			elector: lease,
+			newRepos: func(ns string) (key.UnusedKeysRepository, key.UnusedKeysRepository, error) {
+				repo := redisrepo.NewShardedUnusedKeys(client, ns, cfg.UnusedKeysShards)
+				// keys stored by a replica that has lost the leadership are rejected
+				return repo, redisrepo.NewFencedUnusedKeys(repo, lease), nil
+			},
+			newRelay: func(ns string) key.DemandRelay {
+				return redisrepo.NewDemandRelay(client, ns)
+			},
+			// the key length is stored next to the lease, so the next leader continues with it
+			newKeyLen: func(ns string) (key.KeyLenRepository, error) {
+				return redisrepo.NewKeyLen(client, ns), nil
+			},
+		}, nil
+	default:
+		return unusedKeysStorage{}, fmt.Errorf("unsupported unused keys repository type: %s", cfg.UnusedKeysRepositoryType)
+	}
+}
+
+// openBolt opens the embedded database if any repository is embedded. It returns nil otherwise.
+func openBolt(cfg config) (*bbolt.DB, error) {
+	if cfg.UsedKeysRepositoryType != UsedKeysRepositoryTypeBolt && cfg.UnusedKeysRepositoryType != UnusedKeysRepositoryTypeBolt {
+		return nil, nil
+	}
+	// the file is locked by a single process - another replica waits for the lock until the timeout
+	return bbolt.Open(cfg.Bolt.Path, 0o600, &bbolt.Options{Timeout: cfg.Bolt.OpenTimeout})
+}
+
+// createRedisClient creates a client of a single Redis instance, Redis Cluster or a master monitored by Sentinels.
+// The purpose is a name of stored data for logs.
+func createRedisClient(cfg Redis, purpose string) redis.UniversalClient {
+	client := redis.NewUniversalClient(cfg.options())
 	if err := redisotel.InstrumentTracing(client); err != nil {
-		slog.Error("failed instrument tracing to redis client for unused keys", slog.Any("err", err))
+		slog.Error("failed instrument tracing to redis client for "+purpose, slog.Any("err", err))
 	}
 	if err := redisotel.InstrumentMetrics(client); err != nil {
-		slog.Error("failed instrument metrics to redis client for unused keys", slog.Any("err", err))
+		slog.Error("failed instrument metrics to redis client for "+purpose, slog.Any("err", err))
+	}
+	return client
+}
+
+// createPools creates key pools and starts their generators on the elected leader replica
+// (or right away if unused keys are embedded, since a single replica owns them).
+// Followers serve keys but don't generate them.
+func createPools(ctx context.Context, cfg config, newUsedRepo func(pool string) (key.UsedKeysRepository, error), unused unusedKeysStorage) (*key.Pools, error) {
+	blocklist, err := loadBlocklist(ctx, cfg.Blocklist)
+	if err != nil {
+		return nil, fmt.Errorf("failed load blocklist: %w", err)
+	}
+	newSequence, err := sequenceFactory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed create sequence repository: %w", err)
+	}
+	genCfgs, err := generatorConfigs(cfg, blocklist, newSequence, unused.newKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed create generator configs: %w", err)
+	}
+	pools := make(map[string]*key.Keys, len(genCfgs))
+	generators := make([]func(ctx context.Context), 0, len(genCfgs))
+	for pool, genCfg := range genCfgs {
+		ns := poolNamespace(pool)
+		usedRepo, err := newUsedRepo(ns)
+		if err != nil {
+			return nil, fmt.Errorf("failed create used keys repository of pool %s: %w", pool, err)
+		}
+		unusedRepo, genUnusedRepo, err := unused.newRepos(ns)
+		if err != nil {
+			return nil, fmt.Errorf("failed create unused keys repository of pool %s: %w", pool, err)
+		}
+		genCfg := genCfg
+		if unused.newRelay != nil {
+			// only the leader generates keys, so followers pass their demand to it
+			go genCfg.Demand.Relay(ctx, unused.newRelay(ns))
+		}
+		generators = append(generators, func(ctx context.Context) {
+			key.Generate(ctx, genCfg, usedRepo, genUnusedRepo)
+		})
+		opts := append(keysOptions(cfg, unused.rds, ns), key.WithBlocklist(blocklist), key.WithDemand(genCfg.Demand), key.WithUsage(genCfg.Usage))
+		// reserved keys consist of chars that the pool can generate
+		if alphabet, ok := genCfg.Strategy.(*key.AlphabetStrategy); ok {
+			opts = append(opts, key.WithAlphabet(alphabet.Alphabet()))
+		}
+		keys := key.New(cfg.Keys.TTL, usedRepo, unusedRepo, opts...)
+		// keys are held in memory of the replica that issued them, so every replica returns its own unconfirmed keys
+		go keys.ReturnUnconfirmed(ctx, cfg.Keys.HoldCheckInterval)
+		pools[pool] = keys
 	}
-	return redisrepo.NewUnusedKeys(client)
+	generate := func(ctx context.Context) {
+		var wg sync.WaitGroup
+		for _, g := range generators {
+			wg.Add(1)
+			go func(g func(ctx context.Context)) {
+				defer wg.Done()
+				g(ctx)
+			}(g)
+		}
+		wg.Wait()
+	}
+	if unused.elector == nil {
+		// the storage is owned by this replica - there is nobody to elect
+		go generate(ctx)
+	} else {
+		go key.Lead(ctx, unused.elector, cfg.Leader.RenewInterval, generate)
+	}
+	result := key.NewPools(pools)
+	if err := result.ObserveStats(); err != nil {
+		slog.Error("failed register pool stats gauges", slog.Any("err", err))
+	}
+	return result, nil
 }
 
-func createUsedKeysRepo(cfg config) (key.UsedKeysRepository, error) {
+// usedKeysRepoFactory connects to the storage of used keys
+// and returns a function that creates a used keys repository of the pool namespace.
+// Sweepers of PostgreSQL repositories run until ctx is done.
+func usedKeysRepoFactory(ctx context.Context, cfg config, boltDB *bbolt.DB) (func(pool string) (key.UsedKeysRepository, error), error) {
 	if cfg.UsedKeysRepositoryType == "" {
 		cfg.UsedKeysRepositoryType = UsedKeysRepositoryTypeRedis
 	}
@@ -174,22 +337,238 @@ func createUsedKeysRepo(cfg config) (key.UsedKeysRepository, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed connect to MongoDB: %w", err)
 		}
-		return mongorepo.NewUsedKeys(client.Database("pocket-link"))
-	case UsedKeysRepositoryTypeRedis:
-		client := redis.NewClient(&redis.Options{
-			Addr:     cfg.RedisUsedKeys.Addr,
-			DB:       cfg.RedisUsedKeys.DB,
-			Password: cfg.RedisUsedKeys.Password,
-		})
-		if err := redisotel.InstrumentTracing(client); err != nil {
-			slog.Error("failed instrument tracing to redis client for used keys", slog.Any("err", err))
+		db := client.Database("pocket-link")
+		return func(pool string) (key.UsedKeysRepository, error) {
+			return mongorepo.NewUsedKeys(db, pool)
+		}, nil
+	case UsedKeysRepositoryTypePostgres:
+		db, err := sql.Open("pgx", cfg.PostgresUsedKeys.URI)
+		if err != nil {
+			return nil, fmt.Errorf("failed open PostgreSQL: %w", err)
 		}
-		return redisrepo.NewUsedKeys(client), nil
+		pingCtx, cancel := context.WithTimeout(ctx, cfg.PostgresUsedKeys.InitialConnectTimeout)
+		defer cancel()
+		if err := db.PingContext(pingCtx); err != nil {
+			return nil, fmt.Errorf("failed connect to PostgreSQL: %w", err)
+		}
+		return func(pool string) (key.UsedKeysRepository, error) {
+			repo, err := postgresrepo.NewUsedKeys(db, pool)
+			if err != nil {
+				return nil, err
+			}
+			// PostgreSQL has no TTL, so expired keys are deleted by the sweeper
+			go repo.RunSweeper(ctx, cfg.PostgresUsedKeys.SweepInterval)
+			return repo, nil
+		}, nil
+	case UsedKeysRepositoryTypeBolt:
+		return func(pool string) (key.UsedKeysRepository, error) {
+			repo, err := boltrepo.NewUsedKeys(boltDB, pool)
+			if err != nil {
+				return nil, err
+			}
+			go repo.RunSweeper(ctx, cfg.Bolt.SweepInterval)
+			return repo, nil
+		}, nil
+	case UsedKeysRepositoryTypeRedis:
+		client := createRedisClient(cfg.RedisUsedKeys, "used keys")
+		return func(pool string) (key.UsedKeysRepository, error) {
+			return redisrepo.NewUsedKeys(client, pool), nil
+		}, nil
 	default:
 		return nil, fmt.Errorf("unsupported used keys repository type: %s", cfg.UsedKeysRepositoryType)
 	}
 }
 
+// generatorConfigs returns generator configs by pool names: the default pool and the configured named pools.
+// newSequence creates a sequence of the pool namespace for pools in the sequence mode
+// and newKeyLen creates a repository of the grown key length of the pool namespace.
+func generatorConfigs(cfg config, blocklist *key.Blocklist, newSequence func(ns string) key.Sequence,
+	newKeyLen func(ns string) (key.KeyLenRepository, error),
+) (map[string]key.GeneratorConfig, error) {
+	strategy, err := key.StrategyByName(cfg.Generator.Strategy)
+	if err != nil {
+		return nil, fmt.Errorf("failed create key strategy: %w", err)
+	}
+	seqStrategy, err := sequenceStrategy(cfg, cfg.Generator.Mode, strategy, newSequence, key.DefaultPool)
+	if err != nil {
+		return nil, err
+	}
+	saturation, err := newSaturation(cfg.Generator, key.DefaultPool, cfg.Generator.MaxKeyLen, newKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	defaultCfg := key.GeneratorConfig{
+		PredefinedKeysCount: cfg.Generator.PredefinedKeysCount,
+		MaxKeysCount:        cfg.Generator.MaxKeysCount,
+		BatchSize:           cfg.Generator.BatchSize,
+		Delay:               cfg.Generator.Delay,
+		BufferTime:          cfg.Generator.BufferTime,
+		KeyLen:              cfg.Generator.KeyLen,
+		Strategy:            strategy,
+		Blocklist:           blocklist,
+		Demand:              key.NewDemand(int64(cfg.Generator.LowWatermark)),
+		Usage:               key.NewUsage(),
+		Saturation:          saturation,
+		Sequence:            seqStrategy,
+	}
+	result := map[string]key.GeneratorConfig{key.DefaultPool: defaultCfg}
+	for name, pool := range cfg.Pools {
+		if name == "" || name == key.DefaultPool {
+			return nil, fmt.Errorf("invalid pool name: %q", name)
+		}
+		genCfg := defaultCfg
+		if pool.Strategy != "" {
+			if genCfg.Strategy, err = key.StrategyByName(pool.Strategy); err != nil {
+				return nil, fmt.Errorf("failed create key strategy of pool %s: %w", name, err)
+			}
+		}
+		mode := cfg.Generator.Mode
+		if pool.Mode != "" {
+			mode = pool.Mode
+		}
+		if genCfg.Sequence, err = sequenceStrategy(cfg, mode, genCfg.Strategy, newSequence, name); err != nil {
+			return nil, err
+		}
+		if pool.PredefinedKeysCount != 0 {
+			genCfg.PredefinedKeysCount = pool.PredefinedKeysCount
+		}
+		if pool.KeyLen != 0 {
+			genCfg.KeyLen = pool.KeyLen
+		}
+		maxKeyLen := cfg.Generator.MaxKeyLen
+		if pool.MaxKeyLen != 0 {
+			maxKeyLen = pool.MaxKeyLen
+		}
+		lowWatermark := cfg.Generator.LowWatermark
+		if pool.LowWatermark != 0 {
+			lowWatermark = pool.LowWatermark
+		}
+		// every pool has its own demand, usage and saturation
+		genCfg.Demand = key.NewDemand(int64(lowWatermark))
+		genCfg.Usage = key.NewUsage()
+		if genCfg.Saturation, err = newSaturation(cfg.Generator, name, maxKeyLen, newKeyLen); err != nil {
+			return nil, err
+		}
+		result[name] = genCfg
+	}
+	return result, nil
+}
+
+// sequenceStrategy returns a SequenceStrategy of the pool with the alphabet of the strategy.
+// It returns nil if the pool generates random keys.
+func sequenceStrategy(cfg config, mode GeneratorMode, strategy key.KeyStrategy, newSequence func(ns string) key.Sequence, pool string) (*key.SequenceStrategy, error) {
+	switch mode {
+	case "", GeneratorModeRandom:
+		return nil, nil
+	case GeneratorModeSequence:
+		if cfg.Generator.SequenceSecret == "" {
+			return nil, fmt.Errorf("sequence secret is required for the sequence mode of pool %s", pool)
+		}
+		alphabet, ok := strategy.(*key.AlphabetStrategy)
+		if !ok {
+			return nil, fmt.Errorf("key strategy of pool %s has no alphabet for the sequence mode", pool)
+		}
+		return key.NewSequenceStrategy(newSequence(poolNamespace(pool)), alphabet.Alphabet(), []byte(cfg.Generator.SequenceSecret)), nil
+	default:
+		return nil, fmt.Errorf("unsupported generator mode of pool %s: %s", pool, mode)
+	}
+}
+
+// sequenceFactory connects to the storage of sequences and returns a function that creates a sequence of the pool namespace.
+// It doesn't connect if no pool is in the sequence mode.
+func sequenceFactory(cfg config) (func(ns string) key.Sequence, error) {
+	used := cfg.Generator.Mode == GeneratorModeSequence
+	for _, pool := range cfg.Pools {
+		used = used || pool.Mode == GeneratorModeSequence
+	}
+	if !used {
+		return nil, nil
+	}
+	switch cfg.SequenceRepositoryType {
+	case "", SequenceRepositoryTypeRedis:
+		client := createRedisClient(cfg.RedisSequence, "sequences")
+		return func(ns string) key.Sequence {
+			return redisrepo.NewSequence(client, ns)
+		}, nil
+	case SequenceRepositoryTypeMongo:
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.MongoSequence.InitialConnectTimeout)
+		defer cancel()
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoSequence.URI).SetMonitor(otelmongo.NewMonitor()))
+		if err != nil {
+			return nil, fmt.Errorf("failed connect to MongoDB: %w", err)
+		}
+		db := client.Database("pocket-link")
+		return func(ns string) key.Sequence {
+			return mongorepo.NewSequence(db, ns)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported sequence repository type: %s", cfg.SequenceRepositoryType)
+	}
+}
+
+// newSaturation returns nil if saturation detection is disabled.
+func newSaturation(cfg Generator, pool string, maxKeyLen uint8, newKeyLen func(ns string) (key.KeyLenRepository, error)) (*key.Saturation, error) {
+	if cfg.SaturationThreshold <= 0 {
+		return nil, nil
+	}
+	repo, err := newKeyLen(poolNamespace(pool))
+	if err != nil {
+		return nil, fmt.Errorf("failed create key length repository of pool %s: %w", pool, err)
+	}
+	return key.NewSaturation(pool, cfg.SaturationThreshold, maxKeyLen, repo), nil
+}
+
+// loadBlocklist loads the blocklist and starts its hot reload if it's configured.
+// It returns nil if the blocklist is disabled.
+func loadBlocklist(ctx context.Context, cfg Blocklist) (*key.Blocklist, error) {
+	if cfg.Path == "" {
+		return nil, nil
+	}
+	blocklist, err := key.LoadBlocklist(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.ReloadInterval > 0 {
+		go blocklist.Watch(ctx, cfg.ReloadInterval)
+	}
+	return blocklist, nil
+}
+
+// poolNamespace returns a namespace of the pool in repositories.
+// The default pool has no namespace to keep keys that were stored before pools were introduced.
+func poolNamespace(pool string) string {
+	if pool == key.DefaultPool {
+		return ""
+	}
+	return pool
+}
+
+// keysOptions returns options of key.Keys of the pool namespace that depend on the configured repositories.
+// unusedClient is nil if unused keys aren't stored in Redis.
+func keysOptions(cfg config, unusedClient redis.Cmdable, ns string) []key.Option {
+	opts := []key.Option{
+		key.WithMaxBatchSize(cfg.Keys.MaxBatchSize),
+		key.WithReservedLen(cfg.Keys.MinReservedLen, cfg.Keys.MaxReservedLen),
+		key.WithTTLBounds(cfg.Keys.MinTTL, cfg.Keys.MaxTTL),
+		key.WithPrefetch(cfg.Keys.PrefetchSize, cfg.Keys.PrefetchThreshold),
+		key.WithRetry(key.Retry{
+			Attempts:  cfg.Keys.RetryAttempts,
+			Delay:     cfg.Keys.RetryDelay,
+			MaxDelay:  cfg.Keys.RetryMaxDelay,
+			MaxJitter: cfg.Keys.RetryMaxJitter,
+		}),
+		key.WithHoldTimeout(cfg.Keys.HoldTimeout),
+		key.WithUsedCountMaxAge(cfg.Keys.UsedCountMaxAge),
+	}
+	usedInRedis := cfg.UsedKeysRepositoryType == "" || cfg.UsedKeysRepositoryType == UsedKeysRepositoryTypeRedis
+	if usedInRedis && unusedClient != nil && !cfg.RedisUnusedKeys.cluster() && cfg.RedisUsedKeys.sameServer(cfg.RedisUnusedKeys) {
+		// both unused and used keys live in the same Redis instance - keys can be claimed atomically.
+		// Redis Cluster can't change keys of different slots in a single script.
+		opts = append(opts, key.WithClaimer(redisrepo.NewShardedClaimer(unusedClient, cfg.RedisUsedKeys.DB, ns, cfg.UnusedKeysShards)))
+	}
+	return opts
+}
+
 func profiling(cfg config) func() {
 	if !cfg.Profiler.Enabled {
 		return func() {}
@@ -0,0 +1,23 @@
+// Package bolt implements embedded repositories of keys on top of bbolt,
+// so keygen can run as a single binary without external storages.
+// The database file is locked by a single process, so it's suitable only for a single replica.
+package bolt
+
+import (
+	"go.etcd.io/bbolt"
+)
+
+// bucketNameOf returns a name of the bucket of the pool.
+func bucketNameOf(name, pool string) string {
+	if pool == "" {
+		return name
+	}
+	return name + ":" + pool
+}
+
+func createBucket(db *bbolt.DB, name []byte) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(name)
+		return err
+	})
+}
@@ -0,0 +1,54 @@
+package bolt
+
+import (
+	"context"
+
+	"go.etcd.io/bbolt"
+)
+
+const keyLenBucketName = "key_len"
+
+// keyLenKey is the only key of the bucket.
+var keyLenKey = []byte("key_len")
+
+// KeyLen is a key length of the pool grown by the generator (see key.Saturation).
+type KeyLen struct {
+	db     *bbolt.DB
+	bucket []byte
+}
+
+// NewKeyLen creates a new KeyLen of the pool and creates its bucket if it doesn't exist.
+// Every pool keeps its length in a separate bucket.
+func NewKeyLen(db *bbolt.DB, pool string) (*KeyLen, error) {
+	bucket := []byte(bucketNameOf(keyLenBucketName, pool))
+	if err := createBucket(db, bucket); err != nil {
+		return nil, err
+	}
+	return &KeyLen{db: db, bucket: bucket}, nil
+}
+
+// Load returns zero if the key length isn't stored.
+func (l *KeyLen) Load(_ context.Context) (uint8, error) {
+	var keyLen uint8
+	err := l.db.View(func(tx *bbolt.Tx) error {
+		if v := tx.Bucket(l.bucket).Get(keyLenKey); len(v) == 1 {
+			keyLen = v[0]
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return keyLen, nil
+}
+
+// Store sets the key length unless a longer one is stored.
+func (l *KeyLen) Store(_ context.Context, keyLen uint8) error {
+	return l.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(l.bucket)
+		if v := b.Get(keyLenKey); len(v) == 1 && v[0] >= keyLen {
+			return nil
+		}
+		return b.Put(keyLenKey, []byte{keyLen})
+	})
+}
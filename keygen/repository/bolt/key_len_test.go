@@ -0,0 +1,33 @@
+package bolt
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyLen(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+	keyLen, err := NewKeyLen(db, "")
+	require.NoError(t, err)
+
+	actual, err := keyLen.Load(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, actual)
+
+	require.NoError(t, keyLen.Store(ctx, 7))
+	require.NoError(t, keyLen.Store(ctx, 6))
+	actual, err = keyLen.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(7), actual)
+
+	// every pool has its own length
+	other, err := NewKeyLen(db, "sms")
+	require.NoError(t, err)
+	actual, err = other.Load(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, actual)
+}
@@ -0,0 +1,40 @@
+package bolt
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/demeero/pocket-link/keygen/key"
+	"github.com/demeero/pocket-link/keygen/repository/repotest"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+func openDB(t *testing.T) *bbolt.DB {
+	t.Helper()
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "keygen.db"), 0o600, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+	return db
+}
+
+func TestUsedKeys_Suite(t *testing.T) {
+	repotest.UsedKeys(t, func(t *testing.T) (key.UsedKeysRepository, func(time.Duration)) {
+		uk, err := NewUsedKeys(openDB(t), "")
+		require.NoError(t, err)
+		now := time.Now()
+		uk.now = func() time.Time { return now }
+		return uk, func(d time.Duration) { now = now.Add(d) }
+	})
+}
+
+func TestUnusedKeys_Suite(t *testing.T) {
+	repotest.UnusedKeys(t, func(t *testing.T) key.UnusedKeysRepository {
+		uk, err := NewUnusedKeys(openDB(t), "")
+		require.NoError(t, err)
+		return uk
+	})
+}
@@ -0,0 +1,142 @@
+package bolt
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+
+	"github.com/demeero/bricks/errbrick"
+	"go.etcd.io/bbolt"
+)
+
+const unusedBucketName = "unused_keys"
+
+type UnusedKeys struct {
+	db     *bbolt.DB
+	bucket []byte
+}
+
+// NewUnusedKeys creates a new UnusedKeys of the pool and creates its bucket if it doesn't exist.
+// Every pool keeps its keys in a separate bucket.
+func NewUnusedKeys(db *bbolt.DB, pool string) (*UnusedKeys, error) {
+	bucket := []byte(bucketNameOf(unusedBucketName, pool))
+	if err := createBucket(db, bucket); err != nil {
+		return nil, err
+	}
+	return &UnusedKeys{db: db, bucket: bucket}, nil
+}
+
+func (u *UnusedKeys) LoadAndDelete(ctx context.Context) (string, error) {
+	keys, err := u.LoadAndDeleteN(ctx, 1)
+	if err != nil {
+		return "", err
+	}
+	return keys[0], nil
+}
+
+// LoadAndDeleteN pops up to n keys at random positions, since bbolt keeps keys sorted and the first keys
+// are predictable. Every key is the first one after a random point between the first and the last keys
+// (wrapping around to the first key). Keys after wider gaps are picked more often, so the choice isn't uniform.
+func (u *UnusedKeys) LoadAndDeleteN(_ context.Context, n int64) ([]string, error) {
+	var result []string
+	err := u.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(u.bucket)
+		for int64(len(result)) < n {
+			// the cursor is created for every key since deleting keys invalidates its position
+			c := b.Cursor()
+			first, _ := c.First()
+			if first == nil {
+				return nil
+			}
+			last, _ := c.Last()
+			point, err := randomBetween(first, last)
+			if err != nil {
+				return err
+			}
+			k, _ := c.Seek(point)
+			if k == nil {
+				k, _ = c.First()
+			}
+			result = append(result, string(k))
+			if err := b.Delete([]byte(result[len(result)-1])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, errbrick.ErrNotFound
+	}
+	return result, nil
+}
+
+// randomBetween returns a random key between the first and the last keys. The keys are compared as big-endian
+// numbers of the same length, so the shorter one is padded with zero bytes.
+func randomBetween(first, last []byte) ([]byte, error) {
+	size := max(len(first), len(last))
+	lo := new(big.Int).SetBytes(padRight(first, size))
+	span := new(big.Int).SetBytes(padRight(last, size))
+	span.Sub(span, lo).Add(span, big.NewInt(1))
+	point, err := rand.Int(rand.Reader, span)
+	if err != nil {
+		return nil, err
+	}
+	return point.Add(point, lo).FillBytes(make([]byte, size)), nil
+}
+
+func padRight(k []byte, size int) []byte {
+	return append(append(make([]byte, 0, size), k...), make([]byte, size-len(k))...)
+}
+
+// Store adds keys and returns the number of keys that haven't been stored before.
+func (u *UnusedKeys) Store(_ context.Context, k ...string) (int64, error) {
+	var stored int64
+	err := u.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(u.bucket)
+		for _, key := range k {
+			if b.Get([]byte(key)) != nil {
+				continue
+			}
+			if err := b.Put([]byte(key), []byte{}); err != nil {
+				return err
+			}
+			stored++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return stored, nil
+}
+
+func (u *UnusedKeys) Delete(_ context.Context, k string) (bool, error) {
+	var deleted bool
+	err := u.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(u.bucket)
+		if b.Get([]byte(k)) == nil {
+			return nil
+		}
+		deleted = true
+		return b.Delete([]byte(k))
+	})
+	if err != nil {
+		return false, err
+	}
+	return deleted, nil
+}
+
+func (u *UnusedKeys) Size(_ context.Context) (int64, error) {
+	var size int64
+	err := u.db.View(func(tx *bbolt.Tx) error {
+		size = int64(tx.Bucket(u.bucket).Stats().KeyN)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return size, nil
+}
@@ -0,0 +1,264 @@
+package bolt
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/demeero/bricks/errbrick"
+	"go.etcd.io/bbolt"
+)
+
+const usedBucketName = "used_keys"
+
+// mdIssuedAt is a name of the metadata field with the issue time of the used key (see key.Keys.Lookup).
+const mdIssuedAt = "issued_at"
+
+// sweepBatchSize is a maximum number of expired keys that are deleted by a single transaction,
+// so the sweeper doesn't block writers for long.
+const sweepBatchSize = 10000
+
+// UsedKeys keeps used keys with their expiration time followed by metadata encoded as JSON.
+// Keys that were stored before issue times were recorded have no metadata.
+// Expired keys are treated as absent and deleted by the sweeper, since bbolt doesn't expire keys by itself.
+type UsedKeys struct {
+	db     *bbolt.DB
+	now    func() time.Time
+	bucket []byte
+}
+
+// NewUsedKeys creates a new UsedKeys of the pool and creates its bucket if it doesn't exist.
+// Every pool keeps its keys in a separate bucket, so the same key can be used in different pools.
+func NewUsedKeys(db *bbolt.DB, pool string) (*UsedKeys, error) {
+	bucket := []byte(bucketNameOf(usedBucketName, pool))
+	if err := createBucket(db, bucket); err != nil {
+		return nil, err
+	}
+	return &UsedKeys{db: db, bucket: bucket, now: time.Now}, nil
+}
+
+// Store puts the key with metadata that has its issue time unless the key is alive.
+func (u *UsedKeys) Store(_ context.Context, k string, ttl time.Duration) (bool, error) {
+	var stored bool
+	err := u.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(u.bucket)
+		now := u.now()
+		if alive(b.Get([]byte(k)), now) {
+			return nil
+		}
+		md, err := json.Marshal(map[string]string{mdIssuedAt: now.UTC().Format(time.RFC3339Nano)})
+		if err != nil {
+			return err
+		}
+		stored = true
+		return b.Put([]byte(k), append(encodeExpAt(now.Add(ttl)), md...))
+	})
+	if err != nil {
+		return false, err
+	}
+	return stored, nil
+}
+
+func (u *UsedKeys) Exists(ctx context.Context, k string) (bool, error) {
+	result, err := u.ExistsMany(ctx, []string{k})
+	if err != nil {
+		return false, err
+	}
+	return result[0], nil
+}
+
+// ExistsMany checks existence of the keys in a single transaction.
+func (u *UsedKeys) ExistsMany(_ context.Context, keys []string) ([]bool, error) {
+	result := make([]bool, len(keys))
+	err := u.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(u.bucket)
+		now := u.now()
+		for i, k := range keys {
+			result[i] = alive(b.Get([]byte(k)), now)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *UsedKeys) Delete(_ context.Context, k string) (bool, error) {
+	var deleted bool
+	err := u.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(u.bucket)
+		v := b.Get([]byte(k))
+		if v == nil {
+			return nil
+		}
+		deleted = alive(v, u.now())
+		return b.Delete([]byte(k))
+	})
+	if err != nil {
+		return false, err
+	}
+	return deleted, nil
+}
+
+// Extend sets a new expiration time of the key.
+// Expired keys are not extended even if the sweeper hasn't deleted them yet.
+func (u *UsedKeys) Extend(_ context.Context, k string, expAt time.Time) (bool, error) {
+	var extended bool
+	err := u.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(u.bucket)
+		v := b.Get([]byte(k))
+		if !alive(v, u.now()) {
+			return nil
+		}
+		extended = true
+		// the metadata is kept
+		return b.Put([]byte(k), append(encodeExpAt(expAt), v[8:]...))
+	})
+	if err != nil {
+		return false, err
+	}
+	return extended, nil
+}
+
+// Annotate sets metadata of the key that hasn't expired yet.
+func (u *UsedKeys) Annotate(_ context.Context, k string, md map[string]string) (bool, error) {
+	encoded, err := json.Marshal(md)
+	if err != nil {
+		return false, err
+	}
+	var annotated bool
+	err = u.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(u.bucket)
+		v := b.Get([]byte(k))
+		if !alive(v, u.now()) {
+			return nil
+		}
+		annotated = true
+		return b.Put([]byte(k), append(append([]byte(nil), v[:8]...), encoded...))
+	})
+	if err != nil {
+		return false, err
+	}
+	return annotated, nil
+}
+
+// Lookup loads the key that hasn't expired yet with its metadata.
+func (u *UsedKeys) Lookup(_ context.Context, k string) (map[string]string, time.Time, error) {
+	var (
+		md    map[string]string
+		expAt time.Time
+		found bool
+	)
+	err := u.db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket(u.bucket).Get([]byte(k))
+		if !alive(v, u.now()) {
+			return nil
+		}
+		found = true
+		expAt = decodeExpAt(v)
+		if len(v) == 8 {
+			return nil
+		}
+		return json.Unmarshal(v[8:], &md)
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if !found {
+		return nil, time.Time{}, fmt.Errorf("%w: key is not used: %s", errbrick.ErrNotFound, k)
+	}
+	return md, expAt, nil
+}
+
+// Count counts keys that haven't expired yet. It iterates over all keys of the pool.
+func (u *UsedKeys) Count(_ context.Context) (int64, error) {
+	var count int64
+	err := u.db.View(func(tx *bbolt.Tx) error {
+		now := u.now()
+		return tx.Bucket(u.bucket).ForEach(func(_, v []byte) error {
+			if alive(v, now) {
+				count++
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Sweep deletes expired keys in batches and returns the number of deleted keys.
+func (u *UsedKeys) Sweep(_ context.Context) (int64, error) {
+	var total int64
+	for {
+		var expired [][]byte
+		err := u.db.Update(func(tx *bbolt.Tx) error {
+			b := tx.Bucket(u.bucket)
+			now := u.now()
+			c := b.Cursor()
+			for k, v := c.First(); k != nil && len(expired) < sweepBatchSize; k, v = c.Next() {
+				if !alive(v, now) {
+					// the key is only valid during the transaction
+					expired = append(expired, append([]byte(nil), k...))
+				}
+			}
+			for _, k := range expired {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += int64(len(expired))
+		if len(expired) < sweepBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// RunSweeper deletes expired keys every interval. It blocks until ctx is done.
+func (u *UsedKeys) RunSweeper(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			deleted, err := u.Sweep(ctx)
+			if err != nil {
+				slog.Error("failed sweep expired used keys", slog.String("bucket", string(u.bucket)), slog.Any("err", err))
+				continue
+			}
+			if deleted > 0 {
+				slog.Debug("swept expired used keys", slog.String("bucket", string(u.bucket)), slog.Int64("deleted", deleted))
+			}
+		}
+	}
+}
+
+// alive reports whether the stored expiration time v is after now. A missing key isn't alive.
+func alive(v []byte, now time.Time) bool {
+	if len(v) < 8 {
+		return false
+	}
+	return decodeExpAt(v).After(now)
+}
+
+func decodeExpAt(v []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(v[:8]))) //nolint:gosec // expiration time is always positive
+}
+
+func encodeExpAt(expAt time.Time) []byte {
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, uint64(expAt.UnixNano())) //nolint:gosec // expiration time is always positive
+	return v
+}
@@ -0,0 +1,75 @@
+package bolt
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+func TestUsedKeys_Sweep(t *testing.T) {
+	uk, err := NewUsedKeys(openDB(t), "")
+	require.NoError(t, err)
+	now := time.Now()
+	uk.now = func() time.Time { return now }
+	_, err = uk.Store(context.Background(), "k1", time.Minute)
+	require.NoError(t, err)
+	_, err = uk.Store(context.Background(), "k2", time.Hour)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	deleted, err := uk.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	err = uk.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(uk.bucket)
+		assert.Nil(t, b.Get([]byte("k1")))
+		assert.NotNil(t, b.Get([]byte("k2")))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestUsedKeys_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keygen.db")
+	db, err := bbolt.Open(path, 0o600, nil)
+	require.NoError(t, err)
+	uk, err := NewUsedKeys(db, "")
+	require.NoError(t, err)
+	_, err = uk.Store(context.Background(), "k1", time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = bbolt.Open(path, 0o600, nil)
+	require.NoError(t, err)
+	defer db.Close()
+	uk, err = NewUsedKeys(db, "")
+	require.NoError(t, err)
+
+	exists, err := uk.Exists(context.Background(), "k1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestUsedKeys_Pool(t *testing.T) {
+	db := openDB(t)
+	uk, err := NewUsedKeys(db, "")
+	require.NoError(t, err)
+	smsUK, err := NewUsedKeys(db, "sms")
+	require.NoError(t, err)
+	_, err = uk.Store(context.Background(), "k1", time.Hour)
+	require.NoError(t, err)
+
+	exists, err := smsUK.Exists(context.Background(), "k1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	stored, err := smsUK.Store(context.Background(), "k1", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, stored)
+}
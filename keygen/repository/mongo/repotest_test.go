@@ -0,0 +1,40 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	keygenkey "github.com/demeero/pocket-link/keygen/key"
+	"github.com/demeero/pocket-link/keygen/repository/repotest"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestUsedKeys_Suite runs against a real MongoDB, since the mocked one can't keep state between calls.
+// It's skipped if TEST_MONGO_URI isn't set.
+func TestUsedKeys_Suite(t *testing.T) {
+	uri := os.Getenv("TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("TEST_MONGO_URI is not set")
+	}
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	db := client.Database(fmt.Sprintf("pocket-link-test-%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		require.NoError(t, db.Drop(context.Background()))
+		require.NoError(t, client.Disconnect(context.Background()))
+	})
+
+	var pools int
+	repotest.UsedKeys(t, func(t *testing.T) (keygenkey.UsedKeysRepository, func(time.Duration)) {
+		// every subtest gets its own collection
+		pools++
+		uk, err := NewUsedKeys(db, fmt.Sprintf("suite%d", pools))
+		require.NoError(t, err)
+		return uk, nil
+	})
+}
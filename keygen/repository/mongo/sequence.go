@@ -0,0 +1,43 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const sequencesCollectionName = "sequences"
+
+type sequence struct {
+	ID    string `bson:"_id"`
+	Value int64  `bson:"value"`
+}
+
+// Sequence is a sequence of key values stored in a document of the sequences collection.
+type Sequence struct {
+	coll *mongo.Collection
+	id   string
+}
+
+// NewSequence creates a new Sequence of the pool.
+// Every pool has a separate document.
+func NewSequence(db *mongo.Database, pool string) *Sequence {
+	id := "keys"
+	if pool != "" {
+		id += "_" + pool
+	}
+	return &Sequence{coll: db.Collection(sequencesCollectionName), id: id}
+}
+
+// Next reserves n next values atomically and returns the first of them.
+func (s *Sequence) Next(ctx context.Context, n int64) (int64, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var result sequence
+	err := s.coll.FindOneAndUpdate(ctx, bson.M{"_id": s.id}, bson.M{"$inc": bson.M{"value": n}}, opts).Decode(&result)
+	if err != nil {
+		return 0, err
+	}
+	return result.Value - n, nil
+}
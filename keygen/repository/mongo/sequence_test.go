@@ -0,0 +1,28 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestSequence_Next(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("next", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: bson.D{{Key: "_id", Value: "keys"}, {Key: "value", Value: int64(15)}}}})
+		first, err := NewSequence(mt.DB, "").Next(context.Background(), 5)
+		require.NoError(mt, err)
+		assert.Equal(mt, int64(10), first)
+	})
+
+	mt.Run("error", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 0}})
+		_, err := NewSequence(mt.DB, "").Next(context.Background(), 5)
+		assert.Error(mt, err)
+	})
+}
@@ -3,24 +3,32 @@ package mongo
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/demeero/bricks/errbrick"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// mdIssuedAt is a name of the metadata field with the issue time of the used key (see key.Keys.Lookup).
+const mdIssuedAt = "issued_at"
+
 type key struct {
-	ExpAt time.Time `bson:"exp_at"`
-	ID    string    `bson:"_id"`
+	ExpAt time.Time         `bson:"exp_at"`
+	MD    map[string]string `bson:"md,omitempty"`
+	ID    string            `bson:"_id"`
 }
 
 type UsedKeys struct {
 	coll *mongo.Collection
 }
 
-func NewUsedKeys(db *mongo.Database) (*UsedKeys, error) {
-	coll := db.Collection("used_keys")
+// NewUsedKeys creates a new UsedKeys of the pool.
+// Every pool keeps its keys in a separate collection, so the same key can be used in different pools.
+func NewUsedKeys(db *mongo.Database, pool string) (*UsedKeys, error) {
+	coll := db.Collection(usedCollectionNameOf(pool))
 	ind := mongo.IndexModel{
 		Keys: bson.M{"exp_at": 1}, Options: options.Index().SetExpireAfterSeconds(0),
 	}
@@ -32,11 +40,25 @@ func NewUsedKeys(db *mongo.Database) (*UsedKeys, error) {
 	return &UsedKeys{coll: coll}, nil
 }
 
+// usedCollectionNameOf returns a name of the collection with used keys of the pool.
+// Empty pool keeps the collection name that was used before pools were introduced.
+func usedCollectionNameOf(pool string) string {
+	if pool == "" {
+		return "used_keys"
+	}
+	return "used_keys_" + pool
+}
+
+// Store inserts the key or overwrites it if it has expired, so expired keys are treated as absent
+// even if MongoDB hasn't deleted them yet. The upsert doesn't match a key that hasn't expired
+// and fails to insert it with a duplicate key error, so the key is already used.
+// The key is stored with metadata that has its issue time.
 func (u *UsedKeys) Store(ctx context.Context, k string, ttl time.Duration) (bool, error) {
-	_, err := u.coll.InsertOne(ctx, key{
-		ID:    k,
-		ExpAt: time.Now().Add(ttl).UTC(),
-	})
+	now := time.Now().UTC()
+	filter := bson.M{"_id": k, "exp_at": bson.M{"$lte": now}}
+	md := bson.M{mdIssuedAt: now.Format(time.RFC3339Nano)}
+	update := bson.M{"$set": bson.M{"exp_at": now.Add(ttl), "md": md}}
+	_, err := u.coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
 	if mongo.IsDuplicateKeyError(err) {
 		return false, nil
 	}
@@ -46,8 +68,9 @@ func (u *UsedKeys) Store(ctx context.Context, k string, ttl time.Duration) (bool
 	return true, nil
 }
 
+// Exists checks the key that hasn't expired yet, since MongoDB deletes expired keys with a delay.
 func (u *UsedKeys) Exists(ctx context.Context, k string) (bool, error) {
-	err := u.coll.FindOne(ctx, bson.M{"_id": k}).Err()
+	err := u.coll.FindOne(ctx, bson.M{"_id": k, "exp_at": bson.M{"$gt": time.Now().UTC()}}).Err()
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return false, nil
 	}
@@ -56,3 +79,120 @@ func (u *UsedKeys) Exists(ctx context.Context, k string) (bool, error) {
 	}
 	return true, nil
 }
+
+// ExistsMany checks existence of the keys that haven't expired yet with a single $in query.
+func (u *UsedKeys) ExistsMany(ctx context.Context, keys []string) ([]bool, error) {
+	filter := bson.M{"_id": bson.M{"$in": keys}, "exp_at": bson.M{"$gt": time.Now().UTC()}}
+	cursor, err := u.coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var found []key
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	existed := make(map[string]bool, len(found))
+	for _, k := range found {
+		existed[k.ID] = true
+	}
+	result := make([]bool, len(keys))
+	for i, k := range keys {
+		result[i] = existed[k]
+	}
+	return result, nil
+}
+
+func (u *UsedKeys) Delete(ctx context.Context, k string) (bool, error) {
+	result, err := u.coll.DeleteOne(ctx, bson.M{"_id": k})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount == 1, nil
+}
+
+// Extend sets a new expiration time of the key.
+// Expired keys are not extended even if MongoDB hasn't deleted them yet.
+func (u *UsedKeys) Extend(ctx context.Context, k string, expAt time.Time) (bool, error) {
+	filter := bson.M{"_id": k, "exp_at": bson.M{"$gt": time.Now().UTC()}}
+	result, err := u.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"exp_at": expAt.UTC()}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// Annotate sets metadata of the key that hasn't expired yet.
+func (u *UsedKeys) Annotate(ctx context.Context, k string, md map[string]string) (bool, error) {
+	filter := bson.M{"_id": k, "exp_at": bson.M{"$gt": time.Now().UTC()}}
+	result, err := u.coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"md": md}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// Lookup loads the key that hasn't expired yet with its metadata.
+func (u *UsedKeys) Lookup(ctx context.Context, k string) (map[string]string, time.Time, error) {
+	var found key
+	err := u.coll.FindOne(ctx, bson.M{"_id": k, "exp_at": bson.M{"$gt": time.Now().UTC()}}).Decode(&found)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, time.Time{}, fmt.Errorf("%w: key is not used: %s", errbrick.ErrNotFound, k)
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return found.MD, found.ExpAt, nil
+}
+
+// Count counts keys that haven't expired yet, since MongoDB deletes expired keys with a delay.
+func (u *UsedKeys) Count(ctx context.Context) (int64, error) {
+	return u.coll.CountDocuments(ctx, bson.M{"exp_at": bson.M{"$gt": time.Now().UTC()}})
+}
+
+// Scan reads keys that haven't expired in the order of keys, so every page starts after the last key of the previous one.
+func (u *UsedKeys) Scan(ctx context.Context, pageSize int64, fn func(map[string]time.Time) error) error {
+	var after string
+	for {
+		filter := bson.M{"_id": bson.M{"$gt": after}, "exp_at": bson.M{"$gt": time.Now().UTC()}}
+		cursor, err := u.coll.Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}).SetLimit(pageSize))
+		if err != nil {
+			return err
+		}
+		var found []key
+		if err := cursor.All(ctx, &found); err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			return nil
+		}
+		page := make(map[string]time.Time, len(found))
+		for _, k := range found {
+			page[k.ID] = k.ExpAt
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if int64(len(found)) < pageSize {
+			return nil
+		}
+		after = found[len(found)-1].ID
+	}
+}
+
+// ExpiresAt loads keys that haven't expired with a single $in query.
+func (u *UsedKeys) ExpiresAt(ctx context.Context, keys []string) (map[string]time.Time, error) {
+	filter := bson.M{"_id": bson.M{"$in": keys}, "exp_at": bson.M{"$gt": time.Now().UTC()}}
+	cursor, err := u.coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var found []key
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	result := make(map[string]time.Time, len(found))
+	for _, k := range found {
+		result[k.ID] = k.ExpAt
+	}
+	return result, nil
+}
@@ -5,25 +5,25 @@ import (
 	"testing"
 	"time"
 
+	"github.com/demeero/bricks/errbrick"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
 )
 
-// nolint:govet
 func TestUsedKeys_Exists(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 
 	k := "existed_test_key"
 
 	mt.Run("exists", func(mt *mtest.T) {
-		mt.AddMockResponses(bson.D{{"ok", 1}})
-		repo, err := NewUsedKeys(mt.DB)
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
 		require.NoError(mt, err)
 
 		mt.AddMockResponses(mtest.CreateCursorResponse(1, "foo.bar", mtest.FirstBatch, bson.D{
-			{"_id", k},
+			{Key: "_id", Value: k},
 		}))
 		ok, err := repo.Exists(context.Background(), k)
 		assert.NoError(mt, err)
@@ -31,19 +31,19 @@ func TestUsedKeys_Exists(t *testing.T) {
 	})
 
 	mt.Run("error", func(mt *mtest.T) {
-		mt.AddMockResponses(bson.D{{"ok", 1}})
-		repo, err := NewUsedKeys(mt.DB)
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
 		require.NoError(mt, err)
 
-		mt.AddMockResponses(bson.D{{"ok", 0}})
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 0}})
 		ok, err := repo.Exists(context.Background(), k)
 		assert.Error(mt, err)
 		assert.False(mt, ok)
 	})
 
 	mt.Run("not exists", func(mt *mtest.T) {
-		mt.AddMockResponses(bson.D{{"ok", 1}})
-		repo, err := NewUsedKeys(mt.DB)
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
 		require.NoError(mt, err)
 
 		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch))
@@ -53,26 +53,67 @@ func TestUsedKeys_Exists(t *testing.T) {
 	})
 }
 
-// nolint:govet
+func TestUsedKeys_ExistsMany(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("exists", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch, bson.D{{Key: "_id", Value: "k1"}}, bson.D{{Key: "_id", Value: "k3"}}))
+		actual, err := repo.ExistsMany(context.Background(), []string{"k1", "k2", "k3"})
+		assert.NoError(mt, err)
+		assert.Equal(mt, []bool{true, false, true}, actual)
+	})
+
+	mt.Run("error", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 0}})
+		actual, err := repo.ExistsMany(context.Background(), []string{"k1"})
+		assert.Error(mt, err)
+		assert.Nil(mt, actual)
+	})
+}
+
 func TestUsedKeys_Store(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 
 	k := "test_key"
 
 	mt.Run("success", func(mt *mtest.T) {
-		mt.AddMockResponses(bson.D{{"ok", 1}})
-		repo, err := NewUsedKeys(mt.DB)
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
 		require.NoError(mt, err)
 
-		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		mt.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "n", Value: 1},
+			{Key: "upserted", Value: bson.A{bson.D{{Key: "index", Value: 0}, {Key: "_id", Value: k}}}},
+		})
+		ok, err := repo.Store(context.Background(), k, time.Second)
+		assert.NoError(mt, err)
+		assert.True(mt, ok)
+	})
+
+	mt.Run("expired", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		// the expired key that MongoDB hasn't deleted yet is overwritten
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
 		ok, err := repo.Store(context.Background(), k, time.Second)
 		assert.NoError(mt, err)
 		assert.True(mt, ok)
 	})
 
 	mt.Run("duplicate key", func(mt *mtest.T) {
-		mt.AddMockResponses(bson.D{{"ok", 1}})
-		repo, err := NewUsedKeys(mt.DB)
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
 		require.NoError(mt, err)
 
 		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
@@ -86,13 +127,244 @@ func TestUsedKeys_Store(t *testing.T) {
 	})
 
 	mt.Run("error", func(mt *mtest.T) {
-		mt.AddMockResponses(bson.D{{"ok", 1}})
-		repo, err := NewUsedKeys(mt.DB)
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
 		require.NoError(mt, err)
 
-		mt.AddMockResponses(bson.D{{"ok", 0}})
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 0}})
 		ok, err := repo.Store(context.Background(), k, time.Second)
 		assert.Error(mt, err)
 		assert.False(mt, ok)
 	})
 }
+
+func TestUsedKeys_Delete(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	k := "test_key"
+
+	mt.Run("success", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}})
+		ok, err := repo.Delete(context.Background(), k)
+		assert.NoError(mt, err)
+		assert.True(mt, ok)
+	})
+
+	mt.Run("not exists", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}})
+		ok, err := repo.Delete(context.Background(), k)
+		assert.NoError(mt, err)
+		assert.False(mt, ok)
+	})
+
+	mt.Run("error", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 0}})
+		ok, err := repo.Delete(context.Background(), k)
+		assert.Error(mt, err)
+		assert.False(mt, ok)
+	})
+}
+
+func TestUsedKeys_Count(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("success", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch, bson.D{{Key: "n", Value: 42}}))
+		count, err := repo.Count(context.Background())
+		assert.NoError(mt, err)
+		assert.Equal(mt, int64(42), count)
+	})
+
+	mt.Run("error", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 0}})
+		_, err = repo.Count(context.Background())
+		assert.Error(mt, err)
+	})
+}
+
+func TestUsedKeys_Extend(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	k := "test_key"
+	expAt := time.Now().Add(48 * time.Hour)
+
+	mt.Run("success", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+		ok, err := repo.Extend(context.Background(), k, expAt)
+		assert.NoError(mt, err)
+		assert.True(mt, ok)
+	})
+
+	mt.Run("expired", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}})
+		ok, err := repo.Extend(context.Background(), k, expAt)
+		assert.NoError(mt, err)
+		assert.False(mt, ok)
+	})
+
+	mt.Run("error", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 0}})
+		ok, err := repo.Extend(context.Background(), k, expAt)
+		assert.Error(mt, err)
+		assert.False(mt, ok)
+	})
+}
+
+func TestUsedKeys_Pool(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("default", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+		assert.Equal(mt, "used_keys", repo.coll.Name())
+	})
+
+	mt.Run("named", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "sms")
+		require.NoError(mt, err)
+		assert.Equal(mt, "used_keys_sms", repo.coll.Name())
+	})
+}
+
+func TestUsedKeys_Scan(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	expAt := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+
+	mt.Run("pages", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch, bson.D{{Key: "_id", Value: "k1"}, {Key: "exp_at", Value: expAt}}, bson.D{{Key: "_id", Value: "k2"}, {Key: "exp_at", Value: expAt}}),
+			mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch, bson.D{{Key: "_id", Value: "k3"}, {Key: "exp_at", Value: expAt}}),
+		)
+		var pages []map[string]time.Time
+		err = repo.Scan(context.Background(), 2, func(page map[string]time.Time) error {
+			pages = append(pages, page)
+			return nil
+		})
+		require.NoError(mt, err)
+		assert.Equal(mt, []map[string]time.Time{{"k1": expAt, "k2": expAt}, {"k3": expAt}}, pages)
+	})
+
+	mt.Run("error", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 0}})
+		err = repo.Scan(context.Background(), 2, func(map[string]time.Time) error { return nil })
+		assert.Error(mt, err)
+	})
+}
+
+func TestUsedKeys_ExpiresAt(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	expAt := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+
+	mt.Run("success", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch, bson.D{{Key: "_id", Value: "k1"}, {Key: "exp_at", Value: expAt}}))
+		actual, err := repo.ExpiresAt(context.Background(), []string{"k1", "k2"})
+		require.NoError(mt, err)
+		assert.Equal(mt, map[string]time.Time{"k1": expAt}, actual)
+	})
+}
+
+func TestUsedKeys_Annotate(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	md := map[string]string{"client_id": "c1"}
+
+	mt.Run("success", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}})
+		ok, err := repo.Annotate(context.Background(), "k1", md)
+		assert.NoError(mt, err)
+		assert.True(mt, ok)
+	})
+
+	mt.Run("expired", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}})
+		ok, err := repo.Annotate(context.Background(), "k1", md)
+		assert.NoError(mt, err)
+		assert.False(mt, ok)
+	})
+}
+
+func TestUsedKeys_Lookup(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	expAt := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+
+	mt.Run("found", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "foo.bar", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: "k1"}, {Key: "exp_at", Value: expAt}, {Key: "md", Value: bson.D{{Key: "client_id", Value: "c1"}}},
+		}))
+		md, actualExpAt, err := repo.Lookup(context.Background(), "k1")
+		require.NoError(mt, err)
+		assert.Equal(mt, map[string]string{"client_id": "c1"}, md)
+		assert.True(mt, expAt.Equal(actualExpAt))
+	})
+
+	mt.Run("not found", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}})
+		repo, err := NewUsedKeys(mt.DB, "")
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "foo.bar", mtest.FirstBatch))
+		_, _, err = repo.Lookup(context.Background(), "k1")
+		assert.ErrorIs(mt, err, errbrick.ErrNotFound)
+	})
+}
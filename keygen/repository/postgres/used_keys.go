@@ -0,0 +1,304 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/demeero/bricks/errbrick"
+	"github.com/jackc/pgx/v5"
+)
+
+// sweepBatchSize is a maximum number of expired keys that are deleted by a single statement,
+// so the sweeper doesn't hold locks on a large number of rows at once.
+const sweepBatchSize = 10000
+
+// mdIssuedAt is a name of the metadata field with the issue time of the used key (see key.Keys.Lookup).
+const mdIssuedAt = "issued_at"
+
+type UsedKeys struct {
+	db          *sql.DB
+	table       string
+	storeSQL    string
+	existsSQL   string
+	existsNSQL  string
+	deleteSQL   string
+	extendSQL   string
+	sweepSQL    string
+	countSQL    string
+	scanSQL     string
+	expAtSQL    string
+	annotateSQL string
+	lookupSQL   string
+}
+
+// NewUsedKeys creates a new UsedKeys of the pool and creates its table if it doesn't exist.
+// Every pool keeps its keys in a separate table, so the same key can be used in different pools.
+func NewUsedKeys(db *sql.DB, pool string) (*UsedKeys, error) {
+	name := usedTableNameOf(pool)
+	table := pgx.Identifier{name}.Sanitize()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	stmts := []string{
+		"CREATE TABLE IF NOT EXISTS " + table + " (id TEXT PRIMARY KEY, exp_at TIMESTAMPTZ NOT NULL)",
+		"CREATE INDEX IF NOT EXISTS " + pgx.Identifier{name + "_exp_at_idx"}.Sanitize() + " ON " + table + " (exp_at)",
+		// tables created before metadata was introduced get the column on start up
+		"ALTER TABLE " + table + " ADD COLUMN IF NOT EXISTS md JSONB",
+	}
+	for _, stmt := range stmts {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, err
+		}
+	}
+	// an expired key that the sweeper hasn't deleted yet is overwritten as if it didn't exist
+	storeSQL := "INSERT INTO " + table + " (id, exp_at, md) VALUES ($1, $2, $4) " +
+		"ON CONFLICT (id) DO UPDATE SET exp_at = EXCLUDED.exp_at, md = EXCLUDED.md WHERE " + table + ".exp_at <= $3"
+	// the table name can't be a query parameter, so queries are built once with the sanitized name
+	return &UsedKeys{
+		db:          db,
+		table:       table,
+		storeSQL:    storeSQL,
+		existsSQL:   "SELECT EXISTS (SELECT 1 FROM " + table + " WHERE id = $1 AND exp_at > $2)",
+		existsNSQL:  "SELECT id FROM " + table + " WHERE id = ANY($1) AND exp_at > $2",
+		deleteSQL:   "DELETE FROM " + table + " WHERE id = $1",
+		extendSQL:   "UPDATE " + table + " SET exp_at = $2 WHERE id = $1 AND exp_at > $3",
+		sweepSQL:    "DELETE FROM " + table + " WHERE id IN (SELECT id FROM " + table + " WHERE exp_at <= $1 LIMIT $2)",
+		countSQL:    "SELECT count(*) FROM " + table + " WHERE exp_at > $1",
+		scanSQL:     "SELECT id, exp_at FROM " + table + " WHERE id > $1 AND exp_at > $2 ORDER BY id LIMIT $3",
+		expAtSQL:    "SELECT id, exp_at FROM " + table + " WHERE id = ANY($1) AND exp_at > $2",
+		annotateSQL: "UPDATE " + table + " SET md = $2 WHERE id = $1 AND exp_at > $3",
+		lookupSQL:   "SELECT md, exp_at FROM " + table + " WHERE id = $1 AND exp_at > $2",
+	}, nil
+}
+
+// usedTableNameOf returns a name of the table with used keys of the pool.
+func usedTableNameOf(pool string) string {
+	if pool == "" {
+		return "used_keys"
+	}
+	return "used_keys_" + pool
+}
+
+// Store inserts the key or overwrites it if it has expired, so expired keys are treated as absent
+// even if the sweeper hasn't deleted them yet. The key is stored with metadata that has its issue time.
+func (u *UsedKeys) Store(ctx context.Context, k string, ttl time.Duration) (bool, error) {
+	now := time.Now().UTC()
+	md, err := json.Marshal(map[string]string{mdIssuedAt: now.Format(time.RFC3339Nano)})
+	if err != nil {
+		return false, err
+	}
+	result, err := u.db.ExecContext(ctx, u.storeSQL, k, now.Add(ttl), now, string(md))
+	if err != nil {
+		return false, err
+	}
+	stored, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return stored == 1, nil
+}
+
+// Exists checks existence of the key that hasn't expired yet.
+func (u *UsedKeys) Exists(ctx context.Context, k string) (bool, error) {
+	var exists bool
+	err := u.db.QueryRowContext(ctx, u.existsSQL, k, time.Now().UTC()).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// ExistsMany checks existence of the keys that haven't expired yet with a single query.
+func (u *UsedKeys) ExistsMany(ctx context.Context, keys []string) ([]bool, error) {
+	rows, err := u.db.QueryContext(ctx, u.existsNSQL, keys, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	existed := make(map[string]bool, len(keys))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		existed[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	result := make([]bool, len(keys))
+	for i, k := range keys {
+		result[i] = existed[k]
+	}
+	return result, nil
+}
+
+func (u *UsedKeys) Delete(ctx context.Context, k string) (bool, error) {
+	result, err := u.db.ExecContext(ctx, u.deleteSQL, k)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return deleted == 1, nil
+}
+
+// Extend sets a new expiration time of the key.
+// Expired keys are not extended even if the sweeper hasn't deleted them yet.
+func (u *UsedKeys) Extend(ctx context.Context, k string, expAt time.Time) (bool, error) {
+	result, err := u.db.ExecContext(ctx, u.extendSQL, k, expAt.UTC(), time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return updated == 1, nil
+}
+
+// Annotate sets metadata of the key that hasn't expired yet.
+func (u *UsedKeys) Annotate(ctx context.Context, k string, md map[string]string) (bool, error) {
+	v, err := json.Marshal(md)
+	if err != nil {
+		return false, err
+	}
+	result, err := u.db.ExecContext(ctx, u.annotateSQL, k, string(v), time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return updated == 1, nil
+}
+
+// Lookup loads the key that hasn't expired yet with its metadata.
+func (u *UsedKeys) Lookup(ctx context.Context, k string) (map[string]string, time.Time, error) {
+	var (
+		v     []byte
+		expAt time.Time
+	)
+	err := u.db.QueryRowContext(ctx, u.lookupSQL, k, time.Now().UTC()).Scan(&v, &expAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, time.Time{}, fmt.Errorf("%w: key is not used: %s", errbrick.ErrNotFound, k)
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if v == nil {
+		return nil, expAt, nil
+	}
+	var md map[string]string
+	if err := json.Unmarshal(v, &md); err != nil {
+		return nil, time.Time{}, err
+	}
+	return md, expAt, nil
+}
+
+// Sweep deletes expired keys in batches and returns the number of deleted keys.
+func (u *UsedKeys) Sweep(ctx context.Context) (int64, error) {
+	var total int64
+	for {
+		result, err := u.db.ExecContext(ctx, u.sweepSQL, time.Now().UTC(), sweepBatchSize)
+		if err != nil {
+			return total, err
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+		if deleted < sweepBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// RunSweeper deletes expired keys every interval, since PostgreSQL doesn't expire rows by itself.
+// It blocks until ctx is done.
+func (u *UsedKeys) RunSweeper(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			deleted, err := u.Sweep(ctx)
+			if err != nil {
+				slog.Error("failed sweep expired used keys", slog.String("table", u.table), slog.Any("err", err))
+				continue
+			}
+			if deleted > 0 {
+				slog.Debug("swept expired used keys", slog.String("table", u.table), slog.Int64("deleted", deleted))
+			}
+		}
+	}
+}
+
+// Count counts keys that haven't expired yet, since the sweeper deletes expired keys with a delay.
+func (u *UsedKeys) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := u.db.QueryRowContext(ctx, u.countSQL, time.Now().UTC()).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Scan reads keys that haven't expired in the order of keys, so every page starts after the last row of the previous one.
+func (u *UsedKeys) Scan(ctx context.Context, pageSize int64, fn func(map[string]time.Time) error) error {
+	var after string
+	for {
+		page, last, err := u.queryExpAt(ctx, u.scanSQL, after, time.Now().UTC(), pageSize)
+		if err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			return nil
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if int64(len(page)) < pageSize {
+			return nil
+		}
+		// the next page starts after the last row in the order of the query (the collation of the DB)
+		after = last
+	}
+}
+
+// ExpiresAt loads keys that haven't expired with a single query.
+func (u *UsedKeys) ExpiresAt(ctx context.Context, keys []string) (map[string]time.Time, error) {
+	result, _, err := u.queryExpAt(ctx, u.expAtSQL, keys, time.Now().UTC())
+	return result, err
+}
+
+// queryExpAt loads expiration times of keys selected by the query. It also returns the key of the last row.
+func (u *UsedKeys) queryExpAt(ctx context.Context, query string, args ...any) (map[string]time.Time, string, error) {
+	rows, err := u.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+	var last string
+	result := make(map[string]time.Time)
+	for rows.Next() {
+		var expAt time.Time
+		if err := rows.Scan(&last, &expAt); err != nil {
+			return nil, "", err
+		}
+		result[last] = expAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+	return result, last, nil
+}
@@ -0,0 +1,301 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/demeero/bricks/errbrick"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// passConverter passes query arguments to the mock as is, so slices can be used as arguments like with pgx.
+type passConverter struct{}
+
+func (passConverter) ConvertValue(v interface{}) (driver.Value, error) {
+	return v, nil
+}
+
+func newMock(t *testing.T, pool string) (*UsedKeys, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(passConverter{}))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE INDEX IF NOT EXISTS")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE")).WillReturnResult(sqlmock.NewResult(0, 0))
+	uk, err := NewUsedKeys(db, pool)
+	require.NoError(t, err)
+	return uk, mock
+}
+
+func TestNewUsedKeys_Err(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS")).WillReturnError(sql.ErrConnDone)
+
+	uk, err := NewUsedKeys(db, "")
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.Nil(t, uk)
+}
+
+func TestUsedKeys_Store(t *testing.T) {
+	uk, mock := newMock(t, "")
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "used_keys" (id, exp_at, md) VALUES ($1, $2, $4) `+
+		`ON CONFLICT (id) DO UPDATE SET exp_at = EXCLUDED.exp_at, md = EXCLUDED.md WHERE "used_keys".exp_at <= $3`)).
+		WithArgs("k1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	stored, err := uk.Store(context.Background(), "k1", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, stored)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsedKeys_StoreDuplicate(t *testing.T) {
+	uk, mock := newMock(t, "")
+	// the key hasn't expired, so it isn't overwritten
+	mock.ExpectExec("INSERT INTO").WithArgs("k1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	stored, err := uk.Store(context.Background(), "k1", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, stored)
+}
+
+func TestUsedKeys_Store_Err(t *testing.T) {
+	uk, mock := newMock(t, "")
+	mock.ExpectExec("INSERT INTO").WillReturnError(sql.ErrConnDone)
+
+	stored, err := uk.Store(context.Background(), "k1", time.Hour)
+	assert.Error(t, err)
+	assert.False(t, stored)
+}
+
+func TestUsedKeys_Exists(t *testing.T) {
+	uk, mock := newMock(t, "")
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM "used_keys" WHERE id = $1 AND exp_at > $2)`)).
+		WithArgs("k1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs("k2", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	exists, err := uk.Exists(context.Background(), "k1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = uk.Exists(context.Background(), "k2")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestUsedKeys_Exists_Err(t *testing.T) {
+	uk, mock := newMock(t, "")
+	mock.ExpectQuery("SELECT EXISTS").WillReturnError(sql.ErrConnDone)
+
+	exists, err := uk.Exists(context.Background(), "k1")
+	assert.Error(t, err)
+	assert.False(t, exists)
+}
+
+func TestUsedKeys_ExistsMany(t *testing.T) {
+	uk, mock := newMock(t, "")
+	keys := []string{"k1", "k2", "k3"}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id FROM "used_keys" WHERE id = ANY($1) AND exp_at > $2`)).
+		WithArgs(keys, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("k3").AddRow("k1"))
+
+	actual, err := uk.ExistsMany(context.Background(), keys)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false, true}, actual)
+}
+
+func TestUsedKeys_ExistsMany_Err(t *testing.T) {
+	uk, mock := newMock(t, "")
+	mock.ExpectQuery("SELECT id FROM").WillReturnError(sql.ErrConnDone)
+
+	actual, err := uk.ExistsMany(context.Background(), []string{"k1"})
+	assert.Error(t, err)
+	assert.Nil(t, actual)
+}
+
+func TestUsedKeys_Delete(t *testing.T) {
+	uk, mock := newMock(t, "")
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "used_keys" WHERE id = $1`)).WithArgs("k1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM").WithArgs("k2").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	deleted, err := uk.Delete(context.Background(), "k1")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = uk.Delete(context.Background(), "k2")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func TestUsedKeys_Extend(t *testing.T) {
+	uk, mock := newMock(t, "")
+	expAt := time.Now().Add(time.Hour)
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "used_keys" SET exp_at = $2 WHERE id = $1 AND exp_at > $3`)).
+		WithArgs("k1", expAt.UTC(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	extended, err := uk.Extend(context.Background(), "k1", expAt)
+	require.NoError(t, err)
+	assert.True(t, extended)
+}
+
+func TestUsedKeys_Extend_Expired(t *testing.T) {
+	uk, mock := newMock(t, "")
+	mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	extended, err := uk.Extend(context.Background(), "k1", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, extended)
+}
+
+func TestUsedKeys_Count(t *testing.T) {
+	uk, mock := newMock(t, "")
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "used_keys" WHERE exp_at > $1`)).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	count, err := uk.Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), count)
+}
+
+func TestUsedKeys_Sweep(t *testing.T) {
+	uk, mock := newMock(t, "")
+	sweep := regexp.QuoteMeta(`DELETE FROM "used_keys" WHERE id IN (SELECT id FROM "used_keys" WHERE exp_at <= $1 LIMIT $2)`)
+	mock.ExpectExec(sweep).WithArgs(sqlmock.AnyArg(), sweepBatchSize).WillReturnResult(sqlmock.NewResult(0, sweepBatchSize))
+	mock.ExpectExec(sweep).WithArgs(sqlmock.AnyArg(), sweepBatchSize).WillReturnResult(sqlmock.NewResult(0, 5))
+
+	deleted, err := uk.Sweep(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(sweepBatchSize+5), deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsedKeys_Sweep_Err(t *testing.T) {
+	uk, mock := newMock(t, "")
+	mock.ExpectExec("DELETE FROM").WillReturnResult(sqlmock.NewResult(0, sweepBatchSize))
+	mock.ExpectExec("DELETE FROM").WillReturnError(sql.ErrConnDone)
+
+	deleted, err := uk.Sweep(context.Background())
+	assert.ErrorIs(t, err, sql.ErrConnDone)
+	assert.Equal(t, int64(sweepBatchSize), deleted)
+}
+
+func TestUsedKeys_Pool(t *testing.T) {
+	uk, mock := newMock(t, "sms")
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "used_keys_sms"`)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	stored, err := uk.Store(context.Background(), "k1", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, stored)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsedKeys_Scan(t *testing.T) {
+	uk, mock := newMock(t, "")
+	expAt := time.Now().Add(time.Hour)
+	query := regexp.QuoteMeta(`SELECT id, exp_at FROM "used_keys" WHERE id > $1 AND exp_at > $2 ORDER BY id LIMIT $3`)
+	mock.ExpectQuery(query).WithArgs("", sqlmock.AnyArg(), int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "exp_at"}).AddRow("k1", expAt).AddRow("k2", expAt))
+	mock.ExpectQuery(query).WithArgs("k2", sqlmock.AnyArg(), int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "exp_at"}).AddRow("k3", expAt))
+
+	var pages []map[string]time.Time
+	err := uk.Scan(context.Background(), 2, func(page map[string]time.Time) error {
+		pages = append(pages, page)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]time.Time{{"k1": expAt, "k2": expAt}, {"k3": expAt}}, pages)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsedKeys_Scan_Collation(t *testing.T) {
+	uk, mock := newMock(t, "")
+	expAt := time.Now().Add(time.Hour)
+	// a linguistic collation orders "a" before "B", unlike bytes, so the next page starts after the last row
+	mock.ExpectQuery("SELECT id, exp_at FROM").WithArgs("", sqlmock.AnyArg(), int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "exp_at"}).AddRow("a", expAt).AddRow("B", expAt))
+	mock.ExpectQuery("SELECT id, exp_at FROM").WithArgs("B", sqlmock.AnyArg(), int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "exp_at"}))
+
+	err := uk.Scan(context.Background(), 2, func(map[string]time.Time) error { return nil })
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsedKeys_ExpiresAt(t *testing.T) {
+	uk, mock := newMock(t, "")
+	expAt := time.Now().Add(time.Hour)
+	keys := []string{"k1", "k2"}
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, exp_at FROM "used_keys" WHERE id = ANY($1) AND exp_at > $2`)).
+		WithArgs(keys, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "exp_at"}).AddRow("k1", expAt))
+
+	actual, err := uk.ExpiresAt(context.Background(), keys)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Time{"k1": expAt}, actual)
+}
+
+func TestUsedKeys_ExpiresAt_Err(t *testing.T) {
+	uk, mock := newMock(t, "")
+	mock.ExpectQuery("SELECT id, exp_at FROM").WillReturnError(sql.ErrConnDone)
+
+	actual, err := uk.ExpiresAt(context.Background(), []string{"k1"})
+	assert.Error(t, err)
+	assert.Nil(t, actual)
+}
+
+func TestUsedKeys_Annotate(t *testing.T) {
+	uk, mock := newMock(t, "")
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "used_keys" SET md = $2 WHERE id = $1 AND exp_at > $3`)).
+		WithArgs("k1", `{"client_id":"c1"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	annotated, err := uk.Annotate(context.Background(), "k1", map[string]string{"client_id": "c1"})
+	require.NoError(t, err)
+	assert.True(t, annotated)
+}
+
+func TestUsedKeys_Lookup(t *testing.T) {
+	uk, mock := newMock(t, "")
+	expAt := time.Now().Add(time.Hour)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT md, exp_at FROM "used_keys" WHERE id = $1 AND exp_at > $2`)).
+		WithArgs("k1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"md", "exp_at"}).AddRow([]byte(`{"client_id":"c1"}`), expAt))
+	mock.ExpectQuery("SELECT md, exp_at FROM").
+		WillReturnRows(sqlmock.NewRows([]string{"md", "exp_at"}).AddRow(nil, expAt))
+
+	md, actualExpAt, err := uk.Lookup(context.Background(), "k1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"client_id": "c1"}, md)
+	assert.Equal(t, expAt, actualExpAt)
+
+	// the key is stored without metadata
+	md, actualExpAt, err = uk.Lookup(context.Background(), "k2")
+	require.NoError(t, err)
+	assert.Nil(t, md)
+	assert.Equal(t, expAt, actualExpAt)
+}
+
+func TestUsedKeys_Lookup_NotFound(t *testing.T) {
+	uk, mock := newMock(t, "")
+	mock.ExpectQuery("SELECT md, exp_at FROM").WillReturnRows(sqlmock.NewRows([]string{"md", "exp_at"}))
+
+	_, _, err := uk.Lookup(context.Background(), "k1")
+	assert.ErrorIs(t, err, errbrick.ErrNotFound)
+}
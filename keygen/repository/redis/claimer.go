@@ -0,0 +1,97 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/demeero/bricks/errbrick"
+	"github.com/redis/go-redis/v9"
+)
+
+// claimScript pops ARGV[3] random keys from the unused keys shard sets KEYS (in turn until enough keys are popped)
+// and stores them as used keys in the same script, so every key is either fully claimed or still free.
+// It returns only successfully claimed keys.
+// The used keys are stored with the prefix ARGV[4] in the DB passed as ARGV[1]
+// (SELECT inside the script doesn't affect the connection) and the value ARGV[5] that has their issue time.
+var claimScript = redis.NewScript(`
+local n = tonumber(ARGV[3])
+local popped = {}
+for _, set in ipairs(KEYS) do
+	for _, k in ipairs(redis.call('SPOP', set, n - #popped)) do
+		table.insert(popped, k)
+	end
+	if #popped >= n then
+		break
+	end
+end
+if #popped == 0 then
+	return false
+end
+redis.call('SELECT', ARGV[1])
+local claimed = {}
+for _, k in ipairs(popped) do
+	if redis.call('SET', ARGV[4] .. k, ARGV[5], 'PX', ARGV[2], 'NX') then
+		table.insert(claimed, k)
+	end
+end
+return claimed
+`)
+
+// Claimer atomically moves keys from unused keys to used keys.
+// It can be used only when both unused and used keys live in the same Redis instance.
+// It can't be used with Redis Cluster, since the script changes keys of different slots.
+type Claimer struct {
+	rds        redis.Cmdable
+	usedPrefix string
+	setNames   []string
+	usedDB     int
+}
+
+// NewClaimer creates a new Claimer of the pool with a single shard of unused keys.
+// The rds is a client for unused keys and usedDB is a DB number of used keys in the same Redis instance.
+func NewClaimer(rds redis.Cmdable, usedDB int, pool string) *Claimer {
+	return NewShardedClaimer(rds, usedDB, pool, 1)
+}
+
+// NewShardedClaimer creates a new Claimer of the pool with the number of shards of unused keys (see NewShardedUnusedKeys).
+func NewShardedClaimer(rds redis.Cmdable, usedDB int, pool string, shards int) *Claimer {
+	return &Claimer{
+		rds:        rds,
+		usedDB:     usedDB,
+		setNames:   unusedSetNamesOf(rds, pool, shards),
+		usedPrefix: usedKeyPrefixOf(pool),
+	}
+}
+
+// Claim pops a random unused key and stores it as used key with the given ttl.
+// It returns errbrick.ErrNotFound if there are no unused keys and errbrick.ErrConflict if the popped key is already used.
+func (c *Claimer) Claim(ctx context.Context, ttl time.Duration) (string, error) {
+	claimed, err := c.ClaimN(ctx, 1, ttl)
+	if err != nil {
+		return "", err
+	}
+	if len(claimed) == 0 {
+		return "", fmt.Errorf("%w: key already exist", errbrick.ErrConflict)
+	}
+	return claimed[0], nil
+}
+
+// ClaimN pops up to n random unused keys starting with a random shard and stores them as used keys with the given ttl.
+// Popped keys that are already used are skipped, so the result can contain fewer than n keys.
+// It returns errbrick.ErrNotFound if there are no unused keys.
+func (c *Claimer) ClaimN(ctx context.Context, n int64, ttl time.Duration) ([]string, error) {
+	start := rand.Intn(len(c.setNames))
+	sets := append(slices.Clone(c.setNames[start:]), c.setNames[:start]...)
+	claimed, err := claimScript.Run(ctx, c.rds, sets, c.usedDB, ttl.Milliseconds(), n, c.usedPrefix, issuedValue(time.Now())).StringSlice()
+	if errors.Is(err, redis.Nil) {
+		return nil, errbrick.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
@@ -0,0 +1,149 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/demeero/bricks/errbrick"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimer_Claim(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), DB: 1})
+	client.SAdd(context.Background(), unusedSetName, "k1", "k2", "k3")
+
+	c := NewClaimer(client, 0, "")
+	actual, err := c.Claim(context.Background(), time.Hour)
+	require.NoError(t, err)
+	assert.NotEmpty(t, actual)
+
+	assert.Equal(t, int64(2), client.SCard(context.Background(), unusedSetName).Val())
+	assert.False(t, client.SIsMember(context.Background(), unusedSetName, actual).Val())
+	assert.True(t, mr.DB(0).Exists(actual))
+	assert.Equal(t, time.Hour, mr.DB(0).TTL(actual))
+
+	md, _, err := NewUsedKeys(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "").Lookup(context.Background(), actual)
+	require.NoError(t, err)
+	issuedAt, err := time.Parse(time.RFC3339Nano, md[mdIssuedAt])
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), issuedAt, time.Second)
+}
+
+func TestClaimer_Claim_EmptySet(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	c := NewClaimer(client, 0, "")
+	actual, err := c.Claim(context.Background(), time.Hour)
+	assert.Equal(t, errbrick.ErrNotFound, err)
+	assert.Empty(t, actual)
+}
+
+func TestClaimer_Claim_AlreadyUsed(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), DB: 1})
+	client.SAdd(context.Background(), unusedSetName, "k1")
+	require.NoError(t, mr.DB(0).Set("k1", ""))
+	mr.DB(0).SetTTL("k1", time.Minute)
+
+	c := NewClaimer(client, 0, "")
+	actual, err := c.Claim(context.Background(), time.Hour)
+	assert.ErrorIs(t, err, errbrick.ErrConflict)
+	assert.Empty(t, actual)
+
+	assert.Zero(t, client.SCard(context.Background(), unusedSetName).Val())
+	assert.Equal(t, time.Minute, mr.DB(0).TTL("k1"))
+}
+
+func TestClaimer_Claim_RedisError(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), DB: 1})
+	client.SAdd(context.Background(), unusedSetName, "k1", "k2", "k3")
+
+	mr.SetError("ERR injected failure")
+	c := NewClaimer(client, 0, "")
+	actual, err := c.Claim(context.Background(), time.Hour)
+	assert.Error(t, err)
+	assert.Empty(t, actual)
+	mr.SetError("")
+
+	assert.Equal(t, int64(3), client.SCard(context.Background(), unusedSetName).Val())
+	assert.Empty(t, mr.DB(0).Keys())
+}
+
+func TestClaimer_ClaimN(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), DB: 1})
+	client.SAdd(context.Background(), unusedSetName, "k1", "k2", "k3", "k4")
+	require.NoError(t, mr.DB(0).Set("k2", ""))
+
+	c := NewClaimer(client, 0, "")
+	actual, err := c.ClaimN(context.Background(), 10, time.Hour)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"k1", "k3", "k4"}, actual)
+
+	assert.Zero(t, client.SCard(context.Background(), unusedSetName).Val())
+	for _, k := range actual {
+		assert.Equal(t, time.Hour, mr.DB(0).TTL(k))
+	}
+}
+
+func TestClaimer_ClaimN_EmptySet(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	c := NewClaimer(client, 0, "")
+	actual, err := c.ClaimN(context.Background(), 10, time.Hour)
+	assert.Equal(t, errbrick.ErrNotFound, err)
+	assert.Empty(t, actual)
+}
+
+func TestClaimer_ClaimN_Pool(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), DB: 1})
+	client.SAdd(context.Background(), unusedSetName, "k1")
+	client.SAdd(context.Background(), unusedSetName+":sms", "k1", "k2")
+	require.NoError(t, mr.DB(0).Set("k1", ""))
+
+	c := NewClaimer(client, 0, "sms")
+	actual, err := c.ClaimN(context.Background(), 10, time.Hour)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"k1", "k2"}, actual)
+
+	assert.Zero(t, client.SCard(context.Background(), unusedSetName+":sms").Val())
+	assert.Equal(t, int64(1), client.SCard(context.Background(), unusedSetName).Val())
+	assert.Equal(t, time.Hour, mr.DB(0).TTL("sms:k1"))
+	assert.Equal(t, time.Hour, mr.DB(0).TTL("sms:k2"))
+}
+
+func TestClaimer_ClaimN_Sharded(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), DB: 1})
+	client.SAdd(context.Background(), unusedSetName, "k1")
+	client.SAdd(context.Background(), unusedSetName+"#2", "k2", "k3")
+
+	c := NewShardedClaimer(client, 0, "", 4)
+	actual, err := c.ClaimN(context.Background(), 10, time.Hour)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"k1", "k2", "k3"}, actual)
+
+	assert.Zero(t, client.SCard(context.Background(), unusedSetName).Val())
+	assert.Zero(t, client.SCard(context.Background(), unusedSetName+"#2").Val())
+	assert.Equal(t, time.Hour, mr.DB(0).TTL("k2"))
+
+	_, err = c.ClaimN(context.Background(), 10, time.Hour)
+	assert.ErrorIs(t, err, errbrick.ErrNotFound)
+}
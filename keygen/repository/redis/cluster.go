@@ -0,0 +1,41 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// clusterHashTag puts keys of keygen that are changed by a single script (the lease and unused keys)
+// into a single slot of Redis Cluster, since a script can't change keys of different slots.
+const clusterHashTag = "{keygen}"
+
+// slotted returns the name with the cluster hash tag if rds is a Redis Cluster client.
+// Names in a single Redis instance are unchanged to keep keys that were stored before Redis Cluster was supported.
+func slotted(rds redis.Cmdable, name string) string {
+	if _, ok := rds.(*redis.ClusterClient); ok {
+		return clusterHashTag + name
+	}
+	return name
+}
+
+// shardSlotted returns the name of the shard with its own cluster hash tag if rds is a Redis Cluster client,
+// so shards are spread across slots. The first shard stays in the slot of the lease.
+func shardSlotted(rds redis.Cmdable, name string, shard int) string {
+	if _, ok := rds.(*redis.ClusterClient); ok && shard > 0 {
+		return "{keygen#" + strconv.Itoa(shard) + "}" + name
+	}
+	return slotted(rds, name)
+}
+
+// forEachNode calls fn with every master node of Redis Cluster concurrently or with rds itself if it's a single instance.
+// Commands that aren't routed by keys (e.g. SCAN) see only a single node of Redis Cluster.
+func forEachNode(ctx context.Context, rds redis.Cmdable, fn func(context.Context, redis.Cmdable) error) error {
+	if c, ok := rds.(*redis.ClusterClient); ok {
+		return c.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return fn(ctx, node)
+		})
+	}
+	return fn(ctx, rds)
+}
@@ -0,0 +1,203 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/demeero/bricks/errbrick"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFencedUnusedKeys_Store_Cluster(t *testing.T) {
+	client, nodes := newCluster(t, 3)
+	ctx := context.Background()
+
+	lease := NewLease(client, "lease_keygen_generator", time.Second)
+	acquired, err := lease.Acquire(ctx)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	for _, pool := range []string{"", "sms"} {
+		unused := NewUnusedKeys(client, pool)
+		stored, err := NewFencedUnusedKeys(unused, lease).Store(ctx, "k1", "k2")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), stored)
+
+		size, err := unused.Size(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), size)
+	}
+
+	// the script changes the lease and the sets, so they must be on the same node
+	var leaseNode *miniredis.Miniredis
+	for _, n := range nodes {
+		if n.Exists("{keygen}lease_keygen_generator") {
+			leaseNode = n
+		}
+	}
+	require.NotNil(t, leaseNode)
+	assert.True(t, leaseNode.Exists("{keygen}lease_keygen_generator:fencing"))
+	assert.True(t, leaseNode.Exists("{keygen}set_unusedkeys"))
+	assert.True(t, leaseNode.Exists("{keygen}set_unusedkeys:sms"))
+}
+
+func TestUsedKeys_Cluster(t *testing.T) {
+	client, nodes := newCluster(t, 3)
+	ctx := context.Background()
+
+	used := NewUsedKeys(client, "")
+	for i := 0; i < 100; i++ {
+		stored, err := used.Store(ctx, "k"+strconv.Itoa(i), time.Hour)
+		require.NoError(t, err)
+		require.True(t, stored)
+	}
+	// other keys of keygen live in the same DB of Redis Cluster
+	_, err := NewUnusedKeys(client, "").Store(ctx, "k1000")
+	require.NoError(t, err)
+	_, err = NewUsedKeys(client, "sms").Store(ctx, "k1", time.Hour)
+	require.NoError(t, err)
+
+	for _, n := range nodes {
+		assert.NotEmpty(t, n.Keys(), "used keys are spread over all nodes")
+	}
+
+	count, err := used.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), count)
+
+	scanned := map[string]time.Time{}
+	err = used.Scan(ctx, 10, func(page map[string]time.Time) error {
+		for k, expAt := range page {
+			scanned[k] = expAt
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, scanned, 100)
+
+	exist, err := used.ExistsMany(ctx, []string{"k1", "k50", "k1000"})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true, false}, exist)
+}
+
+func TestFencedUnusedKeys_Store_ShardedCluster(t *testing.T) {
+	client, nodes := newCluster(t, 3)
+	ctx := context.Background()
+
+	l1 := NewLease(client, "lease", time.Second)
+	l2 := NewLease(client, "lease", time.Second)
+	fenced1 := NewFencedUnusedKeys(NewShardedUnusedKeys(client, "", 8), l1)
+	fenced2 := NewFencedUnusedKeys(NewShardedUnusedKeys(client, "", 8), l2)
+
+	keys := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		keys = append(keys, "k"+strconv.Itoa(i))
+	}
+	_, err := fenced1.Store(ctx, keys...)
+	assert.ErrorIs(t, err, errbrick.ErrConflict)
+
+	_, err = l1.Acquire(ctx)
+	require.NoError(t, err)
+	stored, err := fenced1.Store(ctx, keys...)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), stored)
+
+	for _, n := range nodes {
+		assert.NotEmpty(t, n.Keys(), "shards are spread over all nodes")
+	}
+
+	// l1 is paused (e.g. by GC) for longer than the lease TTL - l2 becomes the leader
+	for _, n := range nodes {
+		n.FastForward(2 * time.Second)
+	}
+	_, err = l2.Acquire(ctx)
+	require.NoError(t, err)
+
+	_, err = fenced1.Store(ctx, "k100")
+	assert.ErrorIs(t, err, errbrick.ErrConflict)
+	stored, err = fenced2.Store(ctx, "k101", "k102")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stored)
+
+	// l1 has checked the lease just before l2 took it - shards that l2 has stored to reject keys of l1
+	for _, k := range []string{"k101", "k102"} {
+		stored, err = fenced1.storeSpread(ctx, l1.Token(), []string{k})
+		assert.ErrorIs(t, err, errbrick.ErrConflict)
+		assert.Zero(t, stored)
+	}
+
+	size, err := fenced2.Size(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(102), size)
+}
+
+// BenchmarkUnusedKeys_LoadAndDelete issues keys of a pool from Redis Cluster by concurrent clients.
+// A single shard is served by a single node, while shards are spread over all nodes.
+func BenchmarkUnusedKeys_LoadAndDelete(b *testing.B) {
+	const keysCount = 10000
+	keys := make([]string, 0, keysCount)
+	for i := 0; i < keysCount; i++ {
+		keys = append(keys, "k"+strconv.Itoa(i))
+	}
+	for _, shards := range []int{1, 4, 16} {
+		b.Run("shards "+strconv.Itoa(shards), func(b *testing.B) {
+			client, _ := newCluster(b, 4)
+			ctx := context.Background()
+			uk := NewShardedUnusedKeys(client, "", shards)
+			_, err := uk.Store(ctx, keys...)
+			require.NoError(b, err)
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					// the issued key is returned, so the pool keeps its size
+					k, err := uk.LoadAndDelete(ctx)
+					if err != nil {
+						b.Error(err)
+						return
+					}
+					if _, err := uk.Store(ctx, k); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+// newCluster runs n miniredis nodes that serve equal ranges of Redis Cluster slots.
+func newCluster(t testing.TB, n int) (*redis.ClusterClient, []*miniredis.Miniredis) {
+	t.Helper()
+	const slots = 16384
+	nodes := make([]*miniredis.Miniredis, 0, n)
+	ranges := make([]redis.ClusterSlot, 0, n)
+	for i := 0; i < n; i++ {
+		mr := miniredis.RunT(t)
+		nodes = append(nodes, mr)
+		ranges = append(ranges, redis.ClusterSlot{
+			Start: i * slots / n,
+			End:   (i+1)*slots/n - 1,
+			Nodes: []redis.ClusterNode{{Addr: mr.Addr()}},
+		})
+	}
+	client := redis.NewClusterClient(&redis.ClusterOptions{
+		ClusterSlots: func(context.Context) ([]redis.ClusterSlot, error) {
+			return ranges, nil
+		},
+	})
+	t.Cleanup(func() {
+		client.Close()
+	})
+	// the client knows nodes only after it has loaded slots
+	err := client.ForEachMaster(context.Background(), func(ctx context.Context, node *redis.Client) error {
+		return node.Ping(ctx).Err()
+	})
+	require.NoError(t, err)
+	return client, nodes
+}
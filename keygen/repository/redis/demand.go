@@ -0,0 +1,64 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const demandChannelName = "chan_keygen_demand"
+
+// DemandRelay passes reports of used keys between replicas over Redis Pub/Sub.
+// Messages aren't stored, so reports published while nobody leads are lost.
+type DemandRelay struct {
+	rds     redis.UniversalClient
+	channel string
+}
+
+// NewDemandRelay creates a new DemandRelay of the pool.
+// Every pool has a separate channel.
+func NewDemandRelay(rds redis.UniversalClient, pool string) *DemandRelay {
+	channel := demandChannelName
+	if pool != "" {
+		channel += ":" + pool
+	}
+	return &DemandRelay{rds: rds, channel: channel}
+}
+
+// Publish publishes the report as "<used> <exhausted>".
+func (r *DemandRelay) Publish(ctx context.Context, used int64, exhausted bool) error {
+	return r.rds.Publish(ctx, r.channel, fmt.Sprintf("%d %t", used, exhausted)).Err()
+}
+
+// Subscribe calls fn with every published report until ctx is done.
+// The subscription is restored by the client if the connection is lost.
+func (r *DemandRelay) Subscribe(ctx context.Context, fn func(used int64, exhausted bool)) error {
+	sub := r.rds.Subscribe(ctx, r.channel)
+	defer sub.Close()
+	// the subscription is confirmed before reports are received, so the first error is returned to the caller
+	if _, err := sub.Receive(ctx); err != nil {
+		return err
+	}
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			var (
+				used      int64
+				exhausted bool
+			)
+			if _, err := fmt.Sscanf(msg.Payload, "%d %t", &used, &exhausted); err != nil {
+				slog.Error("failed parse demand report", slog.String("payload", msg.Payload), slog.Any("err", err))
+				continue
+			}
+			fn(used, exhausted)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDemandRelay(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rds := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type report struct {
+		used      int64
+		exhausted bool
+	}
+	reports := make(chan report, 1)
+	relay := NewDemandRelay(rds, "sms")
+	done := make(chan error)
+	go func() {
+		done <- relay.Subscribe(ctx, func(used int64, exhausted bool) {
+			reports <- report{used: used, exhausted: exhausted}
+		})
+	}()
+	require.Eventually(t, func() bool { return len(mr.PubSubChannels("")) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"chan_keygen_demand:sms"}, mr.PubSubChannels(""))
+
+	// another pool has its own channel
+	require.NoError(t, NewDemandRelay(rds, "").Publish(ctx, 1, false))
+	require.NoError(t, relay.Publish(ctx, 42, true))
+	assert.Equal(t, report{used: 42, exhausted: true}, <-reports)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const keyLenName = "keylen_keygen"
+
+// storeKeyLenScript sets the key length KEYS[1] to ARGV[1] unless the stored one is longer.
+var storeKeyLenScript = redis.NewScript(`
+if tonumber(redis.call('GET', KEYS[1]) or '0') < tonumber(ARGV[1]) then
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return 0
+`)
+
+// KeyLen is a key length of the pool grown by the generator (see key.Saturation).
+// It's stored next to the generator lease, so the elected leader continues with the length of the previous one.
+type KeyLen struct {
+	rds  redis.Cmdable
+	name string
+}
+
+// NewKeyLen creates a new KeyLen of the pool.
+// Every pool has a separate key length.
+func NewKeyLen(rds redis.Cmdable, pool string) *KeyLen {
+	name := keyLenName
+	if pool != "" {
+		name += ":" + pool
+	}
+	return &KeyLen{rds: rds, name: name}
+}
+
+// Load returns zero if the key length isn't stored.
+func (l *KeyLen) Load(ctx context.Context) (uint8, error) {
+	keyLen, err := l.rds.Get(ctx, l.name).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return uint8(min(keyLen, 255)), nil
+}
+
+// Store sets the key length in a single script unless a longer one is stored,
+// so a replica that has lost the leadership can't shrink it.
+func (l *KeyLen) Store(ctx context.Context, keyLen uint8) error {
+	return storeKeyLenScript.Run(ctx, l.rds, []string{l.name}, keyLen).Err()
+}
@@ -0,0 +1,43 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redismock/v9"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyLen(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+	keyLen := NewKeyLen(client, "")
+
+	actual, err := keyLen.Load(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, actual)
+
+	require.NoError(t, keyLen.Store(ctx, 7))
+	// a replica that has lost the leadership can't shrink the length
+	require.NoError(t, keyLen.Store(ctx, 6))
+	actual, err = keyLen.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(7), actual)
+
+	// every pool has its own length
+	actual, err = NewKeyLen(client, "sms").Load(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, actual)
+}
+
+func TestKeyLen_Load_RedisErr(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	mock.ExpectGet(keyLenName).SetErr(redis.ErrClosed)
+
+	_, err := NewKeyLen(db, "").Load(context.Background())
+	assert.ErrorIs(t, err, redis.ErrClosed)
+}
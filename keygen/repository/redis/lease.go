@@ -0,0 +1,232 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/demeero/bricks/errbrick"
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireScript takes the lease KEYS[1] for ARGV[1] milliseconds if it's free.
+// Every acquisition gets a new fencing token from the counter KEYS[2] - the token is the value of the lease.
+var acquireScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return false
+end
+local token = redis.call('INCR', KEYS[2])
+redis.call('SET', KEYS[1], token, 'PX', ARGV[1])
+return token
+`)
+
+// renewScript prolongs the lease KEYS[1] for ARGV[2] milliseconds if it's still held with the fencing token ARGV[1].
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lease KEYS[1] if it's still held with the fencing token ARGV[1].
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// fencedStoreScript adds keys to the shard sets KEYS[2..] only if the lease KEYS[1] is held with the fencing token ARGV[1].
+// ARGV[2..] are groups of keys of every shard: the number of keys followed by the keys.
+var fencedStoreScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) ~= ARGV[1] then
+	return false
+end
+local stored = 0
+local i = 2
+for s = 2, #KEYS do
+	local n = tonumber(ARGV[i])
+	if n > 0 then
+		stored = stored + redis.call('SADD', KEYS[s], unpack(ARGV, i + 1, i + n))
+	end
+	i = i + n + 1
+end
+return stored
+`)
+
+// GeneratorLeaseName is a name of the lease of the replica that generates keys.
+const GeneratorLeaseName = "lease_keygen_generator"
+
+// Lease is a lock that is held by a single replica until it expires or is released.
+// Every acquisition gets a fencing token that is greater than tokens of previous holders,
+// so writes of a replica that has lost the lease can be rejected (see FencedUnusedKeys).
+type Lease struct {
+	rds         redis.Cmdable
+	name        string
+	fencingName string
+	ttl         time.Duration
+	token       atomic.Int64
+}
+
+// NewLease creates a new Lease with the name. The lease expires if it's not renewed within ttl.
+func NewLease(rds redis.Cmdable, name string, ttl time.Duration) *Lease {
+	name = slotted(rds, name)
+	return &Lease{
+		rds:         rds,
+		name:        name,
+		fencingName: name + ":fencing",
+		ttl:         ttl,
+	}
+}
+
+// Acquire takes the lease if it's free. It returns false if the lease is held by another replica.
+func (l *Lease) Acquire(ctx context.Context) (bool, error) {
+	token, err := acquireScript.Run(ctx, l.rds, []string{l.name, l.fencingName}, l.ttl.Milliseconds()).Int64()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	l.token.Store(token)
+	return true, nil
+}
+
+// Renew prolongs the held lease. It returns false if the lease has expired or is held by another replica.
+func (l *Lease) Renew(ctx context.Context) (bool, error) {
+	token := l.token.Load()
+	if token == 0 {
+		return false, nil
+	}
+	renewed, err := renewScript.Run(ctx, l.rds, []string{l.name}, token, l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, err
+	}
+	if renewed == 0 {
+		l.token.CompareAndSwap(token, 0)
+		return false, nil
+	}
+	return true, nil
+}
+
+// Release frees the held lease, so another replica can take it without waiting for expiration.
+func (l *Lease) Release(ctx context.Context) error {
+	token := l.token.Swap(0)
+	if token == 0 {
+		return nil
+	}
+	return releaseScript.Run(ctx, l.rds, []string{l.name}, token).Err()
+}
+
+// Token returns the fencing token of the held lease or zero if the lease isn't held.
+func (l *Lease) Token() int64 {
+	return l.token.Load()
+}
+
+// shardFencedStoreScript adds ARGV[2..] to the shard set KEYS[2] unless the fencing token ARGV[1] is older than
+// the last token KEYS[1] that has stored keys to the shard.
+var shardFencedStoreScript = redis.NewScript(`
+local last = tonumber(redis.call('GET', KEYS[1]) or '0')
+local token = tonumber(ARGV[1])
+if token < last then
+	return false
+end
+if token > last then
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return redis.call('SADD', KEYS[2], unpack(ARGV, 2))
+`)
+
+// FencedUnusedKeys stores unused keys only while the lease is held with the current fencing token.
+// The lease and unused keys must live in the same Redis instance or Redis Cluster.
+type FencedUnusedKeys struct {
+	*UnusedKeys
+	lease *Lease
+	// fencingNames are names of the last fencing tokens of shards that are spread across slots of Redis Cluster.
+	fencingNames []string
+}
+
+// NewFencedUnusedKeys creates a new FencedUnusedKeys.
+func NewFencedUnusedKeys(u *UnusedKeys, lease *Lease) *FencedUnusedKeys {
+	f := &FencedUnusedKeys{
+		UnusedKeys: u,
+		lease:      lease,
+	}
+	if _, ok := u.rds.(*redis.ClusterClient); ok && len(u.setNames) > 1 {
+		f.fencingNames = make([]string, 0, len(u.setNames))
+		for _, name := range u.setNames {
+			f.fencingNames = append(f.fencingNames, name+":fencing")
+		}
+	}
+	return f
+}
+
+// Store adds keys to their shard sets of unused keys in a single script.
+// It returns errbrick.ErrConflict if the lease isn't held anymore (e.g. it has expired and another replica took it).
+func (f *FencedUnusedKeys) Store(ctx context.Context, k ...string) (int64, error) {
+	token := f.lease.Token()
+	if token == 0 {
+		return 0, fmt.Errorf("%w: lease %s is not held", errbrick.ErrConflict, f.lease.name)
+	}
+	if f.fencingNames != nil {
+		return f.storeSpread(ctx, token, k)
+	}
+	names := make([]string, 0, len(f.setNames)+1)
+	names = append(names, f.lease.name)
+	args := make([]interface{}, 0, len(k)+len(f.setNames)+1)
+	args = append(args, strconv.FormatInt(token, 10))
+	for i, keys := range shardKeys(k, len(f.setNames)) {
+		if len(keys) == 0 {
+			continue
+		}
+		names = append(names, f.setNames[i])
+		args = append(args, len(keys))
+		for _, key := range keys {
+			args = append(args, key)
+		}
+	}
+	stored, err := fencedStoreScript.Run(ctx, f.rds, names, args...).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, fmt.Errorf("%w: lease %s is held by another replica", errbrick.ErrConflict, f.lease.name)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return stored, nil
+}
+
+// storeSpread stores keys to shards in different slots of Redis Cluster, so the lease can't be checked by the same script.
+// The lease is checked before keys are stored and every shard rejects tokens older than the last one it has seen,
+// so a replica that has lost the lease can't store keys to a shard after the new leader has stored to it.
+func (f *FencedUnusedKeys) storeSpread(ctx context.Context, token int64, k []string) (int64, error) {
+	held, err := f.rds.Get(ctx, f.lease.name).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, err
+	}
+	if held != strconv.FormatInt(token, 10) {
+		return 0, fmt.Errorf("%w: lease %s is held by another replica", errbrick.ErrConflict, f.lease.name)
+	}
+	var stored int64
+	for i, keys := range shardKeys(k, len(f.setNames)) {
+		if len(keys) == 0 {
+			continue
+		}
+		args := make([]interface{}, 0, len(keys)+1)
+		args = append(args, token)
+		for _, key := range keys {
+			args = append(args, key)
+		}
+		n, err := shardFencedStoreScript.Run(ctx, f.rds, []string{f.fencingNames[i], f.setNames[i]}, args...).Int64()
+		if errors.Is(err, redis.Nil) {
+			return stored, fmt.Errorf("%w: shard %s is fenced by another replica", errbrick.ErrConflict, f.setNames[i])
+		}
+		if err != nil {
+			return stored, err
+		}
+		stored += n
+	}
+	return stored, nil
+}
@@ -0,0 +1,162 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/demeero/bricks/errbrick"
+	"github.com/go-redis/redismock/v9"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLease_Acquire(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	l1 := NewLease(client, "lease", time.Second)
+	acquired, err := l1.Acquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, int64(1), l1.Token())
+	assert.Equal(t, time.Second, mr.TTL("lease"))
+
+	l2 := NewLease(client, "lease", time.Second)
+	acquired, err = l2.Acquire(ctx)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	assert.Zero(t, l2.Token())
+}
+
+func TestLease_Acquire_RedisErr(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	mock.Regexp().ExpectEvalSha(".*", []string{"lease", "lease:fencing"}, int64(1000)).SetErr(redis.ErrClosed)
+
+	l := NewLease(db, "lease", time.Second)
+	acquired, err := l.Acquire(context.Background())
+	assert.Error(t, err)
+	assert.False(t, acquired)
+}
+
+func TestLease_Renew(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	l := NewLease(client, "lease", time.Second)
+	_, err = l.Acquire(ctx)
+	require.NoError(t, err)
+	mr.FastForward(900 * time.Millisecond)
+
+	renewed, err := l.Renew(ctx)
+	require.NoError(t, err)
+	assert.True(t, renewed)
+	assert.Equal(t, time.Second, mr.TTL("lease"))
+}
+
+func TestLease_Expiry_Handover(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	l1 := NewLease(client, "lease", time.Second)
+	l2 := NewLease(client, "lease", time.Second)
+	acquired, err := l1.Acquire(ctx)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	// l1 hasn't renewed the lease in time - it expires and l2 takes it
+	mr.FastForward(2 * time.Second)
+	acquired, err = l2.Acquire(ctx)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	assert.Greater(t, l2.Token(), int64(1))
+
+	renewed, err := l1.Renew(ctx)
+	require.NoError(t, err)
+	assert.False(t, renewed)
+	assert.Zero(t, l1.Token())
+
+	// the lease is released by the new holder - l1 can take it again
+	require.NoError(t, l1.Release(ctx))
+	assert.True(t, mr.Exists("lease"))
+	require.NoError(t, l2.Release(ctx))
+	assert.False(t, mr.Exists("lease"))
+	acquired, err = l1.Acquire(ctx)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, int64(3), l1.Token())
+}
+
+func TestFencedUnusedKeys_Store(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	l1 := NewLease(client, "lease", time.Second)
+	l2 := NewLease(client, "lease", time.Second)
+	fenced1 := NewFencedUnusedKeys(NewUnusedKeys(client, ""), l1)
+	fenced2 := NewFencedUnusedKeys(NewUnusedKeys(client, ""), l2)
+
+	_, err = fenced1.Store(ctx, "k1")
+	assert.ErrorIs(t, err, errbrick.ErrConflict)
+
+	_, err = l1.Acquire(ctx)
+	require.NoError(t, err)
+	stored, err := fenced1.Store(ctx, "k1", "k2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stored)
+
+	// l1 is paused (e.g. by GC) for longer than the lease TTL - l2 becomes the leader
+	mr.FastForward(2 * time.Second)
+	_, err = l2.Acquire(ctx)
+	require.NoError(t, err)
+
+	stored, err = fenced1.Store(ctx, "k3")
+	assert.ErrorIs(t, err, errbrick.ErrConflict)
+	assert.Zero(t, stored)
+	stored, err = fenced2.Store(ctx, "k4")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stored)
+
+	assert.ElementsMatch(t, []string{"k1", "k2", "k4"}, client.SMembers(ctx, unusedSetName).Val())
+}
+
+func TestFencedUnusedKeys_Store_Sharded(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	l1 := NewLease(client, "lease", time.Second)
+	l2 := NewLease(client, "lease", time.Second)
+	fenced1 := NewFencedUnusedKeys(NewShardedUnusedKeys(client, "", 4), l1)
+	fenced2 := NewFencedUnusedKeys(NewShardedUnusedKeys(client, "", 4), l2)
+
+	_, err := l1.Acquire(ctx)
+	require.NoError(t, err)
+	stored, err := fenced1.Store(ctx, "k1", "k2", "k3", "k4", "k5")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), stored)
+
+	mr.FastForward(2 * time.Second)
+	_, err = l2.Acquire(ctx)
+	require.NoError(t, err)
+
+	_, err = fenced1.Store(ctx, "k6", "k7")
+	assert.ErrorIs(t, err, errbrick.ErrConflict)
+	stored, err = fenced2.Store(ctx, "k5", "k8")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stored)
+
+	size, err := fenced2.Size(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), size)
+}
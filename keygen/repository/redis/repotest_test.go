@@ -0,0 +1,32 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/demeero/pocket-link/keygen/key"
+	"github.com/demeero/pocket-link/keygen/repository/repotest"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestUsedKeys_Suite(t *testing.T) {
+	repotest.UsedKeys(t, func(t *testing.T) (key.UsedKeysRepository, func(time.Duration)) {
+		mr := miniredis.RunT(t)
+		return NewUsedKeys(redis.NewClient(&redis.Options{Addr: mr.Addr()}), ""), mr.FastForward
+	})
+}
+
+func TestUnusedKeys_Suite(t *testing.T) {
+	repotest.UnusedKeys(t, func(t *testing.T) key.UnusedKeysRepository {
+		mr := miniredis.RunT(t)
+		return NewUnusedKeys(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "")
+	})
+}
+
+func TestUnusedKeys_Suite_Sharded(t *testing.T) {
+	repotest.UnusedKeys(t, func(t *testing.T) key.UnusedKeysRepository {
+		mr := miniredis.RunT(t)
+		return NewShardedUnusedKeys(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "", 4)
+	})
+}
@@ -0,0 +1,34 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const sequenceName = "seq_keygen"
+
+// Sequence is a sequence of key values stored in a Redis counter.
+type Sequence struct {
+	rds  redis.Cmdable
+	name string
+}
+
+// NewSequence creates a new Sequence of the pool.
+// Every pool has a separate counter.
+func NewSequence(rds redis.Cmdable, pool string) *Sequence {
+	name := sequenceName
+	if pool != "" {
+		name += ":" + pool
+	}
+	return &Sequence{rds: rds, name: name}
+}
+
+// Next reserves n next values atomically and returns the first of them.
+func (s *Sequence) Next(ctx context.Context, n int64) (int64, error) {
+	last, err := s.rds.IncrBy(ctx, s.name, n).Result()
+	if err != nil {
+		return 0, err
+	}
+	return last - n, nil
+}
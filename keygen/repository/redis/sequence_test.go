@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redismock/v9"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequence_Next(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	seq := NewSequence(client, "")
+
+	first, err := seq.Next(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Zero(t, first)
+
+	first, err = seq.Next(context.Background(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), first)
+
+	// every pool has its own sequence
+	first, err = NewSequence(client, "sms").Next(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Zero(t, first)
+}
+
+func TestSequence_Next_Concurrent(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	seq := NewSequence(client, "")
+
+	const workers, n = 10, 100
+	firsts := make(chan int64, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			first, err := seq.Next(context.Background(), n)
+			assert.NoError(t, err)
+			firsts <- first
+		}()
+	}
+	wg.Wait()
+	close(firsts)
+
+	// ranges of values don't overlap
+	seen := make(map[int64]struct{}, workers)
+	for first := range firsts {
+		assert.Zero(t, first%n)
+		seen[first] = struct{}{}
+	}
+	assert.Len(t, seen, workers)
+}
+
+func TestSequence_Next_Err(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	mock.ExpectIncrBy(sequenceName, 1).SetErr(errors.New("test err"))
+
+	_, err := NewSequence(client, "").Next(context.Background(), 1)
+	assert.Error(t, err)
+}
@@ -3,6 +3,9 @@ package redis
 import (
 	"context"
 	"errors"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
 
 	"github.com/demeero/bricks/errbrick"
 	"github.com/redis/go-redis/v9"
@@ -10,31 +13,168 @@ import (
 
 const unusedSetName = "set_unusedkeys"
 
+// UnusedKeys keeps unused keys in shard sets, so issuing keys doesn't hit a single hot set.
 type UnusedKeys struct {
-	rds redis.Cmdable
+	rds      redis.Cmdable
+	setNames []string
 }
 
-func NewUnusedKeys(rds redis.Cmdable) *UnusedKeys {
+// NewUnusedKeys creates a new UnusedKeys of the pool with a single shard.
+// Every pool keeps its keys in separate sets. In Redis Cluster the first shards of all pools are in the slot of the lease,
+// so keys can be stored only while the lease is held (see FencedUnusedKeys).
+func NewUnusedKeys(rds redis.Cmdable, pool string) *UnusedKeys {
+	return NewShardedUnusedKeys(rds, pool, 1)
+}
+
+// NewShardedUnusedKeys creates a new UnusedKeys of the pool that keeps keys in the number of shard sets.
+// Shards are spread across slots of Redis Cluster. A single Redis instance serves all shards.
+// The number of shards can be increased, but not decreased: keys of removed shards aren't issued.
+func NewShardedUnusedKeys(rds redis.Cmdable, pool string, shards int) *UnusedKeys {
 	return &UnusedKeys{
-		rds: rds,
+		rds:      rds,
+		setNames: unusedSetNamesOf(rds, pool, shards),
+	}
+}
+
+// unusedSetNameOf returns a name of the set with unused keys of the pool.
+// Empty pool keeps the set name that was used before pools were introduced.
+func unusedSetNameOf(pool string) string {
+	if pool == "" {
+		return unusedSetName
+	}
+	return unusedSetName + ":" + pool
+}
+
+// unusedSetNamesOf returns names of shard sets of the pool. The first shard keeps the set name that was used
+// before shards were introduced. Other shards are numbered before the pool, since pool names may contain any symbols.
+func unusedSetNamesOf(rds redis.Cmdable, pool string, shards int) []string {
+	names := make([]string, 0, max(shards, 1))
+	names = append(names, slotted(rds, unusedSetNameOf(pool)))
+	for i := 1; i < shards; i++ {
+		name := unusedSetName + "#" + strconv.Itoa(i)
+		if pool != "" {
+			name += ":" + pool
+		}
+		names = append(names, shardSlotted(rds, name, i))
 	}
+	return names
 }
 
+// shardOf returns an index of the shard set of the key, so keys are spread evenly across shards.
+func shardOf(k string, shards int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(k))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// shardKeys groups keys by their shard sets. Shards without keys are nil.
+func shardKeys(keys []string, shards int) [][]string {
+	result := make([][]string, shards)
+	for _, k := range keys {
+		i := shardOf(k, shards)
+		result[i] = append(result[i], k)
+	}
+	return result
+}
+
+// LoadAndDelete pops a random key of a random shard. Other shards are checked in turn if the shard is empty.
 func (u *UnusedKeys) LoadAndDelete(ctx context.Context) (string, error) {
-	result, err := u.rds.SPop(ctx, unusedSetName).Result()
-	if errors.Is(err, redis.Nil) {
-		return "", errbrick.ErrNotFound
+	start := rand.Intn(len(u.setNames))
+	for i := range u.setNames {
+		result, err := u.rds.SPop(ctx, u.setNames[(start+i)%len(u.setNames)]).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return result, nil
 	}
-	if err != nil {
-		return "", err
+	return "", errbrick.ErrNotFound
+}
+
+// LoadAndDeleteN pops up to n random keys starting with a random shard and taking the rest from other shards in turn.
+func (u *UnusedKeys) LoadAndDeleteN(ctx context.Context, n int64) ([]string, error) {
+	start := rand.Intn(len(u.setNames))
+	var result []string
+	for i := 0; i < len(u.setNames) && int64(len(result)) < n; i++ {
+		popped, err := u.rds.SPopN(ctx, u.setNames[(start+i)%len(u.setNames)], n-int64(len(result))).Result()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, popped...)
+	}
+	if len(result) == 0 {
+		return nil, errbrick.ErrNotFound
 	}
 	return result, nil
 }
 
+// Store adds keys to their shard sets with a single pipeline.
 func (u *UnusedKeys) Store(ctx context.Context, k ...string) (int64, error) {
-	return u.rds.SAdd(ctx, unusedSetName, k).Result()
+	if len(u.setNames) == 1 {
+		return u.rds.SAdd(ctx, u.setNames[0], k).Result()
+	}
+	var cmds []*redis.IntCmd
+	_, err := u.rds.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, keys := range shardKeys(k, len(u.setNames)) {
+			if len(keys) > 0 {
+				cmds = append(cmds, pipe.SAdd(ctx, u.setNames[i], keys))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return sum(cmds), nil
 }
 
+// Delete removes the key from all shards with a single pipeline,
+// since the key may have been stored with another number of shards.
+func (u *UnusedKeys) Delete(ctx context.Context, k string) (bool, error) {
+	if len(u.setNames) == 1 {
+		result, err := u.rds.SRem(ctx, u.setNames[0], k).Result()
+		if err != nil {
+			return false, err
+		}
+		return result == 1, nil
+	}
+	cmds := make([]*redis.IntCmd, 0, len(u.setNames))
+	_, err := u.rds.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, name := range u.setNames {
+			cmds = append(cmds, pipe.SRem(ctx, name, k))
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return sum(cmds) > 0, nil
+}
+
+// Size returns the total number of keys in all shards with a single pipeline.
 func (u *UnusedKeys) Size(ctx context.Context) (int64, error) {
-	return u.rds.SCard(ctx, unusedSetName).Result()
+	if len(u.setNames) == 1 {
+		return u.rds.SCard(ctx, u.setNames[0]).Result()
+	}
+	cmds := make([]*redis.IntCmd, 0, len(u.setNames))
+	_, err := u.rds.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, name := range u.setNames {
+			cmds = append(cmds, pipe.SCard(ctx, name))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return sum(cmds), nil
+}
+
+func sum(cmds []*redis.IntCmd) int64 {
+	var result int64
+	for _, cmd := range cmds {
+		result += cmd.Val()
+	}
+	return result
 }
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"strconv"
 	"testing"
 
 	"github.com/alicebob/miniredis/v2"
@@ -18,7 +19,7 @@ func TestUnusedKeys_LoadAndDelete(t *testing.T) {
 	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
 	client.SAdd(context.Background(), unusedSetName, "k1", "k2", "k3")
 
-	uk := NewUnusedKeys(client)
+	uk := NewUnusedKeys(client, "")
 	actual, err := uk.LoadAndDelete(context.Background())
 	assert.NoError(t, err)
 	assert.NotEmpty(t, actual)
@@ -31,7 +32,7 @@ func TestUnusedKeys_LoadAndDelete_EmptySet(t *testing.T) {
 	require.NoError(t, err)
 	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
 
-	uk := NewUnusedKeys(client)
+	uk := NewUnusedKeys(client, "")
 	actual, err := uk.LoadAndDelete(context.Background())
 	assert.Equal(t, errbrick.ErrNotFound, err)
 	assert.Empty(t, actual)
@@ -41,19 +42,85 @@ func TestUnusedKeys_LoadAndDelete_RedisError(t *testing.T) {
 	db, mock := redismock.NewClientMock()
 	mock.ExpectSPop(unusedSetName).SetErr(redis.ErrClosed)
 
-	uk := NewUnusedKeys(db)
+	uk := NewUnusedKeys(db, "")
 	actual, err := uk.LoadAndDelete(context.Background())
 	assert.Empty(t, actual)
 	assert.Error(t, err)
 }
 
+func TestUnusedKeys_LoadAndDeleteN(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	client.SAdd(context.Background(), unusedSetName, "k1", "k2", "k3")
+
+	uk := NewUnusedKeys(client, "")
+	actual, err := uk.LoadAndDeleteN(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Len(t, actual, 2)
+
+	assert.Equal(t, int64(1), client.SCard(context.Background(), unusedSetName).Val())
+}
+
+func TestUnusedKeys_LoadAndDeleteN_NotEnough(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	client.SAdd(context.Background(), unusedSetName, "k1", "k2")
+
+	uk := NewUnusedKeys(client, "")
+	actual, err := uk.LoadAndDeleteN(context.Background(), 5)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"k1", "k2"}, actual)
+
+	assert.Zero(t, client.SCard(context.Background(), unusedSetName).Val())
+}
+
+func TestUnusedKeys_LoadAndDeleteN_EmptySet(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	uk := NewUnusedKeys(client, "")
+	actual, err := uk.LoadAndDeleteN(context.Background(), 5)
+	assert.Equal(t, errbrick.ErrNotFound, err)
+	assert.Empty(t, actual)
+}
+
+func TestUnusedKeys_Delete(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	client.SAdd(context.Background(), unusedSetName, "k1", "k2", "k3")
+
+	uk := NewUnusedKeys(client, "")
+	actual, err := uk.Delete(context.Background(), "k2")
+	assert.NoError(t, err)
+	assert.True(t, actual)
+
+	assert.False(t, client.SIsMember(context.Background(), unusedSetName, "k2").Val())
+	assert.Equal(t, int64(2), client.SCard(context.Background(), unusedSetName).Val())
+}
+
+func TestUnusedKeys_Delete_NotExists(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	client.SAdd(context.Background(), unusedSetName, "k1")
+
+	uk := NewUnusedKeys(client, "")
+	actual, err := uk.Delete(context.Background(), "k2")
+	assert.NoError(t, err)
+	assert.False(t, actual)
+}
+
 func TestUnusedKeys_Size(t *testing.T) {
 	mr, err := miniredis.Run()
 	require.NoError(t, err)
 	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
 	client.SAdd(context.Background(), unusedSetName, "k1", "k2", "k3")
 
-	uk := NewUnusedKeys(client)
+	uk := NewUnusedKeys(client, "")
 	actual, err := uk.Size(context.Background())
 	assert.NoError(t, err)
 	assert.Equal(t, int64(3), actual)
@@ -65,7 +132,7 @@ func TestUnusedKeys_Store(t *testing.T) {
 	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
 
 	k := "test_key"
-	uk := NewUnusedKeys(client)
+	uk := NewUnusedKeys(client, "")
 	actual, err := uk.Store(context.Background(), k)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), actual)
@@ -80,7 +147,7 @@ func TestUnusedKeys_StoreDuplicate(t *testing.T) {
 	client.SAdd(context.Background(), unusedSetName, "k1", "k2", "k3")
 
 	k := "k2"
-	uk := NewUnusedKeys(client)
+	uk := NewUnusedKeys(client, "")
 	actual, err := uk.Store(context.Background(), k)
 	assert.NoError(t, err)
 	assert.Zero(t, actual)
@@ -93,8 +160,113 @@ func TestUnusedKeys_Store_RedisError(t *testing.T) {
 	k := "k2"
 	mock.ExpectSAdd(unusedSetName, k).SetErr(redis.ErrClosed)
 
-	uk := NewUnusedKeys(db)
+	uk := NewUnusedKeys(db, "")
 	actual, err := uk.Store(context.Background())
 	assert.Zero(t, actual)
 	assert.Error(t, err)
 }
+
+func TestUnusedKeys_Pool(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	client.SAdd(context.Background(), unusedSetName, "k1")
+
+	uk := NewUnusedKeys(client, "sms")
+	stored, err := uk.Store(context.Background(), "k2", "k3")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stored)
+
+	assert.ElementsMatch(t, []string{"k2", "k3"}, client.SMembers(context.Background(), unusedSetName+":sms").Val())
+	assert.Equal(t, []string{"k1"}, client.SMembers(context.Background(), unusedSetName).Val())
+
+	size, err := uk.Size(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), size)
+}
+
+func TestUnusedKeys_Sharded_Store(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	keys := make([]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		keys = append(keys, "k"+strconv.Itoa(i))
+	}
+	uk := NewShardedUnusedKeys(client, "sms", 4)
+	stored, err := uk.Store(ctx, keys...)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), stored)
+	stored, err = uk.Store(ctx, "k1", "k2")
+	require.NoError(t, err)
+	assert.Zero(t, stored)
+
+	// the first shard keeps the set name of unsharded keys
+	names := []string{unusedSetName + ":sms", unusedSetName + "#1:sms", unusedSetName + "#2:sms", unusedSetName + "#3:sms"}
+	for _, name := range names {
+		assert.InDelta(t, 250, client.SCard(ctx, name).Val(), 50, name)
+	}
+
+	size, err := uk.Size(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), size)
+}
+
+func TestUnusedKeys_Sharded_LoadAndDelete(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+	// a single shard has keys - other shards are empty
+	client.SAdd(ctx, unusedSetName+"#2", "k1", "k2", "k3")
+
+	uk := NewShardedUnusedKeys(client, "", 4)
+	loaded := map[string]struct{}{}
+	for i := 0; i < 3; i++ {
+		k, err := uk.LoadAndDelete(ctx)
+		require.NoError(t, err)
+		loaded[k] = struct{}{}
+	}
+	assert.Len(t, loaded, 3)
+
+	_, err := uk.LoadAndDelete(ctx)
+	assert.ErrorIs(t, err, errbrick.ErrNotFound)
+}
+
+func TestUnusedKeys_Sharded_LoadAndDeleteN(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+	client.SAdd(ctx, unusedSetName, "k1", "k2")
+	client.SAdd(ctx, unusedSetName+"#1", "k3")
+	client.SAdd(ctx, unusedSetName+"#2", "k4", "k5")
+
+	uk := NewShardedUnusedKeys(client, "", 3)
+	actual, err := uk.LoadAndDeleteN(ctx, 4)
+	require.NoError(t, err)
+	assert.Len(t, actual, 4)
+
+	actual, err = uk.LoadAndDeleteN(ctx, 4)
+	require.NoError(t, err)
+	assert.Len(t, actual, 1)
+
+	_, err = uk.LoadAndDeleteN(ctx, 4)
+	assert.ErrorIs(t, err, errbrick.ErrNotFound)
+}
+
+func TestUnusedKeys_Sharded_Delete(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+	// the key is stored before sharding, so it's not in its shard
+	client.SAdd(ctx, unusedSetName, "k1")
+
+	uk := NewShardedUnusedKeys(client, "", 4)
+	deleted, err := uk.Delete(ctx, "k1")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = uk.Delete(ctx, "k1")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
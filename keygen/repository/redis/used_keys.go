@@ -2,29 +2,264 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/demeero/bricks/errbrick"
 	"github.com/redis/go-redis/v9"
 )
 
+const (
+	// scanBatchSize is a hint of the number of keys that are scanned in a single round trip.
+	scanBatchSize = 1000
+	// mdIssuedAt is a name of the metadata field with the issue time of the used key (see key.Keys.Lookup).
+	mdIssuedAt = "issued_at"
+)
+
 type UsedKeys struct {
-	rds redis.Cmdable
+	rds    redis.Cmdable
+	prefix string
 }
 
-func NewUsedKeys(rds redis.Cmdable) *UsedKeys {
+// NewUsedKeys creates a new UsedKeys of the pool.
+// Keys of every pool are stored with the pool prefix, so the same key can be used in different pools.
+func NewUsedKeys(rds redis.Cmdable, pool string) *UsedKeys {
 	return &UsedKeys{
-		rds: rds,
+		rds:    rds,
+		prefix: usedKeyPrefixOf(pool),
+	}
+}
+
+// usedKeyPrefixOf returns a prefix of used keys of the pool.
+// Empty pool has no prefix to keep keys that were stored before pools were introduced.
+func usedKeyPrefixOf(pool string) string {
+	if pool == "" {
+		return ""
 	}
+	return pool + ":"
 }
 
+// Store sets the key with SETNX. Its value is metadata with the issue time, so the time is recorded atomically.
 func (u *UsedKeys) Store(ctx context.Context, k string, ttl time.Duration) (bool, error) {
-	return u.rds.SetNX(ctx, k, nil, ttl).Result()
+	return u.rds.SetNX(ctx, u.prefix+k, issuedValue(time.Now()), ttl).Result()
+}
+
+// issuedValue returns metadata with the issue time encoded as JSON as the value of the stored used key.
+func issuedValue(issuedAt time.Time) string {
+	return `{"` + mdIssuedAt + `":"` + issuedAt.UTC().Format(time.RFC3339Nano) + `"}`
+}
+
+func (u *UsedKeys) Delete(ctx context.Context, k string) (bool, error) {
+	result, err := u.rds.Del(ctx, u.prefix+k).Result()
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
+func (u *UsedKeys) Extend(ctx context.Context, k string, expAt time.Time) (bool, error) {
+	return u.rds.PExpireAt(ctx, u.prefix+k, expAt).Result()
+}
+
+// Annotate sets metadata encoded as JSON as the value of the key. The TTL of the key is kept.
+func (u *UsedKeys) Annotate(ctx context.Context, k string, md map[string]string) (bool, error) {
+	v, err := json.Marshal(md)
+	if err != nil {
+		return false, err
+	}
+	err = u.rds.SetArgs(ctx, u.prefix+k, v, redis.SetArgs{Mode: "XX", KeepTTL: true}).Err()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Lookup loads the value and TTL of the key with a single pipeline.
+// The value is empty if the key was stored before issue times were recorded.
+func (u *UsedKeys) Lookup(ctx context.Context, k string) (map[string]string, time.Time, error) {
+	var (
+		get *redis.StringCmd
+		ttl *redis.DurationCmd
+	)
+	_, err := u.rds.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, u.prefix+k)
+		ttl = pipe.PTTL(ctx, u.prefix+k)
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return nil, time.Time{}, fmt.Errorf("%w: key is not used: %s", errbrick.ErrNotFound, k)
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var expAt time.Time
+	// the reply is -1 if the key has no TTL
+	if ttl.Val() >= 0 {
+		expAt = time.Now().Add(ttl.Val())
+	}
+	if get.Val() == "" {
+		return nil, expAt, nil
+	}
+	var md map[string]string
+	if err := json.Unmarshal([]byte(get.Val()), &md); err != nil {
+		return nil, time.Time{}, err
+	}
+	return md, expAt, nil
 }
 
 func (u *UsedKeys) Exists(ctx context.Context, k string) (bool, error) {
-	result, err := u.rds.Exists(ctx, k).Result()
+	result, err := u.rds.Exists(ctx, u.prefix+k).Result()
 	if err != nil {
 		return false, err
 	}
 	return result == 1, nil
 }
+
+// ExistsMany checks existence of the keys with a single pipeline.
+func (u *UsedKeys) ExistsMany(ctx context.Context, keys []string) ([]bool, error) {
+	cmds := make([]*redis.IntCmd, 0, len(keys))
+	_, err := u.rds.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, k := range keys {
+			cmds = append(cmds, pipe.Exists(ctx, u.prefix+k))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]bool, len(cmds))
+	for i, cmd := range cmds {
+		result[i] = cmd.Val() == 1
+	}
+	return result, nil
+}
+
+// Count scans keys of the pool. It takes a round trip per scanned batch of keys in the database
+// (of every node of Redis Cluster).
+func (u *UsedKeys) Count(ctx context.Context) (int64, error) {
+	var count atomic.Int64
+	err := forEachNode(ctx, u.rds, func(ctx context.Context, node redis.Cmdable) error {
+		iter := node.Scan(ctx, 0, u.match(), scanBatchSize).Iterator()
+		for iter.Next(ctx) {
+			if u.foreign(iter.Val()) {
+				continue
+			}
+			count.Add(1)
+		}
+		return iter.Err()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count.Load(), nil
+}
+
+// match returns a SCAN pattern of keys of the pool.
+func (u *UsedKeys) match() string {
+	if u.prefix == "" {
+		return "*"
+	}
+	return globEscaper.Replace(u.prefix) + "*"
+}
+
+// keygenKeys are names of other keys of keygen that have no chars that generated and reserved keys can't contain.
+// A used key can't have such a name while the key of keygen exists in the same DB, since used keys are stored with SETNX.
+var keygenKeys = map[string]struct{}{
+	unusedSetName:      {},
+	sequenceName:       {},
+	keyLenName:         {},
+	GeneratorLeaseName: {},
+}
+
+// foreign reports whether the scanned key isn't a used key of the pool.
+// Keys of other pools and most of other keys of keygen (e.g. in the DB shared with unused keys or in Redis Cluster
+// that has a single DB) have ':', '{' or '#' that generated and reserved keys can't contain.
+func (u *UsedKeys) foreign(k string) bool {
+	if u.prefix != "" {
+		return false
+	}
+	if strings.ContainsAny(k, ":{#") {
+		return true
+	}
+	_, ok := keygenKeys[k]
+	return ok
+}
+
+// Scan scans keys of the pool with SCAN, so a page may have more or fewer keys than pageSize
+// and a key may be passed more than once. Expiration times of every page are loaded with a single pipeline.
+// Nodes of Redis Cluster are scanned concurrently, but fn is called by one page at a time.
+func (u *UsedKeys) Scan(ctx context.Context, pageSize int64, fn func(map[string]time.Time) error) error {
+	var mu sync.Mutex
+	return forEachNode(ctx, u.rds, func(ctx context.Context, node redis.Cmdable) error {
+		var cursor uint64
+		for {
+			scanned, next, err := node.Scan(ctx, cursor, u.match(), pageSize).Result()
+			if err != nil {
+				return err
+			}
+			keys := make([]string, 0, len(scanned))
+			for _, k := range scanned {
+				if u.foreign(k) {
+					continue
+				}
+				keys = append(keys, strings.TrimPrefix(k, u.prefix))
+			}
+			if len(keys) > 0 {
+				page, err := u.ExpiresAt(ctx, keys)
+				if err != nil {
+					return err
+				}
+				mu.Lock()
+				err = fn(page)
+				mu.Unlock()
+				if err != nil {
+					return err
+				}
+			}
+			if next == 0 {
+				return nil
+			}
+			cursor = next
+		}
+	})
+}
+
+// ExpiresAt loads expiration times of the keys with a single pipeline.
+// The expiration time is zero if the key has no TTL.
+func (u *UsedKeys) ExpiresAt(ctx context.Context, keys []string) (map[string]time.Time, error) {
+	cmds := make([]*redis.DurationCmd, 0, len(keys))
+	_, err := u.rds.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, k := range keys {
+			cmds = append(cmds, pipe.PTTL(ctx, u.prefix+k))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	result := make(map[string]time.Time, len(keys))
+	for i, cmd := range cmds {
+		// the reply is -2 if the key doesn't exist and -1 if it has no TTL
+		switch ttl := cmd.Val(); {
+		case ttl == -2:
+			continue
+		case ttl < 0:
+			result[keys[i]] = time.Time{}
+		default:
+			result[keys[i]] = now.Add(ttl)
+		}
+	}
+	return result, nil
+}
+
+// globEscaper escapes special chars of the Redis glob-style pattern.
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -18,7 +19,7 @@ func TestUsedKeys_Exists(t *testing.T) {
 	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
 	k := "k1"
 	client.Set(context.Background(), k, nil, 0)
-	uk := NewUsedKeys(client)
+	uk := NewUsedKeys(client, "")
 
 	actual, err := uk.Exists(context.Background(), k)
 	assert.True(t, actual)
@@ -31,7 +32,7 @@ func TestUsedKeys_Exists_NotExists(t *testing.T) {
 	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
 	k := "k1"
 	client.Set(context.Background(), "k2", nil, 0)
-	uk := NewUsedKeys(client)
+	uk := NewUsedKeys(client, "")
 
 	actual, err := uk.Exists(context.Background(), k)
 	assert.False(t, actual)
@@ -42,19 +43,43 @@ func TestUsedKeys_Exists_RedisErr(t *testing.T) {
 	db, mock := redismock.NewClientMock()
 	k := "k1"
 	mock.ExpectExists(k).SetErr(redis.ErrClosed)
-	uk := NewUsedKeys(db)
+	uk := NewUsedKeys(db, "")
 
 	actual, err := uk.Exists(context.Background(), k)
 	assert.False(t, actual)
 	assert.Error(t, err)
 }
 
+func TestUsedKeys_ExistsMany(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	client.Set(context.Background(), "k1", nil, 0)
+	client.Set(context.Background(), "k3", nil, 0)
+	uk := NewUsedKeys(client, "")
+
+	actual, err := uk.ExistsMany(context.Background(), []string{"k1", "k2", "k3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true, false, true}, actual)
+}
+
+func TestUsedKeys_ExistsMany_RedisErr(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	mock.ExpectExists("k1").SetVal(1)
+	mock.ExpectExists("k2").SetErr(redis.ErrClosed)
+	uk := NewUsedKeys(db, "")
+
+	actual, err := uk.ExistsMany(context.Background(), []string{"k1", "k2"})
+	assert.Nil(t, actual)
+	assert.Error(t, err)
+}
+
 func TestUsedKeys_Store(t *testing.T) {
 	mr, err := miniredis.Run()
 	require.NoError(t, err)
 	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
 	k := "k1"
-	uk := NewUsedKeys(client)
+	uk := NewUsedKeys(client, "")
 
 	actual, err := uk.Store(context.Background(), k, time.Hour)
 	assert.True(t, actual)
@@ -70,7 +95,7 @@ func TestUsedKeys_StoreDuplicate(t *testing.T) {
 	k := "k1"
 	client.Set(context.Background(), k, nil, 0)
 
-	uk := NewUsedKeys(client)
+	uk := NewUsedKeys(client, "")
 
 	actual, err := uk.Store(context.Background(), k, time.Hour)
 	assert.False(t, actual)
@@ -80,10 +105,200 @@ func TestUsedKeys_StoreDuplicate(t *testing.T) {
 func TestUsedKeys_Store_RedisErr(t *testing.T) {
 	db, mock := redismock.NewClientMock()
 	k := "k1"
-	mock.ExpectSetNX(k, "", time.Hour).SetErr(redis.ErrClosed)
-	uk := NewUsedKeys(db)
+	mock.Regexp().ExpectSetNX(k, `\{"issued_at":".*"\}`, time.Hour).SetErr(redis.ErrClosed)
+	uk := NewUsedKeys(db, "")
 
 	actual, err := uk.Store(context.Background(), k, time.Hour)
 	assert.False(t, actual)
 	assert.Error(t, err)
 }
+
+func TestUsedKeys_Delete(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	k := "k1"
+	client.Set(context.Background(), k, nil, time.Hour)
+	uk := NewUsedKeys(client, "")
+
+	actual, err := uk.Delete(context.Background(), k)
+	assert.True(t, actual)
+	assert.NoError(t, err)
+
+	assert.Zero(t, client.Exists(context.Background(), k).Val())
+}
+
+func TestUsedKeys_Delete_NotExists(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	uk := NewUsedKeys(client, "")
+
+	actual, err := uk.Delete(context.Background(), "k1")
+	assert.False(t, actual)
+	assert.NoError(t, err)
+}
+
+func TestUsedKeys_Delete_RedisErr(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	k := "k1"
+	mock.ExpectDel(k).SetErr(redis.ErrClosed)
+	uk := NewUsedKeys(db, "")
+
+	actual, err := uk.Delete(context.Background(), k)
+	assert.False(t, actual)
+	assert.Error(t, err)
+}
+
+func TestUsedKeys_Extend(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	k := "k1"
+	client.Set(context.Background(), k, nil, time.Hour)
+	uk := NewUsedKeys(client, "")
+
+	actual, err := uk.Extend(context.Background(), k, time.Now().Add(48*time.Hour))
+	assert.True(t, actual)
+	assert.NoError(t, err)
+
+	assert.InDelta(t, 48*time.Hour, client.PTTL(context.Background(), k).Val(), float64(time.Minute))
+}
+
+func TestUsedKeys_Extend_Expired(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	k := "k1"
+	client.Set(context.Background(), k, nil, time.Hour)
+	mr.FastForward(2 * time.Hour)
+	uk := NewUsedKeys(client, "")
+
+	actual, err := uk.Extend(context.Background(), k, time.Now().Add(48*time.Hour))
+	assert.False(t, actual)
+	assert.NoError(t, err)
+
+	assert.Zero(t, client.Exists(context.Background(), k).Val())
+}
+
+func TestUsedKeys_Extend_RedisErr(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	k := "k1"
+	expAt := time.Now().Add(48 * time.Hour)
+	mock.ExpectPExpireAt(k, expAt).SetErr(redis.ErrClosed)
+	uk := NewUsedKeys(db, "")
+
+	actual, err := uk.Extend(context.Background(), k, expAt)
+	assert.False(t, actual)
+	assert.Error(t, err)
+}
+
+func TestUsedKeys_Pool(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	k := "k1"
+	client.Set(context.Background(), k, "", time.Hour)
+	uk := NewUsedKeys(client, "sms")
+
+	exists, err := uk.Exists(context.Background(), k)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	stored, err := uk.Store(context.Background(), k, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, stored)
+	assert.Equal(t, int64(1), client.Exists(context.Background(), "sms:"+k).Val())
+
+	deleted, err := uk.Delete(context.Background(), k)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	assert.Equal(t, int64(1), client.Exists(context.Background(), k).Val())
+}
+
+func TestUsedKeys_Count_Pools(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	for _, k := range []string{"k1", "k2", "sms:k1", "sms*:k1", "other:k3"} {
+		client.Set(context.Background(), k, "", time.Hour)
+	}
+
+	count, err := NewUsedKeys(client, "").Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	count, err = NewUsedKeys(client, "sms").Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, err = NewUsedKeys(client, "sms*").Count(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestUsedKeys_Count_SharedDB(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+	used := NewUsedKeys(client, "")
+	_, err := used.Store(ctx, "k1", time.Hour)
+	require.NoError(t, err)
+	// other keys of keygen live in the same DB
+	_, err = NewShardedUnusedKeys(client, "", 4).Store(ctx, "k2", "k3", "k4", "k5", "k6", "k7")
+	require.NoError(t, err)
+	lease := NewLease(client, GeneratorLeaseName, time.Minute)
+	_, err = lease.Acquire(ctx)
+	require.NoError(t, err)
+	_, err = NewFencedUnusedKeys(NewUnusedKeys(client, ""), lease).Store(ctx, "k8")
+	require.NoError(t, err)
+	_, err = NewSequence(client, "").Next(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, NewKeyLen(client, "").Store(ctx, 7))
+
+	count, err := used.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestUsedKeys_Scan(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	for _, k := range []string{"k1", "k2", "k3", "sms:k1", "sms:k4"} {
+		client.Set(context.Background(), k, "", time.Hour)
+	}
+
+	scanned := map[string]time.Time{}
+	err := NewUsedKeys(client, "sms").Scan(context.Background(), 1, func(page map[string]time.Time) error {
+		for k, expAt := range page {
+			scanned[k] = expAt
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, scanned, 2)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), scanned["k1"], time.Second)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), scanned["k4"], time.Second)
+}
+
+func TestUsedKeys_Scan_FnErr(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	client.Set(context.Background(), "k1", "", time.Hour)
+	testErr := errors.New("test err")
+
+	err := NewUsedKeys(client, "").Scan(context.Background(), 10, func(map[string]time.Time) error { return testErr })
+	assert.ErrorIs(t, err, testErr)
+}
+
+func TestUsedKeys_ExpiresAt(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	client.Set(context.Background(), "k1", "", time.Hour)
+	client.Set(context.Background(), "k2", "", 0)
+
+	actual, err := NewUsedKeys(client, "").ExpiresAt(context.Background(), []string{"k1", "k2", "k3"})
+	require.NoError(t, err)
+	require.Len(t, actual, 2)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), actual["k1"], time.Second)
+	assert.Zero(t, actual["k2"])
+}
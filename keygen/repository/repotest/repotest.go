@@ -0,0 +1,260 @@
+// Package repotest is a test suite that every implementation of key repositories must pass.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/demeero/bricks/errbrick"
+	"github.com/demeero/pocket-link/keygen/key"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// UsedKeys runs the suite against used keys repositories. Every subtest gets an empty repository from newRepo.
+// newRepo also returns a function that moves the clock of the repository forward.
+// Expiration isn't tested if it's nil (e.g. MongoDB deletes expired keys only once a minute).
+func UsedKeys(t *testing.T, newRepo func(t *testing.T) (key.UsedKeysRepository, func(time.Duration))) {
+	t.Helper()
+	ctx := context.Background()
+
+	t.Run("store", func(t *testing.T) {
+		repo, _ := newRepo(t)
+
+		stored, err := repo.Store(ctx, "k1", time.Hour)
+		require.NoError(t, err)
+		assert.True(t, stored)
+
+		stored, err = repo.Store(ctx, "k1", time.Hour)
+		require.NoError(t, err)
+		assert.False(t, stored)
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		store(t, repo, "k1")
+
+		exists, err := repo.Exists(ctx, "k1")
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = repo.Exists(ctx, "k2")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("exists many", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		store(t, repo, "k1", "k3")
+
+		exists, err := repo.ExistsMany(ctx, []string{"k1", "k2", "k3"})
+		require.NoError(t, err)
+		assert.Equal(t, []bool{true, false, true}, exists)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		store(t, repo, "k1")
+
+		deleted, err := repo.Delete(ctx, "k1")
+		require.NoError(t, err)
+		assert.True(t, deleted)
+
+		exists, err := repo.Exists(ctx, "k1")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		deleted, err = repo.Delete(ctx, "k1")
+		require.NoError(t, err)
+		assert.False(t, deleted)
+	})
+
+	t.Run("extend", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		store(t, repo, "k1")
+
+		extended, err := repo.Extend(ctx, "k1", time.Now().Add(48*time.Hour))
+		require.NoError(t, err)
+		assert.True(t, extended)
+
+		extended, err = repo.Extend(ctx, "k2", time.Now().Add(48*time.Hour))
+		require.NoError(t, err)
+		assert.False(t, extended)
+	})
+
+	t.Run("count", func(t *testing.T) {
+		repo, _ := newRepo(t)
+
+		count, err := repo.Count(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+
+		store(t, repo, "k1", "k2")
+		count, err = repo.Count(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("annotate and lookup", func(t *testing.T) {
+		repo, _ := newRepo(t)
+		store(t, repo, "k1", "k2")
+		md := map[string]string{"client_id": "c1", "tenant": "t1"}
+
+		annotated, err := repo.Annotate(ctx, "k1", md)
+		require.NoError(t, err)
+		assert.True(t, annotated)
+		extended, err := repo.Extend(ctx, "k1", time.Now().Add(48*time.Hour))
+		require.NoError(t, err)
+		require.True(t, extended)
+
+		// the metadata is kept when the key is extended
+		actual, expAt, err := repo.Lookup(ctx, "k1")
+		require.NoError(t, err)
+		assert.Equal(t, md, actual)
+		assert.WithinDuration(t, time.Now().Add(48*time.Hour), expAt, time.Second)
+
+		// the issue time is recorded on store
+		actual, expAt, err = repo.Lookup(ctx, "k2")
+		require.NoError(t, err)
+		require.Len(t, actual, 1)
+		issuedAt, err := time.Parse(time.RFC3339Nano, actual["issued_at"])
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), issuedAt, time.Second)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), expAt, time.Second)
+
+		annotated, err = repo.Annotate(ctx, "k3", md)
+		require.NoError(t, err)
+		assert.False(t, annotated)
+		_, _, err = repo.Lookup(ctx, "k3")
+		assert.ErrorIs(t, err, errbrick.ErrNotFound)
+	})
+
+	t.Run("expiration", func(t *testing.T) {
+		repo, forward := newRepo(t)
+		if forward == nil {
+			t.Skip("the repository doesn't support clock forwarding")
+		}
+		store(t, repo, "k1", "k2")
+		extended, err := repo.Extend(ctx, "k2", time.Now().Add(48*time.Hour))
+		require.NoError(t, err)
+		require.True(t, extended)
+
+		forward(2 * time.Hour)
+
+		exists, err := repo.ExistsMany(ctx, []string{"k1", "k2"})
+		require.NoError(t, err)
+		assert.Equal(t, []bool{false, true}, exists)
+
+		count, err := repo.Count(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		extended, err = repo.Extend(ctx, "k1", time.Now().Add(48*time.Hour))
+		require.NoError(t, err)
+		assert.False(t, extended)
+
+		stored, err := repo.Store(ctx, "k1", time.Hour)
+		require.NoError(t, err)
+		assert.True(t, stored)
+	})
+}
+
+// UnusedKeys runs the suite against unused keys repositories. Every subtest gets an empty repository from newRepo.
+func UnusedKeys(t *testing.T, newRepo func(t *testing.T) key.UnusedKeysRepository) {
+	t.Helper()
+	ctx := context.Background()
+
+	t.Run("store", func(t *testing.T) {
+		repo := newRepo(t)
+
+		stored, err := repo.Store(ctx, "k1", "k2")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), stored)
+
+		stored, err = repo.Store(ctx, "k2", "k3")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), stored)
+
+		size, err := repo.Size(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), size)
+	})
+
+	t.Run("load and delete", func(t *testing.T) {
+		repo := newRepo(t)
+		_, err := repo.Store(ctx, "k1")
+		require.NoError(t, err)
+
+		k, err := repo.LoadAndDelete(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "k1", k)
+
+		_, err = repo.LoadAndDelete(ctx)
+		assert.ErrorIs(t, err, errbrick.ErrNotFound)
+	})
+
+	t.Run("load and delete n", func(t *testing.T) {
+		repo := newRepo(t)
+		_, err := repo.Store(ctx, "k1", "k2", "k3")
+		require.NoError(t, err)
+
+		first, err := repo.LoadAndDeleteN(ctx, 2)
+		require.NoError(t, err)
+		assert.Len(t, first, 2)
+
+		rest, err := repo.LoadAndDeleteN(ctx, 5)
+		require.NoError(t, err)
+		assert.Len(t, rest, 1)
+		assert.ElementsMatch(t, []string{"k1", "k2", "k3"}, append(first, rest...))
+
+		_, err = repo.LoadAndDeleteN(ctx, 1)
+		assert.ErrorIs(t, err, errbrick.ErrNotFound)
+	})
+
+	t.Run("load and delete n in random order", func(t *testing.T) {
+		repo := newRepo(t)
+		keys := make([]string, 100)
+		for i := range keys {
+			keys[i] = fmt.Sprintf("k%03d", i)
+		}
+		_, err := repo.Store(ctx, keys...)
+		require.NoError(t, err)
+
+		// keys that are issued in the order they are stored or sorted are predictable
+		loaded, err := repo.LoadAndDeleteN(ctx, 20)
+		require.NoError(t, err)
+		require.Len(t, loaded, 20)
+		assert.False(t, slices.IsSorted(loaded), "keys are loaded in sorted order: %v", loaded)
+		assert.NotEqual(t, keys[:20], loaded)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		repo := newRepo(t)
+		_, err := repo.Store(ctx, "k1")
+		require.NoError(t, err)
+
+		deleted, err := repo.Delete(ctx, "k1")
+		require.NoError(t, err)
+		assert.True(t, deleted)
+
+		deleted, err = repo.Delete(ctx, "k1")
+		require.NoError(t, err)
+		assert.False(t, deleted)
+
+		size, err := repo.Size(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, size)
+	})
+}
+
+func store(t *testing.T, repo key.UsedKeysRepository, keys ...string) {
+	t.Helper()
+	for _, k := range keys {
+		stored, err := repo.Store(context.Background(), k, time.Hour)
+		require.NoError(t, err)
+		require.True(t, stored)
+	}
+}
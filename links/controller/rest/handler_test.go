@@ -42,11 +42,12 @@ func Test_create(t *testing.T) {
 
 	mockRepo := service.NewMockRepository(ctrl)
 	mockKGCli := service.NewMockKeygenServiceClient(ctrl)
-	mockKGCli.EXPECT().GenerateKey(ctx, &keygenpb.GenerateKeyRequest{}).Return(&keygenpb.GenerateKeyResponse{Key: &keygenpb.Key{
+	mockKGCli.EXPECT().GenerateKey(ctx, &keygenpb.GenerateKeyRequest{RequireConfirmation: true}).Return(&keygenpb.GenerateKeyResponse{Key: &keygenpb.Key{
 		Val:        short,
 		ExpireTime: timestamppb.New(expAt),
 	}}, nil)
 	mockRepo.EXPECT().Create(ctx, l).Return(expected, nil)
+	mockKGCli.EXPECT().ConfirmKey(gomock.Any(), gomock.Any()).Return(&keygenpb.ConfirmKeyResponse{}, nil)
 
 	cl := createLink{Original: orig}
 	b, err := json.Marshal(cl)
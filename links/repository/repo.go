@@ -69,3 +69,9 @@ func (r *Repository) LoadByID(ctx context.Context, shortened string) (service.Li
 		ExpAt:     lm.ExpAt,
 	}, nil
 }
+
+// Delete deletes the link. It's not an error if the link doesn't exist.
+func (r *Repository) Delete(ctx context.Context, shortened string) error {
+	_, err := r.coll.DeleteOne(ctx, bson.M{"_id": shortened})
+	return err
+}
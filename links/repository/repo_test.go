@@ -109,3 +109,28 @@ func TestRepository_LoadByID(t *testing.T) {
 		assert.Zero(mt, actual)
 	})
 }
+
+// nolint:govet
+func TestRepository_Delete(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	shortened := "shortened_test"
+
+	mt.Run("success", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{"ok", 1}})
+		repo, err := New(mt.DB)
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(bson.D{{"ok", 1}, {"n", 1}})
+		assert.NoError(mt, repo.Delete(context.Background(), shortened))
+	})
+
+	mt.Run("error", func(mt *mtest.T) {
+		mt.AddMockResponses(bson.D{{"ok", 1}})
+		repo, err := New(mt.DB)
+		require.NoError(mt, err)
+
+		mt.AddMockResponses(bson.D{{"ok", 0}})
+		assert.Error(mt, repo.Delete(context.Background(), shortened))
+	})
+}
@@ -36,6 +36,46 @@ func (m *MockKeygenServiceClient) EXPECT() *MockKeygenServiceClientMockRecorder
 	return m.recorder
 }
 
+// ConfirmKey mocks base method.
+func (m *MockKeygenServiceClient) ConfirmKey(arg0 context.Context, arg1 *v1beta1.ConfirmKeyRequest, arg2 ...grpc.CallOption) (*v1beta1.ConfirmKeyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ConfirmKey", varargs...)
+	ret0, _ := ret[0].(*v1beta1.ConfirmKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmKey indicates an expected call of ConfirmKey.
+func (mr *MockKeygenServiceClientMockRecorder) ConfirmKey(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmKey", reflect.TypeOf((*MockKeygenServiceClient)(nil).ConfirmKey), varargs...)
+}
+
+// ExtendKey mocks base method.
+func (m *MockKeygenServiceClient) ExtendKey(arg0 context.Context, arg1 *v1beta1.ExtendKeyRequest, arg2 ...grpc.CallOption) (*v1beta1.ExtendKeyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExtendKey", varargs...)
+	ret0, _ := ret[0].(*v1beta1.ExtendKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExtendKey indicates an expected call of ExtendKey.
+func (mr *MockKeygenServiceClientMockRecorder) ExtendKey(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExtendKey", reflect.TypeOf((*MockKeygenServiceClient)(nil).ExtendKey), varargs...)
+}
+
 // GenerateKey mocks base method.
 func (m *MockKeygenServiceClient) GenerateKey(arg0 context.Context, arg1 *v1beta1.GenerateKeyRequest, arg2 ...grpc.CallOption) (*v1beta1.GenerateKeyResponse, error) {
 	m.ctrl.T.Helper()
@@ -55,3 +95,123 @@ func (mr *MockKeygenServiceClientMockRecorder) GenerateKey(arg0, arg1 interface{
 	varargs := append([]interface{}{arg0, arg1}, arg2...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateKey", reflect.TypeOf((*MockKeygenServiceClient)(nil).GenerateKey), varargs...)
 }
+
+// GenerateKeys mocks base method.
+func (m *MockKeygenServiceClient) GenerateKeys(arg0 context.Context, arg1 *v1beta1.GenerateKeysRequest, arg2 ...grpc.CallOption) (*v1beta1.GenerateKeysResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GenerateKeys", varargs...)
+	ret0, _ := ret[0].(*v1beta1.GenerateKeysResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateKeys indicates an expected call of GenerateKeys.
+func (mr *MockKeygenServiceClientMockRecorder) GenerateKeys(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateKeys", reflect.TypeOf((*MockKeygenServiceClient)(nil).GenerateKeys), varargs...)
+}
+
+// GetStats mocks base method.
+func (m *MockKeygenServiceClient) GetStats(arg0 context.Context, arg1 *v1beta1.GetStatsRequest, arg2 ...grpc.CallOption) (*v1beta1.GetStatsResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetStats", varargs...)
+	ret0, _ := ret[0].(*v1beta1.GetStatsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStats indicates an expected call of GetStats.
+func (mr *MockKeygenServiceClientMockRecorder) GetStats(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockKeygenServiceClient)(nil).GetStats), varargs...)
+}
+
+// LookupKey mocks base method.
+func (m *MockKeygenServiceClient) LookupKey(arg0 context.Context, arg1 *v1beta1.LookupKeyRequest, arg2 ...grpc.CallOption) (*v1beta1.LookupKeyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "LookupKey", varargs...)
+	ret0, _ := ret[0].(*v1beta1.LookupKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LookupKey indicates an expected call of LookupKey.
+func (mr *MockKeygenServiceClientMockRecorder) LookupKey(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookupKey", reflect.TypeOf((*MockKeygenServiceClient)(nil).LookupKey), varargs...)
+}
+
+// ReleaseKey mocks base method.
+func (m *MockKeygenServiceClient) ReleaseKey(arg0 context.Context, arg1 *v1beta1.ReleaseKeyRequest, arg2 ...grpc.CallOption) (*v1beta1.ReleaseKeyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ReleaseKey", varargs...)
+	ret0, _ := ret[0].(*v1beta1.ReleaseKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseKey indicates an expected call of ReleaseKey.
+func (mr *MockKeygenServiceClientMockRecorder) ReleaseKey(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseKey", reflect.TypeOf((*MockKeygenServiceClient)(nil).ReleaseKey), varargs...)
+}
+
+// ReserveKey mocks base method.
+func (m *MockKeygenServiceClient) ReserveKey(arg0 context.Context, arg1 *v1beta1.ReserveKeyRequest, arg2 ...grpc.CallOption) (*v1beta1.ReserveKeyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ReserveKey", varargs...)
+	ret0, _ := ret[0].(*v1beta1.ReserveKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReserveKey indicates an expected call of ReserveKey.
+func (mr *MockKeygenServiceClientMockRecorder) ReserveKey(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveKey", reflect.TypeOf((*MockKeygenServiceClient)(nil).ReserveKey), varargs...)
+}
+
+// StreamKeys mocks base method.
+func (m *MockKeygenServiceClient) StreamKeys(arg0 context.Context, arg1 ...grpc.CallOption) (v1beta1.KeygenService_StreamKeysClient, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "StreamKeys", varargs...)
+	ret0, _ := ret[0].(v1beta1.KeygenService_StreamKeysClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StreamKeys indicates an expected call of StreamKeys.
+func (mr *MockKeygenServiceClientMockRecorder) StreamKeys(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamKeys", reflect.TypeOf((*MockKeygenServiceClient)(nil).StreamKeys), varargs...)
+}
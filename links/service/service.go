@@ -3,10 +3,14 @@ package service
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/asaskevich/govalidator"
 	"github.com/demeero/bricks/errbrick"
+	"github.com/demeero/bricks/slogbrick"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	keygenpb "github.com/demeero/pocket-link/proto/gen/go/pocketlink/keygen/v1beta1"
 )
@@ -21,6 +25,7 @@ type Link struct {
 type Repository interface {
 	Create(context.Context, Link) (Link, error)
 	LoadByID(context.Context, string) (Link, error)
+	Delete(context.Context, string) error
 }
 
 type Service struct {
@@ -39,7 +44,8 @@ func (s *Service) Create(ctx context.Context, original string) (Link, error) {
 	if !govalidator.IsURL(original) {
 		return Link{}, fmt.Errorf("%w: incorrect url format: %s", errbrick.ErrInvalidData, original)
 	}
-	resp, err := s.keygenClient.GenerateKey(ctx, &keygenpb.GenerateKeyRequest{})
+	// the key is held until the link is saved - it goes back to free keys if the service crashes before confirmation
+	resp, err := s.keygenClient.GenerateKey(ctx, &keygenpb.GenerateKeyRequest{RequireConfirmation: true})
 	if err != nil {
 		return Link{}, fmt.Errorf("failed generate key: %w", err)
 	}
@@ -49,11 +55,52 @@ func (s *Service) Create(ctx context.Context, original string) (Link, error) {
 		ExpAt:     resp.GetKey().GetExpireTime().AsTime(),
 	})
 	if err != nil {
+		s.releaseKey(ctx, resp.GetKey().GetVal())
 		return Link{}, fmt.Errorf("failed create link: %w", err)
 	}
+	// the link is already saved - the caller's context may be already done, but the key must be confirmed anyway
+	_, err = s.keygenClient.ConfirmKey(context.WithoutCancel(ctx), &keygenpb.ConfirmKeyRequest{
+		Val:        resp.GetKey().GetVal(),
+		ExpireTime: resp.GetKey().GetExpireTime(),
+	})
+	if err != nil {
+		// the unconfirmed key goes back to free keys after the hold timeout or is used by somebody else,
+		// so the link can't keep it
+		s.deleteLink(ctx, resp.GetKey().GetVal(), holdsKey(err, resp.GetConfirmDeadline().AsTime()))
+		return Link{}, fmt.Errorf("failed confirm key: %w", err)
+	}
 	return link, nil
 }
 
+// holdsKey reports whether the key that failed to be confirmed is still held by the service, so it can be released.
+// The key is used by somebody else if the confirmation is rejected with ALREADY_EXISTS,
+// and it may be taken by somebody else once the confirmation deadline has passed.
+func holdsKey(confirmErr error, deadline time.Time) bool {
+	return status.Code(confirmErr) != codes.AlreadyExists && time.Now().Before(deadline)
+}
+
+// deleteLink deletes the link which key isn't confirmed and releases the key if it's still held.
+// If the link can't be deleted, the key isn't released - the link is served until the key is issued again.
+func (s *Service) deleteLink(ctx context.Context, key string, release bool) {
+	// the caller's context may be already done - the link must be deleted anyway
+	if err := s.repo.Delete(context.WithoutCancel(ctx), key); err != nil {
+		slogbrick.FromCtx(ctx).Error("failed delete link with unconfirmed key", slog.String("key", key), slog.Any("err", err))
+		return
+	}
+	if release {
+		s.releaseKey(ctx, key)
+	}
+}
+
+// releaseKey returns the key of the link that wasn't created, so the key doesn't stay used until its expiration.
+func (s *Service) releaseKey(ctx context.Context, key string) {
+	// the caller's context may be already done - the key must be released anyway
+	_, err := s.keygenClient.ReleaseKey(context.WithoutCancel(ctx), &keygenpb.ReleaseKeyRequest{Val: key})
+	if err != nil {
+		slogbrick.FromCtx(ctx).Error("failed release key", slog.String("key", key), slog.Any("err", err))
+	}
+}
+
 func (s *Service) Get(ctx context.Context, shortened string) (Link, error) {
 	link, err := s.repo.LoadByID(ctx, shortened)
 	if err != nil {
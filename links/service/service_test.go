@@ -9,6 +9,8 @@ import (
 	keygenpb "github.com/demeero/pocket-link/proto/gen/go/pocketlink/keygen/v1beta1"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -38,11 +40,13 @@ func TestService_Create(t *testing.T) {
 
 	mockRepo := NewMockRepository(ctrl)
 	mockKGCli := NewMockKeygenServiceClient(ctrl)
-	mockKGCli.EXPECT().GenerateKey(ctx, &keygenpb.GenerateKeyRequest{}).Return(&keygenpb.GenerateKeyResponse{Key: &keygenpb.Key{
+	mockKGCli.EXPECT().GenerateKey(ctx, &keygenpb.GenerateKeyRequest{RequireConfirmation: true}).Return(&keygenpb.GenerateKeyResponse{Key: &keygenpb.Key{
 		Val:        short,
 		ExpireTime: timestamppb.New(expAt),
 	}}, nil)
 	mockRepo.EXPECT().Create(ctx, createLink).Return(expected, nil)
+	mockKGCli.EXPECT().ConfirmKey(gomock.Any(), &keygenpb.ConfirmKeyRequest{Val: short, ExpireTime: timestamppb.New(expAt)}).
+		Return(&keygenpb.ConfirmKeyResponse{}, nil)
 
 	svc := New(mockRepo, mockKGCli)
 
@@ -51,6 +55,104 @@ func TestService_Create(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestService_Create_ConfirmKeyErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	expAt := time.Now().Add(time.Hour)
+	short := "shortened_test1"
+	testErr := errors.New("test err")
+
+	mockRepo := NewMockRepository(ctrl)
+	mockKGCli := NewMockKeygenServiceClient(ctrl)
+	mockKGCli.EXPECT().GenerateKey(ctx, gomock.Any()).Return(&keygenpb.GenerateKeyResponse{
+		Key: &keygenpb.Key{
+			Val:        short,
+			ExpireTime: timestamppb.New(expAt),
+		},
+		ConfirmDeadline: timestamppb.New(time.Now().Add(time.Minute)),
+	}, nil)
+	mockRepo.EXPECT().Create(ctx, gomock.Any()).Return(Link{Shortened: short}, nil)
+	mockKGCli.EXPECT().ConfirmKey(gomock.Any(), gomock.Any()).Return(nil, testErr)
+	mockRepo.EXPECT().Delete(gomock.Any(), short).Return(nil)
+	// the key is still held, so it's released
+	mockKGCli.EXPECT().ReleaseKey(gomock.Any(), &keygenpb.ReleaseKeyRequest{Val: short}).Return(&keygenpb.ReleaseKeyResponse{}, nil)
+
+	svc := New(mockRepo, mockKGCli)
+
+	actual, err := svc.Create(ctx, "original_test.com")
+	assert.ErrorContains(t, err, testErr.Error())
+	assert.Zero(t, actual)
+}
+
+func TestService_Create_ConfirmKeyErr_NotHeld(t *testing.T) {
+	short := "shortened_test1"
+	tests := map[string]struct {
+		confirmErr error
+		deadline   time.Time
+	}{
+		// the key is used by somebody else
+		"already exists": {confirmErr: status.Error(codes.AlreadyExists, "test err"), deadline: time.Now().Add(time.Minute)},
+		// the key may be used by somebody else
+		"deadline passed": {confirmErr: errors.New("test err"), deadline: time.Now().Add(-time.Second)},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			mockRepo := NewMockRepository(ctrl)
+			mockKGCli := NewMockKeygenServiceClient(ctrl)
+			mockKGCli.EXPECT().GenerateKey(ctx, gomock.Any()).Return(&keygenpb.GenerateKeyResponse{
+				Key: &keygenpb.Key{
+					Val:        short,
+					ExpireTime: timestamppb.New(time.Now().Add(time.Hour)),
+				},
+				ConfirmDeadline: timestamppb.New(tt.deadline),
+			}, nil)
+			mockRepo.EXPECT().Create(ctx, gomock.Any()).Return(Link{Shortened: short}, nil)
+			mockKGCli.EXPECT().ConfirmKey(gomock.Any(), gomock.Any()).Return(nil, tt.confirmErr)
+			// the link is deleted, but the key isn't released
+			mockRepo.EXPECT().Delete(gomock.Any(), short).Return(nil)
+
+			svc := New(mockRepo, mockKGCli)
+
+			actual, err := svc.Create(ctx, "original_test.com")
+			assert.Error(t, err)
+			assert.Zero(t, actual)
+		})
+	}
+}
+
+func TestService_Create_ConfirmKeyErr_DeleteErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	expAt := time.Now().Add(time.Hour)
+	short := "shortened_test1"
+	testErr := errors.New("test err")
+
+	mockRepo := NewMockRepository(ctrl)
+	mockKGCli := NewMockKeygenServiceClient(ctrl)
+	mockKGCli.EXPECT().GenerateKey(ctx, gomock.Any()).Return(&keygenpb.GenerateKeyResponse{Key: &keygenpb.Key{
+		Val:        short,
+		ExpireTime: timestamppb.New(expAt),
+	}}, nil)
+	mockRepo.EXPECT().Create(ctx, gomock.Any()).Return(Link{Shortened: short}, nil)
+	mockKGCli.EXPECT().ConfirmKey(gomock.Any(), gomock.Any()).Return(nil, testErr)
+	// the key isn't released while the link keeps it
+	mockRepo.EXPECT().Delete(gomock.Any(), short).Return(errors.New("delete err"))
+
+	svc := New(mockRepo, mockKGCli)
+
+	actual, err := svc.Create(ctx, "original_test.com")
+	assert.ErrorContains(t, err, testErr.Error())
+	assert.Zero(t, actual)
+}
+
 func TestService_Create_InvalidURL(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -78,7 +180,7 @@ func TestService_Create_KeygenClientErr(t *testing.T) {
 	mockRepo := NewMockRepository(ctrl)
 	mockKGCli := NewMockKeygenServiceClient(ctrl)
 	testErr := errors.New("test err")
-	mockKGCli.EXPECT().GenerateKey(ctx, &keygenpb.GenerateKeyRequest{}).Return(nil, testErr)
+	mockKGCli.EXPECT().GenerateKey(ctx, &keygenpb.GenerateKeyRequest{RequireConfirmation: true}).Return(nil, testErr)
 
 	svc := New(mockRepo, mockKGCli)
 
@@ -104,11 +206,38 @@ func TestService_Create_RepoErr(t *testing.T) {
 
 	mockRepo := NewMockRepository(ctrl)
 	mockKGCli := NewMockKeygenServiceClient(ctrl)
-	mockKGCli.EXPECT().GenerateKey(ctx, &keygenpb.GenerateKeyRequest{}).Return(&keygenpb.GenerateKeyResponse{Key: &keygenpb.Key{
+	mockKGCli.EXPECT().GenerateKey(ctx, &keygenpb.GenerateKeyRequest{RequireConfirmation: true}).Return(&keygenpb.GenerateKeyResponse{Key: &keygenpb.Key{
 		Val:        short,
 		ExpireTime: timestamppb.New(expAt),
 	}}, nil)
 	mockRepo.EXPECT().Create(ctx, createLink).Return(Link{}, testErr)
+	mockKGCli.EXPECT().ReleaseKey(gomock.Any(), &keygenpb.ReleaseKeyRequest{Val: short}).Return(&keygenpb.ReleaseKeyResponse{}, nil)
+
+	svc := New(mockRepo, mockKGCli)
+
+	actual, err := svc.Create(ctx, orig)
+	assert.ErrorContains(t, err, testErr.Error())
+	assert.Zero(t, actual)
+}
+
+func TestService_Create_RepoErr_ReleaseKeyErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	expAt := time.Now().Add(time.Hour)
+	orig := "original_test.com"
+	short := "shortened_test1"
+	testErr := errors.New("test err")
+
+	mockRepo := NewMockRepository(ctrl)
+	mockKGCli := NewMockKeygenServiceClient(ctrl)
+	mockKGCli.EXPECT().GenerateKey(ctx, &keygenpb.GenerateKeyRequest{RequireConfirmation: true}).Return(&keygenpb.GenerateKeyResponse{Key: &keygenpb.Key{
+		Val:        short,
+		ExpireTime: timestamppb.New(expAt),
+	}}, nil)
+	mockRepo.EXPECT().Create(ctx, gomock.Any()).Return(Link{}, testErr)
+	mockKGCli.EXPECT().ReleaseKey(gomock.Any(), &keygenpb.ReleaseKeyRequest{Val: short}).Return(nil, errors.New("release err"))
 
 	svc := New(mockRepo, mockKGCli)
 
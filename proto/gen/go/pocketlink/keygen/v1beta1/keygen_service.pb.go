@@ -9,6 +9,7 @@ package v1beta1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
@@ -21,6 +22,57 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type GenerateKeysResponse_Status int32
+
+const (
+	GenerateKeysResponse_STATUS_UNSPECIFIED GenerateKeysResponse_Status = 0
+	// STATUS_COMPLETE means all requested keys are returned.
+	GenerateKeysResponse_STATUS_COMPLETE GenerateKeysResponse_Status = 1
+	// STATUS_PARTIAL means free keys ran out and only part of requested keys is returned.
+	GenerateKeysResponse_STATUS_PARTIAL GenerateKeysResponse_Status = 2
+)
+
+// Enum value maps for GenerateKeysResponse_Status.
+var (
+	GenerateKeysResponse_Status_name = map[int32]string{
+		0: "STATUS_UNSPECIFIED",
+		1: "STATUS_COMPLETE",
+		2: "STATUS_PARTIAL",
+	}
+	GenerateKeysResponse_Status_value = map[string]int32{
+		"STATUS_UNSPECIFIED": 0,
+		"STATUS_COMPLETE":    1,
+		"STATUS_PARTIAL":     2,
+	}
+)
+
+func (x GenerateKeysResponse_Status) Enum() *GenerateKeysResponse_Status {
+	p := new(GenerateKeysResponse_Status)
+	*p = x
+	return p
+}
+
+func (x GenerateKeysResponse_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GenerateKeysResponse_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_enumTypes[0].Descriptor()
+}
+
+func (GenerateKeysResponse_Status) Type() protoreflect.EnumType {
+	return &file_pocketlink_keygen_v1beta1_keygen_service_proto_enumTypes[0]
+}
+
+func (x GenerateKeysResponse_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GenerateKeysResponse_Status.Descriptor instead.
+func (GenerateKeysResponse_Status) EnumDescriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{5, 0}
+}
+
 type Key struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -30,23 +82,1070 @@ type Key struct {
 	ExpireTime *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expire_time,json=expireTime,proto3" json:"expire_time,omitempty"`
 }
 
-func (x *Key) Reset() {
-	*x = Key{}
+func (x *Key) Reset() {
+	*x = Key{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Key) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Key) ProtoMessage() {}
+
+func (x *Key) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Key.ProtoReflect.Descriptor instead.
+func (*Key) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Key) GetVal() string {
+	if x != nil {
+		return x.Val
+	}
+	return ""
+}
+
+func (x *Key) GetExpireTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpireTime
+	}
+	return nil
+}
+
+// KeyMetadata describes the caller a key is generated for, so the key can be traced back to it (e.g. to investigate
+// abuse). All fields are optional.
+type KeyMetadata struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ClientId string `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	Tenant   string `protobuf:"bytes,2,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	TraceId  string `protobuf:"bytes,3,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+}
+
+func (x *KeyMetadata) Reset() {
+	*x = KeyMetadata{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KeyMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyMetadata) ProtoMessage() {}
+
+func (x *KeyMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyMetadata.ProtoReflect.Descriptor instead.
+func (*KeyMetadata) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *KeyMetadata) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *KeyMetadata) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *KeyMetadata) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+type GenerateKeyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// ttl is an optional time to live of the key. If it's not set, the configured default TTL is used.
+	// It must be within the configured bounds.
+	Ttl *durationpb.Duration `protobuf:"bytes,1,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	// pool is a name of the key pool. If it's not set, the default pool is used.
+	// It returns NOT_FOUND if the pool doesn't exist.
+	Pool string `protobuf:"bytes,2,opt,name=pool,proto3" json:"pool,omitempty"`
+	// require_confirmation holds the key for a short time until it's confirmed by ConfirmKey (e.g. after the link is
+	// saved). The key goes back to free keys if it's not confirmed in time, so a client that crashes doesn't leak it.
+	RequireConfirmation bool `protobuf:"varint,3,opt,name=require_confirmation,json=requireConfirmation,proto3" json:"require_confirmation,omitempty"`
+	// metadata is recorded with the key and returned by LookupKey. It's not recorded if it's empty.
+	Metadata *KeyMetadata `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+}
+
+func (x *GenerateKeyRequest) Reset() {
+	*x = GenerateKeyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GenerateKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateKeyRequest) ProtoMessage() {}
+
+func (x *GenerateKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateKeyRequest.ProtoReflect.Descriptor instead.
+func (*GenerateKeyRequest) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GenerateKeyRequest) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+func (x *GenerateKeyRequest) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+func (x *GenerateKeyRequest) GetRequireConfirmation() bool {
+	if x != nil {
+		return x.RequireConfirmation
+	}
+	return false
+}
+
+func (x *GenerateKeyRequest) GetMetadata() *KeyMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type GenerateKeyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// key expires at expire_time once it's confirmed.
+	Key *Key `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// confirm_deadline is a time the key must be confirmed until. It's set only if confirmation is required.
+	ConfirmDeadline *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=confirm_deadline,json=confirmDeadline,proto3" json:"confirm_deadline,omitempty"`
+}
+
+func (x *GenerateKeyResponse) Reset() {
+	*x = GenerateKeyResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GenerateKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateKeyResponse) ProtoMessage() {}
+
+func (x *GenerateKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateKeyResponse.ProtoReflect.Descriptor instead.
+func (*GenerateKeyResponse) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GenerateKeyResponse) GetKey() *Key {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *GenerateKeyResponse) GetConfirmDeadline() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ConfirmDeadline
+	}
+	return nil
+}
+
+type GenerateKeysRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// count is a number of keys to generate. It can't exceed the configured maximum.
+	Count uint32 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	// pool is a name of the key pool. If it's not set, the default pool is used.
+	Pool string `protobuf:"bytes,2,opt,name=pool,proto3" json:"pool,omitempty"`
+}
+
+func (x *GenerateKeysRequest) Reset() {
+	*x = GenerateKeysRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GenerateKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateKeysRequest) ProtoMessage() {}
+
+func (x *GenerateKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateKeysRequest.ProtoReflect.Descriptor instead.
+func (*GenerateKeysRequest) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GenerateKeysRequest) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *GenerateKeysRequest) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+type GenerateKeysResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Keys   []*Key                      `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	Status GenerateKeysResponse_Status `protobuf:"varint,2,opt,name=status,proto3,enum=pocketlink.keygen.v1beta1.GenerateKeysResponse_Status" json:"status,omitempty"`
+}
+
+func (x *GenerateKeysResponse) Reset() {
+	*x = GenerateKeysResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GenerateKeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateKeysResponse) ProtoMessage() {}
+
+func (x *GenerateKeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateKeysResponse.ProtoReflect.Descriptor instead.
+func (*GenerateKeysResponse) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GenerateKeysResponse) GetKeys() []*Key {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+func (x *GenerateKeysResponse) GetStatus() GenerateKeysResponse_Status {
+	if x != nil {
+		return x.Status
+	}
+	return GenerateKeysResponse_STATUS_UNSPECIFIED
+}
+
+type ReserveKeyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Val string `protobuf:"bytes,1,opt,name=val,proto3" json:"val,omitempty"`
+	// pool is a name of the key pool. If it's not set, the default pool is used.
+	Pool string `protobuf:"bytes,2,opt,name=pool,proto3" json:"pool,omitempty"`
+}
+
+func (x *ReserveKeyRequest) Reset() {
+	*x = ReserveKeyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReserveKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReserveKeyRequest) ProtoMessage() {}
+
+func (x *ReserveKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReserveKeyRequest.ProtoReflect.Descriptor instead.
+func (*ReserveKeyRequest) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ReserveKeyRequest) GetVal() string {
+	if x != nil {
+		return x.Val
+	}
+	return ""
+}
+
+func (x *ReserveKeyRequest) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+type ReserveKeyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key *Key `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+}
+
+func (x *ReserveKeyResponse) Reset() {
+	*x = ReserveKeyResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReserveKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReserveKeyResponse) ProtoMessage() {}
+
+func (x *ReserveKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReserveKeyResponse.ProtoReflect.Descriptor instead.
+func (*ReserveKeyResponse) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ReserveKeyResponse) GetKey() *Key {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+type ReleaseKeyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Val string `protobuf:"bytes,1,opt,name=val,proto3" json:"val,omitempty"`
+	// pool is a name of the key pool. If it's not set, the default pool is used.
+	Pool string `protobuf:"bytes,2,opt,name=pool,proto3" json:"pool,omitempty"`
+}
+
+func (x *ReleaseKeyRequest) Reset() {
+	*x = ReleaseKeyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReleaseKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseKeyRequest) ProtoMessage() {}
+
+func (x *ReleaseKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseKeyRequest.ProtoReflect.Descriptor instead.
+func (*ReleaseKeyRequest) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReleaseKeyRequest) GetVal() string {
+	if x != nil {
+		return x.Val
+	}
+	return ""
+}
+
+func (x *ReleaseKeyRequest) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+type ReleaseKeyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ReleaseKeyResponse) Reset() {
+	*x = ReleaseKeyResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReleaseKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseKeyResponse) ProtoMessage() {}
+
+func (x *ReleaseKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseKeyResponse.ProtoReflect.Descriptor instead.
+func (*ReleaseKeyResponse) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{9}
+}
+
+type ExtendKeyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Val string `protobuf:"bytes,1,opt,name=val,proto3" json:"val,omitempty"`
+	// new_expire_time must be in the future and within the configured maximum TTL.
+	NewExpireTime *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=new_expire_time,json=newExpireTime,proto3" json:"new_expire_time,omitempty"`
+	// pool is a name of the key pool. If it's not set, the default pool is used.
+	Pool string `protobuf:"bytes,3,opt,name=pool,proto3" json:"pool,omitempty"`
+}
+
+func (x *ExtendKeyRequest) Reset() {
+	*x = ExtendKeyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExtendKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtendKeyRequest) ProtoMessage() {}
+
+func (x *ExtendKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtendKeyRequest.ProtoReflect.Descriptor instead.
+func (*ExtendKeyRequest) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ExtendKeyRequest) GetVal() string {
+	if x != nil {
+		return x.Val
+	}
+	return ""
+}
+
+func (x *ExtendKeyRequest) GetNewExpireTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NewExpireTime
+	}
+	return nil
+}
+
+func (x *ExtendKeyRequest) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+type ExtendKeyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key *Key `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+}
+
+func (x *ExtendKeyResponse) Reset() {
+	*x = ExtendKeyResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExtendKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtendKeyResponse) ProtoMessage() {}
+
+func (x *ExtendKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtendKeyResponse.ProtoReflect.Descriptor instead.
+func (*ExtendKeyResponse) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ExtendKeyResponse) GetKey() *Key {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+type GetStatsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// pool is a name of the key pool. If it's not set, the default pool is used.
+	Pool string `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"`
+}
+
+func (x *GetStatsRequest) Reset() {
+	*x = GetStatsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsRequest) ProtoMessage() {}
+
+func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsRequest) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetStatsRequest) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+type IssueRate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// window is a period the rate is measured over (e.g. the last 5 minutes).
+	Window        *durationpb.Duration `protobuf:"bytes,1,opt,name=window,proto3" json:"window,omitempty"`
+	KeysPerSecond float64              `protobuf:"fixed64,2,opt,name=keys_per_second,json=keysPerSecond,proto3" json:"keys_per_second,omitempty"`
+}
+
+func (x *IssueRate) Reset() {
+	*x = IssueRate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IssueRate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IssueRate) ProtoMessage() {}
+
+func (x *IssueRate) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IssueRate.ProtoReflect.Descriptor instead.
+func (*IssueRate) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *IssueRate) GetWindow() *durationpb.Duration {
+	if x != nil {
+		return x.Window
+	}
+	return nil
+}
+
+func (x *IssueRate) GetKeysPerSecond() float64 {
+	if x != nil {
+		return x.KeysPerSecond
+	}
+	return 0
+}
+
+type GetStatsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// unused_keys is a number of free keys that are generated in advance.
+	UnusedKeys int64 `protobuf:"varint,1,opt,name=unused_keys,json=unusedKeys,proto3" json:"unused_keys,omitempty"`
+	// used_keys is a number of used keys that have not expired yet.
+	UsedKeys int64 `protobuf:"varint,2,opt,name=used_keys,json=usedKeys,proto3" json:"used_keys,omitempty"`
+	// collisions is a number of generated keys that turned out to be already used or free.
+	// It's counted by the generator of the replica since its start, so it's zero on replicas that are not the leader.
+	Collisions int64 `protobuf:"varint,3,opt,name=collisions,proto3" json:"collisions,omitempty"`
+	// issue_rates are rates of keys issued by the replica over recent windows.
+	IssueRates []*IssueRate `protobuf:"bytes,4,rep,name=issue_rates,json=issueRates,proto3" json:"issue_rates,omitempty"`
+	// time_until_empty is an estimated time until free keys run out at the current issue rate of the replica
+	// if the generator stops refilling them. Free keys are shared by all replicas, so with N replicas that issue keys
+	// at the same rate the time is overestimated N times. It's not set if the replica issues no keys.
+	TimeUntilEmpty *durationpb.Duration `protobuf:"bytes,5,opt,name=time_until_empty,json=timeUntilEmpty,proto3" json:"time_until_empty,omitempty"`
+}
+
+func (x *GetStatsResponse) Reset() {
+	*x = GetStatsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsResponse) ProtoMessage() {}
+
+func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetStatsResponse) GetUnusedKeys() int64 {
+	if x != nil {
+		return x.UnusedKeys
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetUsedKeys() int64 {
+	if x != nil {
+		return x.UsedKeys
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetCollisions() int64 {
+	if x != nil {
+		return x.Collisions
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetIssueRates() []*IssueRate {
+	if x != nil {
+		return x.IssueRates
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetTimeUntilEmpty() *durationpb.Duration {
+	if x != nil {
+		return x.TimeUntilEmpty
+	}
+	return nil
+}
+
+type StreamKeysRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// pool is a name of the key pool. It's read from the first request only. If it's not set, the default pool is used.
+	Pool string `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"`
+	// window is a maximum number of keys that are sent but not acknowledged. It's read from the first request only,
+	// it must be positive and can't exceed the configured maximum batch size.
+	Window uint32 `protobuf:"varint,2,opt,name=window,proto3" json:"window,omitempty"`
+	// ack is a number of consumed keys in the order they were received.
+	// It can't exceed the number of sent keys that are not acknowledged yet.
+	Ack uint32 `protobuf:"varint,3,opt,name=ack,proto3" json:"ack,omitempty"`
+}
+
+func (x *StreamKeysRequest) Reset() {
+	*x = StreamKeysRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamKeysRequest) ProtoMessage() {}
+
+func (x *StreamKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamKeysRequest.ProtoReflect.Descriptor instead.
+func (*StreamKeysRequest) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *StreamKeysRequest) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+func (x *StreamKeysRequest) GetWindow() uint32 {
+	if x != nil {
+		return x.Window
+	}
+	return 0
+}
+
+func (x *StreamKeysRequest) GetAck() uint32 {
+	if x != nil {
+		return x.Ack
+	}
+	return 0
+}
+
+type StreamKeysResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Keys []*Key `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+}
+
+func (x *StreamKeysResponse) Reset() {
+	*x = StreamKeysResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamKeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamKeysResponse) ProtoMessage() {}
+
+func (x *StreamKeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamKeysResponse.ProtoReflect.Descriptor instead.
+func (*StreamKeysResponse) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *StreamKeysResponse) GetKeys() []*Key {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type ConfirmKeyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Val string `protobuf:"bytes,1,opt,name=val,proto3" json:"val,omitempty"`
+	// expire_time is a time the confirmed key expires at (e.g. expire_time of the generated key).
+	// It must be in the future and within the configured maximum TTL.
+	ExpireTime *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expire_time,json=expireTime,proto3" json:"expire_time,omitempty"`
+	// pool is a name of the key pool. If it's not set, the default pool is used.
+	Pool string `protobuf:"bytes,3,opt,name=pool,proto3" json:"pool,omitempty"`
+}
+
+func (x *ConfirmKeyRequest) Reset() {
+	*x = ConfirmKeyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ConfirmKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfirmKeyRequest) ProtoMessage() {}
+
+func (x *ConfirmKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfirmKeyRequest.ProtoReflect.Descriptor instead.
+func (*ConfirmKeyRequest) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ConfirmKeyRequest) GetVal() string {
+	if x != nil {
+		return x.Val
+	}
+	return ""
+}
+
+func (x *ConfirmKeyRequest) GetExpireTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpireTime
+	}
+	return nil
+}
+
+func (x *ConfirmKeyRequest) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+type ConfirmKeyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key *Key `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+}
+
+func (x *ConfirmKeyResponse) Reset() {
+	*x = ConfirmKeyResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[0]
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Key) String() string {
+func (x *ConfirmKeyResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Key) ProtoMessage() {}
+func (*ConfirmKeyResponse) ProtoMessage() {}
 
-func (x *Key) ProtoReflect() protoreflect.Message {
-	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[0]
+func (x *ConfirmKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -57,48 +1156,45 @@ func (x *Key) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Key.ProtoReflect.Descriptor instead.
-func (*Key) Descriptor() ([]byte, []int) {
-	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{0}
-}
-
-func (x *Key) GetVal() string {
-	if x != nil {
-		return x.Val
-	}
-	return ""
+// Deprecated: Use ConfirmKeyResponse.ProtoReflect.Descriptor instead.
+func (*ConfirmKeyResponse) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{18}
 }
 
-func (x *Key) GetExpireTime() *timestamppb.Timestamp {
+func (x *ConfirmKeyResponse) GetKey() *Key {
 	if x != nil {
-		return x.ExpireTime
+		return x.Key
 	}
 	return nil
 }
 
-type GenerateKeyRequest struct {
+type LookupKeyRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+
+	Val string `protobuf:"bytes,1,opt,name=val,proto3" json:"val,omitempty"`
+	// pool is a name of the key pool. If it's not set, the default pool is used.
+	Pool string `protobuf:"bytes,2,opt,name=pool,proto3" json:"pool,omitempty"`
 }
 
-func (x *GenerateKeyRequest) Reset() {
-	*x = GenerateKeyRequest{}
+func (x *LookupKeyRequest) Reset() {
+	*x = LookupKeyRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[1]
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *GenerateKeyRequest) String() string {
+func (x *LookupKeyRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GenerateKeyRequest) ProtoMessage() {}
+func (*LookupKeyRequest) ProtoMessage() {}
 
-func (x *GenerateKeyRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[1]
+func (x *LookupKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -109,36 +1205,54 @@ func (x *GenerateKeyRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GenerateKeyRequest.ProtoReflect.Descriptor instead.
-func (*GenerateKeyRequest) Descriptor() ([]byte, []int) {
-	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use LookupKeyRequest.ProtoReflect.Descriptor instead.
+func (*LookupKeyRequest) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{19}
 }
 
-type GenerateKeyResponse struct {
+func (x *LookupKeyRequest) GetVal() string {
+	if x != nil {
+		return x.Val
+	}
+	return ""
+}
+
+func (x *LookupKeyRequest) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+type LookupKeyResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
 	Key *Key `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// metadata is empty if the key was generated without it.
+	Metadata *KeyMetadata `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// issue_time is a time the key was issued at. It's not set if the key was issued before issue times were recorded.
+	IssueTime *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=issue_time,json=issueTime,proto3" json:"issue_time,omitempty"`
 }
 
-func (x *GenerateKeyResponse) Reset() {
-	*x = GenerateKeyResponse{}
+func (x *LookupKeyResponse) Reset() {
+	*x = LookupKeyResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[2]
+		mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *GenerateKeyResponse) String() string {
+func (x *LookupKeyResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GenerateKeyResponse) ProtoMessage() {}
+func (*LookupKeyResponse) ProtoMessage() {}
 
-func (x *GenerateKeyResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[2]
+func (x *LookupKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -149,18 +1263,32 @@ func (x *GenerateKeyResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GenerateKeyResponse.ProtoReflect.Descriptor instead.
-func (*GenerateKeyResponse) Descriptor() ([]byte, []int) {
-	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use LookupKeyResponse.ProtoReflect.Descriptor instead.
+func (*LookupKeyResponse) Descriptor() ([]byte, []int) {
+	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *GenerateKeyResponse) GetKey() *Key {
+func (x *LookupKeyResponse) GetKey() *Key {
 	if x != nil {
 		return x.Key
 	}
 	return nil
 }
 
+func (x *LookupKeyResponse) GetMetadata() *KeyMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *LookupKeyResponse) GetIssueTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.IssueTime
+	}
+	return nil
+}
+
 var File_pocketlink_keygen_v1beta1_keygen_service_proto protoreflect.FileDescriptor
 
 var file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDesc = []byte{
@@ -168,7 +1296,9 @@ var file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDesc = []byte{
 	0x67, 0x65, 0x6e, 0x2f, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2f, 0x6b, 0x65, 0x79, 0x67,
 	0x65, 0x6e, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
 	0x12, 0x19, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79,
-	0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f,
+	0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x1a, 0x1e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x75, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f, 0x6f,
 	0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d,
 	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x54, 0x0a, 0x03,
 	0x4b, 0x65, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x76, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
@@ -176,26 +1306,209 @@ var file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDesc = []byte{
 	0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f,
 	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d,
 	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x54, 0x69,
-	0x6d, 0x65, 0x22, 0x14, 0x0a, 0x12, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x4b, 0x65,
-	0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x47, 0x0a, 0x13, 0x47, 0x65, 0x6e, 0x65,
-	0x72, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x30, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70,
-	0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e,
-	0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x4b, 0x65, 0x79, 0x52, 0x03, 0x6b, 0x65,
-	0x79, 0x32, 0x7f, 0x0a, 0x0d, 0x4b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x53, 0x65, 0x72, 0x76, 0x69,
-	0x63, 0x65, 0x12, 0x6e, 0x0a, 0x0b, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x4b, 0x65,
-	0x79, 0x12, 0x2d, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b,
-	0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x47, 0x65,
-	0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x2e, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65,
+	0x6d, 0x65, 0x22, 0x5d, 0x0a, 0x0b, 0x4b, 0x65, 0x79, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74,
+	0x61, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x16,
+	0x0a, 0x06, 0x74, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x74, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f,
+	0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x72, 0x61, 0x63, 0x65, 0x49,
+	0x64, 0x22, 0xcc, 0x01, 0x0a, 0x12, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x4b, 0x65,
+	0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2b, 0x0a, 0x03, 0x74, 0x74, 0x6c, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x03, 0x74, 0x74, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x12, 0x31, 0x0a, 0x14, 0x72, 0x65, 0x71,
+	0x75, 0x69, 0x72, 0x65, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x13, 0x72, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x42, 0x0a, 0x08,
+	0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x26,
+	0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67,
+	0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x4b, 0x65, 0x79, 0x4d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x22, 0x8e, 0x01, 0x0a, 0x13, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x30, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69,
+	0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61,
+	0x31, 0x2e, 0x4b, 0x65, 0x79, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x45, 0x0a, 0x10, 0x63, 0x6f,
+	0x6e, 0x66, 0x69, 0x72, 0x6d, 0x5f, 0x64, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x52, 0x0f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x44, 0x65, 0x61, 0x64, 0x6c, 0x69, 0x6e,
+	0x65, 0x22, 0x3f, 0x0a, 0x13, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x12,
+	0x0a, 0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x6f,
+	0x6f, 0x6c, 0x22, 0xe5, 0x01, 0x0a, 0x14, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x4b,
+	0x65, 0x79, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x32, 0x0a, 0x04, 0x6b,
+	0x65, 0x79, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x6f, 0x63, 0x6b,
+	0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31,
+	0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x4b, 0x65, 0x79, 0x52, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x12,
+	0x4e, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x36, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79,
+	0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x47, 0x65, 0x6e, 0x65,
+	0x72, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22,
+	0x49, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x0a, 0x12, 0x53, 0x54, 0x41,
+	0x54, 0x55, 0x53, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10,
+	0x00, 0x12, 0x13, 0x0a, 0x0f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x43, 0x4f, 0x4d, 0x50,
+	0x4c, 0x45, 0x54, 0x45, 0x10, 0x01, 0x12, 0x12, 0x0a, 0x0e, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53,
+	0x5f, 0x50, 0x41, 0x52, 0x54, 0x49, 0x41, 0x4c, 0x10, 0x02, 0x22, 0x39, 0x0a, 0x11, 0x52, 0x65,
+	0x73, 0x65, 0x72, 0x76, 0x65, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x10, 0x0a, 0x03, 0x76, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x76, 0x61,
+	0x6c, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x22, 0x46, 0x0a, 0x12, 0x52, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65,
+	0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x30, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65,
+	0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62,
+	0x65, 0x74, 0x61, 0x31, 0x2e, 0x4b, 0x65, 0x79, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x22, 0x39, 0x0a,
+	0x11, 0x52, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x76, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x76, 0x61, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x22, 0x14, 0x0a, 0x12, 0x52, 0x65, 0x6c, 0x65,
+	0x61, 0x73, 0x65, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x7c,
+	0x0a, 0x10, 0x45, 0x78, 0x74, 0x65, 0x6e, 0x64, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x76, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x76, 0x61, 0x6c, 0x12, 0x42, 0x0a, 0x0f, 0x6e, 0x65, 0x77, 0x5f, 0x65, 0x78, 0x70, 0x69,
+	0x72, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0d, 0x6e, 0x65, 0x77, 0x45, 0x78,
+	0x70, 0x69, 0x72, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x6f, 0x6c,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x22, 0x45, 0x0a, 0x11,
+	0x45, 0x78, 0x74, 0x65, 0x6e, 0x64, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x30, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e,
+	0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67,
+	0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x4b, 0x65, 0x79, 0x52, 0x03,
+	0x6b, 0x65, 0x79, 0x22, 0x25, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x22, 0x66, 0x0a, 0x09, 0x49, 0x73,
+	0x73, 0x75, 0x65, 0x52, 0x61, 0x74, 0x65, 0x12, 0x31, 0x0a, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f,
+	0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x12, 0x26, 0x0a, 0x0f, 0x6b, 0x65,
+	0x79, 0x73, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x0d, 0x6b, 0x65, 0x79, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f,
+	0x6e, 0x64, 0x22, 0xfc, 0x01, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x75, 0x6e, 0x75, 0x73, 0x65,
+	0x64, 0x5f, 0x6b, 0x65, 0x79, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x75, 0x6e,
+	0x75, 0x73, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x75, 0x73, 0x65, 0x64,
+	0x5f, 0x6b, 0x65, 0x79, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x75, 0x73, 0x65,
+	0x64, 0x4b, 0x65, 0x79, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6c, 0x6c, 0x69, 0x73, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x63, 0x6f, 0x6c, 0x6c, 0x69,
+	0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x45, 0x0a, 0x0b, 0x69, 0x73, 0x73, 0x75, 0x65, 0x5f, 0x72,
+	0x61, 0x74, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x70, 0x6f, 0x63,
+	0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76,
+	0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x49, 0x73, 0x73, 0x75, 0x65, 0x52, 0x61, 0x74, 0x65,
+	0x52, 0x0a, 0x69, 0x73, 0x73, 0x75, 0x65, 0x52, 0x61, 0x74, 0x65, 0x73, 0x12, 0x43, 0x0a, 0x10,
+	0x74, 0x69, 0x6d, 0x65, 0x5f, 0x75, 0x6e, 0x74, 0x69, 0x6c, 0x5f, 0x65, 0x6d, 0x70, 0x74, 0x79,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x0e, 0x74, 0x69, 0x6d, 0x65, 0x55, 0x6e, 0x74, 0x69, 0x6c, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x22, 0x51, 0x0a, 0x11, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4b, 0x65, 0x79, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x12, 0x16, 0x0a, 0x06, 0x77, 0x69,
+	0x6e, 0x64, 0x6f, 0x77, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x77, 0x69, 0x6e, 0x64,
+	0x6f, 0x77, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x63, 0x6b, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x03, 0x61, 0x63, 0x6b, 0x22, 0x48, 0x0a, 0x12, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4b, 0x65,
+	0x79, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x32, 0x0a, 0x04, 0x6b, 0x65,
+	0x79, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65,
+	0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62,
+	0x65, 0x74, 0x61, 0x31, 0x2e, 0x4b, 0x65, 0x79, 0x52, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x22, 0x76,
+	0x0a, 0x11, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x76, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x76, 0x61, 0x6c, 0x12, 0x3b, 0x0a, 0x0b, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x5f,
+	0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x54, 0x69,
+	0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x22, 0x46, 0x0a, 0x12, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72,
+	0x6d, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x30, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x6f, 0x63, 0x6b,
+	0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31,
+	0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x4b, 0x65, 0x79, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x22, 0x38,
+	0x0a, 0x10, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x76, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x76, 0x61, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x70, 0x6f, 0x6f, 0x6c, 0x22, 0xc4, 0x01, 0x0a, 0x11, 0x4c, 0x6f, 0x6f,
+	0x6b, 0x75, 0x70, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x30,
+	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x6f,
+	0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e,
+	0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x4b, 0x65, 0x79, 0x52, 0x03, 0x6b, 0x65, 0x79,
+	0x12, 0x42, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x26, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e,
+	0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x4b,
+	0x65, 0x79, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x12, 0x39, 0x0a, 0x0a, 0x69, 0x73, 0x73, 0x75, 0x65, 0x5f, 0x74, 0x69,
+	0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x69, 0x73, 0x73, 0x75, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x32,
+	0xe5, 0x07, 0x0a, 0x0d, 0x4b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x12, 0x6e, 0x0a, 0x0b, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79,
+	0x12, 0x2d, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65,
 	0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x47, 0x65, 0x6e,
-	0x65, 0x72, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x22, 0x00, 0x42, 0x47, 0x5a, 0x45, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
-	0x2f, 0x64, 0x65, 0x6d, 0x65, 0x65, 0x72, 0x6f, 0x2f, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x2d,
-	0x6c, 0x69, 0x6e, 0x6b, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x67,
-	0x6f, 0x2f, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2f, 0x6b, 0x65, 0x79,
-	0x67, 0x65, 0x6e, 0x2f, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x33,
+	0x65, 0x72, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x2e, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79,
+	0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x47, 0x65, 0x6e, 0x65,
+	0x72, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x00, 0x12, 0x71, 0x0a, 0x0c, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79,
+	0x73, 0x12, 0x2e, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b,
+	0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x47, 0x65,
+	0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x2f, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b,
+	0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x47, 0x65,
+	0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x22, 0x00, 0x12, 0x6b, 0x0a, 0x0a, 0x52, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x4b,
+	0x65, 0x79, 0x12, 0x2c, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e,
+	0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x52,
+	0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x2d, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65,
+	0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x52, 0x65, 0x73,
+	0x65, 0x72, 0x76, 0x65, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x00, 0x12, 0x6b, 0x0a, 0x0a, 0x52, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x4b, 0x65, 0x79, 0x12,
+	0x2c, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79,
+	0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x52, 0x65, 0x6c, 0x65,
+	0x61, 0x73, 0x65, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2d, 0x2e,
+	0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65,
+	0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x52, 0x65, 0x6c, 0x65, 0x61, 0x73,
+	0x65, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x68,
+	0x0a, 0x09, 0x45, 0x78, 0x74, 0x65, 0x6e, 0x64, 0x4b, 0x65, 0x79, 0x12, 0x2b, 0x2e, 0x70, 0x6f,
+	0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e,
+	0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x45, 0x78, 0x74, 0x65, 0x6e, 0x64, 0x4b, 0x65,
+	0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2c, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65,
+	0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62,
+	0x65, 0x74, 0x61, 0x31, 0x2e, 0x45, 0x78, 0x74, 0x65, 0x6e, 0x64, 0x4b, 0x65, 0x79, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x65, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x53,
+	0x74, 0x61, 0x74, 0x73, 0x12, 0x2a, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e,
+	0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31,
+	0x2e, 0x47, 0x65, 0x74, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x2b, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65,
+	0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x47, 0x65, 0x74,
+	0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12,
+	0x6f, 0x0a, 0x0a, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4b, 0x65, 0x79, 0x73, 0x12, 0x2c, 0x2e,
+	0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65,
+	0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x4b, 0x65, 0x79, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2d, 0x2e, 0x70, 0x6f,
+	0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e,
+	0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4b, 0x65,
+	0x79, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01,
+	0x12, 0x6b, 0x0a, 0x0a, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x4b, 0x65, 0x79, 0x12, 0x2c,
+	0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67,
+	0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x72, 0x6d, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2d, 0x2e, 0x70,
+	0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e,
+	0x2e, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d,
+	0x4b, 0x65, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x68, 0x0a,
+	0x09, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x4b, 0x65, 0x79, 0x12, 0x2b, 0x2e, 0x70, 0x6f, 0x63,
+	0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76,
+	0x31, 0x62, 0x65, 0x74, 0x61, 0x31, 0x2e, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x4b, 0x65, 0x79,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2c, 0x2e, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74,
+	0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2e, 0x76, 0x31, 0x62, 0x65,
+	0x74, 0x61, 0x31, 0x2e, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x4b, 0x65, 0x79, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x47, 0x5a, 0x45, 0x67, 0x69, 0x74, 0x68, 0x75,
+	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x64, 0x65, 0x6d, 0x65, 0x65, 0x72, 0x6f, 0x2f, 0x70, 0x6f,
+	0x63, 0x6b, 0x65, 0x74, 0x2d, 0x6c, 0x69, 0x6e, 0x6b, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f,
+	0x67, 0x65, 0x6e, 0x2f, 0x67, 0x6f, 0x2f, 0x70, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x6c, 0x69, 0x6e,
+	0x6b, 0x2f, 0x6b, 0x65, 0x79, 0x67, 0x65, 0x6e, 0x2f, 0x76, 0x31, 0x62, 0x65, 0x74, 0x61, 0x31,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -210,23 +1523,77 @@ func file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescGZIP() []byte {
 	return file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDescData
 }
 
-var file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_pocketlink_keygen_v1beta1_keygen_service_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
 var file_pocketlink_keygen_v1beta1_keygen_service_proto_goTypes = []interface{}{
-	(*Key)(nil),                   // 0: pocketlink.keygen.v1beta1.Key
-	(*GenerateKeyRequest)(nil),    // 1: pocketlink.keygen.v1beta1.GenerateKeyRequest
-	(*GenerateKeyResponse)(nil),   // 2: pocketlink.keygen.v1beta1.GenerateKeyResponse
-	(*timestamppb.Timestamp)(nil), // 3: google.protobuf.Timestamp
+	(GenerateKeysResponse_Status)(0), // 0: pocketlink.keygen.v1beta1.GenerateKeysResponse.Status
+	(*Key)(nil),                      // 1: pocketlink.keygen.v1beta1.Key
+	(*KeyMetadata)(nil),              // 2: pocketlink.keygen.v1beta1.KeyMetadata
+	(*GenerateKeyRequest)(nil),       // 3: pocketlink.keygen.v1beta1.GenerateKeyRequest
+	(*GenerateKeyResponse)(nil),      // 4: pocketlink.keygen.v1beta1.GenerateKeyResponse
+	(*GenerateKeysRequest)(nil),      // 5: pocketlink.keygen.v1beta1.GenerateKeysRequest
+	(*GenerateKeysResponse)(nil),     // 6: pocketlink.keygen.v1beta1.GenerateKeysResponse
+	(*ReserveKeyRequest)(nil),        // 7: pocketlink.keygen.v1beta1.ReserveKeyRequest
+	(*ReserveKeyResponse)(nil),       // 8: pocketlink.keygen.v1beta1.ReserveKeyResponse
+	(*ReleaseKeyRequest)(nil),        // 9: pocketlink.keygen.v1beta1.ReleaseKeyRequest
+	(*ReleaseKeyResponse)(nil),       // 10: pocketlink.keygen.v1beta1.ReleaseKeyResponse
+	(*ExtendKeyRequest)(nil),         // 11: pocketlink.keygen.v1beta1.ExtendKeyRequest
+	(*ExtendKeyResponse)(nil),        // 12: pocketlink.keygen.v1beta1.ExtendKeyResponse
+	(*GetStatsRequest)(nil),          // 13: pocketlink.keygen.v1beta1.GetStatsRequest
+	(*IssueRate)(nil),                // 14: pocketlink.keygen.v1beta1.IssueRate
+	(*GetStatsResponse)(nil),         // 15: pocketlink.keygen.v1beta1.GetStatsResponse
+	(*StreamKeysRequest)(nil),        // 16: pocketlink.keygen.v1beta1.StreamKeysRequest
+	(*StreamKeysResponse)(nil),       // 17: pocketlink.keygen.v1beta1.StreamKeysResponse
+	(*ConfirmKeyRequest)(nil),        // 18: pocketlink.keygen.v1beta1.ConfirmKeyRequest
+	(*ConfirmKeyResponse)(nil),       // 19: pocketlink.keygen.v1beta1.ConfirmKeyResponse
+	(*LookupKeyRequest)(nil),         // 20: pocketlink.keygen.v1beta1.LookupKeyRequest
+	(*LookupKeyResponse)(nil),        // 21: pocketlink.keygen.v1beta1.LookupKeyResponse
+	(*timestamppb.Timestamp)(nil),    // 22: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),      // 23: google.protobuf.Duration
 }
 var file_pocketlink_keygen_v1beta1_keygen_service_proto_depIdxs = []int32{
-	3, // 0: pocketlink.keygen.v1beta1.Key.expire_time:type_name -> google.protobuf.Timestamp
-	0, // 1: pocketlink.keygen.v1beta1.GenerateKeyResponse.key:type_name -> pocketlink.keygen.v1beta1.Key
-	1, // 2: pocketlink.keygen.v1beta1.KeygenService.GenerateKey:input_type -> pocketlink.keygen.v1beta1.GenerateKeyRequest
-	2, // 3: pocketlink.keygen.v1beta1.KeygenService.GenerateKey:output_type -> pocketlink.keygen.v1beta1.GenerateKeyResponse
-	3, // [3:4] is the sub-list for method output_type
-	2, // [2:3] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	22, // 0: pocketlink.keygen.v1beta1.Key.expire_time:type_name -> google.protobuf.Timestamp
+	23, // 1: pocketlink.keygen.v1beta1.GenerateKeyRequest.ttl:type_name -> google.protobuf.Duration
+	2,  // 2: pocketlink.keygen.v1beta1.GenerateKeyRequest.metadata:type_name -> pocketlink.keygen.v1beta1.KeyMetadata
+	1,  // 3: pocketlink.keygen.v1beta1.GenerateKeyResponse.key:type_name -> pocketlink.keygen.v1beta1.Key
+	22, // 4: pocketlink.keygen.v1beta1.GenerateKeyResponse.confirm_deadline:type_name -> google.protobuf.Timestamp
+	1,  // 5: pocketlink.keygen.v1beta1.GenerateKeysResponse.keys:type_name -> pocketlink.keygen.v1beta1.Key
+	0,  // 6: pocketlink.keygen.v1beta1.GenerateKeysResponse.status:type_name -> pocketlink.keygen.v1beta1.GenerateKeysResponse.Status
+	1,  // 7: pocketlink.keygen.v1beta1.ReserveKeyResponse.key:type_name -> pocketlink.keygen.v1beta1.Key
+	22, // 8: pocketlink.keygen.v1beta1.ExtendKeyRequest.new_expire_time:type_name -> google.protobuf.Timestamp
+	1,  // 9: pocketlink.keygen.v1beta1.ExtendKeyResponse.key:type_name -> pocketlink.keygen.v1beta1.Key
+	23, // 10: pocketlink.keygen.v1beta1.IssueRate.window:type_name -> google.protobuf.Duration
+	14, // 11: pocketlink.keygen.v1beta1.GetStatsResponse.issue_rates:type_name -> pocketlink.keygen.v1beta1.IssueRate
+	23, // 12: pocketlink.keygen.v1beta1.GetStatsResponse.time_until_empty:type_name -> google.protobuf.Duration
+	1,  // 13: pocketlink.keygen.v1beta1.StreamKeysResponse.keys:type_name -> pocketlink.keygen.v1beta1.Key
+	22, // 14: pocketlink.keygen.v1beta1.ConfirmKeyRequest.expire_time:type_name -> google.protobuf.Timestamp
+	1,  // 15: pocketlink.keygen.v1beta1.ConfirmKeyResponse.key:type_name -> pocketlink.keygen.v1beta1.Key
+	1,  // 16: pocketlink.keygen.v1beta1.LookupKeyResponse.key:type_name -> pocketlink.keygen.v1beta1.Key
+	2,  // 17: pocketlink.keygen.v1beta1.LookupKeyResponse.metadata:type_name -> pocketlink.keygen.v1beta1.KeyMetadata
+	22, // 18: pocketlink.keygen.v1beta1.LookupKeyResponse.issue_time:type_name -> google.protobuf.Timestamp
+	3,  // 19: pocketlink.keygen.v1beta1.KeygenService.GenerateKey:input_type -> pocketlink.keygen.v1beta1.GenerateKeyRequest
+	5,  // 20: pocketlink.keygen.v1beta1.KeygenService.GenerateKeys:input_type -> pocketlink.keygen.v1beta1.GenerateKeysRequest
+	7,  // 21: pocketlink.keygen.v1beta1.KeygenService.ReserveKey:input_type -> pocketlink.keygen.v1beta1.ReserveKeyRequest
+	9,  // 22: pocketlink.keygen.v1beta1.KeygenService.ReleaseKey:input_type -> pocketlink.keygen.v1beta1.ReleaseKeyRequest
+	11, // 23: pocketlink.keygen.v1beta1.KeygenService.ExtendKey:input_type -> pocketlink.keygen.v1beta1.ExtendKeyRequest
+	13, // 24: pocketlink.keygen.v1beta1.KeygenService.GetStats:input_type -> pocketlink.keygen.v1beta1.GetStatsRequest
+	16, // 25: pocketlink.keygen.v1beta1.KeygenService.StreamKeys:input_type -> pocketlink.keygen.v1beta1.StreamKeysRequest
+	18, // 26: pocketlink.keygen.v1beta1.KeygenService.ConfirmKey:input_type -> pocketlink.keygen.v1beta1.ConfirmKeyRequest
+	20, // 27: pocketlink.keygen.v1beta1.KeygenService.LookupKey:input_type -> pocketlink.keygen.v1beta1.LookupKeyRequest
+	4,  // 28: pocketlink.keygen.v1beta1.KeygenService.GenerateKey:output_type -> pocketlink.keygen.v1beta1.GenerateKeyResponse
+	6,  // 29: pocketlink.keygen.v1beta1.KeygenService.GenerateKeys:output_type -> pocketlink.keygen.v1beta1.GenerateKeysResponse
+	8,  // 30: pocketlink.keygen.v1beta1.KeygenService.ReserveKey:output_type -> pocketlink.keygen.v1beta1.ReserveKeyResponse
+	10, // 31: pocketlink.keygen.v1beta1.KeygenService.ReleaseKey:output_type -> pocketlink.keygen.v1beta1.ReleaseKeyResponse
+	12, // 32: pocketlink.keygen.v1beta1.KeygenService.ExtendKey:output_type -> pocketlink.keygen.v1beta1.ExtendKeyResponse
+	15, // 33: pocketlink.keygen.v1beta1.KeygenService.GetStats:output_type -> pocketlink.keygen.v1beta1.GetStatsResponse
+	17, // 34: pocketlink.keygen.v1beta1.KeygenService.StreamKeys:output_type -> pocketlink.keygen.v1beta1.StreamKeysResponse
+	19, // 35: pocketlink.keygen.v1beta1.KeygenService.ConfirmKey:output_type -> pocketlink.keygen.v1beta1.ConfirmKeyResponse
+	21, // 36: pocketlink.keygen.v1beta1.KeygenService.LookupKey:output_type -> pocketlink.keygen.v1beta1.LookupKeyResponse
+	28, // [28:37] is the sub-list for method output_type
+	19, // [19:28] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_pocketlink_keygen_v1beta1_keygen_service_proto_init() }
@@ -248,7 +1615,7 @@ func file_pocketlink_keygen_v1beta1_keygen_service_proto_init() {
 			}
 		}
 		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GenerateKeyRequest); i {
+			switch v := v.(*KeyMetadata); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -260,6 +1627,18 @@ func file_pocketlink_keygen_v1beta1_keygen_service_proto_init() {
 			}
 		}
 		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GenerateKeyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GenerateKeyResponse); i {
 			case 0:
 				return &v.state
@@ -271,19 +1650,224 @@ func file_pocketlink_keygen_v1beta1_keygen_service_proto_init() {
 				return nil
 			}
 		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GenerateKeysRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GenerateKeysResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReserveKeyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReserveKeyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReleaseKeyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReleaseKeyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExtendKeyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExtendKeyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetStatsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IssueRate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetStatsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamKeysRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StreamKeysResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ConfirmKeyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ConfirmKeyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LookupKeyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LookupKeyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_pocketlink_keygen_v1beta1_keygen_service_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   3,
+			NumEnums:      1,
+			NumMessages:   21,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_pocketlink_keygen_v1beta1_keygen_service_proto_goTypes,
 		DependencyIndexes: file_pocketlink_keygen_v1beta1_keygen_service_proto_depIdxs,
+		EnumInfos:         file_pocketlink_keygen_v1beta1_keygen_service_proto_enumTypes,
 		MessageInfos:      file_pocketlink_keygen_v1beta1_keygen_service_proto_msgTypes,
 	}.Build()
 	File_pocketlink_keygen_v1beta1_keygen_service_proto = out.File
@@ -19,6 +19,35 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type KeygenServiceClient interface {
 	GenerateKey(ctx context.Context, in *GenerateKeyRequest, opts ...grpc.CallOption) (*GenerateKeyResponse, error)
+	// GenerateKeys returns a batch of keys.
+	// If there are not enough free keys, the batch is partial.
+	GenerateKeys(ctx context.Context, in *GenerateKeysRequest, opts ...grpc.CallOption) (*GenerateKeysResponse, error)
+	// ReserveKey reserves the requested value (e.g. vanity alias) as used key.
+	// It returns ALREADY_EXISTS if the value is already used.
+	ReserveKey(ctx context.Context, in *ReserveKeyRequest, opts ...grpc.CallOption) (*ReserveKeyResponse, error)
+	// ReleaseKey deletes the key from used keys before its expiration (e.g. the link behind the key was not saved).
+	// It returns NOT_FOUND if the key is not used.
+	ReleaseKey(ctx context.Context, in *ReleaseKeyRequest, opts ...grpc.CallOption) (*ReleaseKeyResponse, error)
+	// ExtendKey sets a new expiration time of the used key.
+	// It returns NOT_FOUND if the key is not used (e.g. it has already expired).
+	ExtendKey(ctx context.Context, in *ExtendKeyRequest, opts ...grpc.CallOption) (*ExtendKeyResponse, error)
+	// GetStats returns statistics of the key pool: how many free keys are left, how fast the replica issues them
+	// and when they run out at the current rate of the replica.
+	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
+	// StreamKeys keeps sending keys while the client consumes them.
+	// The first request opens the feed with the window - a maximum number of keys that are sent but not acknowledged.
+	// Every next request acknowledges consumed keys, so the same number of new keys is sent (credit-based flow control).
+	// If free keys run out, the rest of the window is sent when they are generated.
+	// Keys that are not acknowledged when the stream ends go back to free keys, so a key must be acknowledged before
+	// it's used. The client acknowledges keys with messages of its own stream, so the RPC is bidirectional.
+	StreamKeys(ctx context.Context, opts ...grpc.CallOption) (KeygenService_StreamKeysClient, error)
+	// ConfirmKey confirms the key generated with require_confirmation, so it stays used until the expiration time.
+	// If the key isn't confirmed in time, it's taken again by the replica that holds it unless somebody else uses it -
+	// then ALREADY_EXISTS is returned. NOT_FOUND is returned if the key isn't held (e.g. it's returned to free keys).
+	ConfirmKey(ctx context.Context, in *ConfirmKeyRequest, opts ...grpc.CallOption) (*ConfirmKeyResponse, error)
+	// LookupKey returns the used key with metadata of the caller it was generated for and the time it was issued at.
+	// It returns NOT_FOUND if the key is not used (e.g. it has already expired).
+	LookupKey(ctx context.Context, in *LookupKeyRequest, opts ...grpc.CallOption) (*LookupKeyResponse, error)
 }
 
 type keygenServiceClient struct {
@@ -38,11 +67,134 @@ func (c *keygenServiceClient) GenerateKey(ctx context.Context, in *GenerateKeyRe
 	return out, nil
 }
 
+func (c *keygenServiceClient) GenerateKeys(ctx context.Context, in *GenerateKeysRequest, opts ...grpc.CallOption) (*GenerateKeysResponse, error) {
+	out := new(GenerateKeysResponse)
+	err := c.cc.Invoke(ctx, "/pocketlink.keygen.v1beta1.KeygenService/GenerateKeys", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keygenServiceClient) ReserveKey(ctx context.Context, in *ReserveKeyRequest, opts ...grpc.CallOption) (*ReserveKeyResponse, error) {
+	out := new(ReserveKeyResponse)
+	err := c.cc.Invoke(ctx, "/pocketlink.keygen.v1beta1.KeygenService/ReserveKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keygenServiceClient) ReleaseKey(ctx context.Context, in *ReleaseKeyRequest, opts ...grpc.CallOption) (*ReleaseKeyResponse, error) {
+	out := new(ReleaseKeyResponse)
+	err := c.cc.Invoke(ctx, "/pocketlink.keygen.v1beta1.KeygenService/ReleaseKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keygenServiceClient) ExtendKey(ctx context.Context, in *ExtendKeyRequest, opts ...grpc.CallOption) (*ExtendKeyResponse, error) {
+	out := new(ExtendKeyResponse)
+	err := c.cc.Invoke(ctx, "/pocketlink.keygen.v1beta1.KeygenService/ExtendKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keygenServiceClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error) {
+	out := new(GetStatsResponse)
+	err := c.cc.Invoke(ctx, "/pocketlink.keygen.v1beta1.KeygenService/GetStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keygenServiceClient) StreamKeys(ctx context.Context, opts ...grpc.CallOption) (KeygenService_StreamKeysClient, error) {
+	stream, err := c.cc.NewStream(ctx, &KeygenService_ServiceDesc.Streams[0], "/pocketlink.keygen.v1beta1.KeygenService/StreamKeys", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &keygenServiceStreamKeysClient{stream}
+	return x, nil
+}
+
+type KeygenService_StreamKeysClient interface {
+	Send(*StreamKeysRequest) error
+	Recv() (*StreamKeysResponse, error)
+	grpc.ClientStream
+}
+
+type keygenServiceStreamKeysClient struct {
+	grpc.ClientStream
+}
+
+func (x *keygenServiceStreamKeysClient) Send(m *StreamKeysRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *keygenServiceStreamKeysClient) Recv() (*StreamKeysResponse, error) {
+	m := new(StreamKeysResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *keygenServiceClient) ConfirmKey(ctx context.Context, in *ConfirmKeyRequest, opts ...grpc.CallOption) (*ConfirmKeyResponse, error) {
+	out := new(ConfirmKeyResponse)
+	err := c.cc.Invoke(ctx, "/pocketlink.keygen.v1beta1.KeygenService/ConfirmKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keygenServiceClient) LookupKey(ctx context.Context, in *LookupKeyRequest, opts ...grpc.CallOption) (*LookupKeyResponse, error) {
+	out := new(LookupKeyResponse)
+	err := c.cc.Invoke(ctx, "/pocketlink.keygen.v1beta1.KeygenService/LookupKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KeygenServiceServer is the server API for KeygenService service.
 // All implementations must embed UnimplementedKeygenServiceServer
 // for forward compatibility
 type KeygenServiceServer interface {
 	GenerateKey(context.Context, *GenerateKeyRequest) (*GenerateKeyResponse, error)
+	// GenerateKeys returns a batch of keys.
+	// If there are not enough free keys, the batch is partial.
+	GenerateKeys(context.Context, *GenerateKeysRequest) (*GenerateKeysResponse, error)
+	// ReserveKey reserves the requested value (e.g. vanity alias) as used key.
+	// It returns ALREADY_EXISTS if the value is already used.
+	ReserveKey(context.Context, *ReserveKeyRequest) (*ReserveKeyResponse, error)
+	// ReleaseKey deletes the key from used keys before its expiration (e.g. the link behind the key was not saved).
+	// It returns NOT_FOUND if the key is not used.
+	ReleaseKey(context.Context, *ReleaseKeyRequest) (*ReleaseKeyResponse, error)
+	// ExtendKey sets a new expiration time of the used key.
+	// It returns NOT_FOUND if the key is not used (e.g. it has already expired).
+	ExtendKey(context.Context, *ExtendKeyRequest) (*ExtendKeyResponse, error)
+	// GetStats returns statistics of the key pool: how many free keys are left, how fast the replica issues them
+	// and when they run out at the current rate of the replica.
+	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
+	// StreamKeys keeps sending keys while the client consumes them.
+	// The first request opens the feed with the window - a maximum number of keys that are sent but not acknowledged.
+	// Every next request acknowledges consumed keys, so the same number of new keys is sent (credit-based flow control).
+	// If free keys run out, the rest of the window is sent when they are generated.
+	// Keys that are not acknowledged when the stream ends go back to free keys, so a key must be acknowledged before
+	// it's used. The client acknowledges keys with messages of its own stream, so the RPC is bidirectional.
+	StreamKeys(KeygenService_StreamKeysServer) error
+	// ConfirmKey confirms the key generated with require_confirmation, so it stays used until the expiration time.
+	// If the key isn't confirmed in time, it's taken again by the replica that holds it unless somebody else uses it -
+	// then ALREADY_EXISTS is returned. NOT_FOUND is returned if the key isn't held (e.g. it's returned to free keys).
+	ConfirmKey(context.Context, *ConfirmKeyRequest) (*ConfirmKeyResponse, error)
+	// LookupKey returns the used key with metadata of the caller it was generated for and the time it was issued at.
+	// It returns NOT_FOUND if the key is not used (e.g. it has already expired).
+	LookupKey(context.Context, *LookupKeyRequest) (*LookupKeyResponse, error)
 	mustEmbedUnimplementedKeygenServiceServer()
 }
 
@@ -53,6 +205,30 @@ type UnimplementedKeygenServiceServer struct {
 func (UnimplementedKeygenServiceServer) GenerateKey(context.Context, *GenerateKeyRequest) (*GenerateKeyResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GenerateKey not implemented")
 }
+func (UnimplementedKeygenServiceServer) GenerateKeys(context.Context, *GenerateKeysRequest) (*GenerateKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GenerateKeys not implemented")
+}
+func (UnimplementedKeygenServiceServer) ReserveKey(context.Context, *ReserveKeyRequest) (*ReserveKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReserveKey not implemented")
+}
+func (UnimplementedKeygenServiceServer) ReleaseKey(context.Context, *ReleaseKeyRequest) (*ReleaseKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseKey not implemented")
+}
+func (UnimplementedKeygenServiceServer) ExtendKey(context.Context, *ExtendKeyRequest) (*ExtendKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExtendKey not implemented")
+}
+func (UnimplementedKeygenServiceServer) GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedKeygenServiceServer) StreamKeys(KeygenService_StreamKeysServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamKeys not implemented")
+}
+func (UnimplementedKeygenServiceServer) ConfirmKey(context.Context, *ConfirmKeyRequest) (*ConfirmKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmKey not implemented")
+}
+func (UnimplementedKeygenServiceServer) LookupKey(context.Context, *LookupKeyRequest) (*LookupKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupKey not implemented")
+}
 func (UnimplementedKeygenServiceServer) mustEmbedUnimplementedKeygenServiceServer() {}
 
 // UnsafeKeygenServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -84,6 +260,158 @@ func _KeygenService_GenerateKey_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KeygenService_GenerateKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeygenServiceServer).GenerateKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pocketlink.keygen.v1beta1.KeygenService/GenerateKeys",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeygenServiceServer).GenerateKeys(ctx, req.(*GenerateKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeygenService_ReserveKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReserveKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeygenServiceServer).ReserveKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pocketlink.keygen.v1beta1.KeygenService/ReserveKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeygenServiceServer).ReserveKey(ctx, req.(*ReserveKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeygenService_ReleaseKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeygenServiceServer).ReleaseKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pocketlink.keygen.v1beta1.KeygenService/ReleaseKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeygenServiceServer).ReleaseKey(ctx, req.(*ReleaseKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeygenService_ExtendKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExtendKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeygenServiceServer).ExtendKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pocketlink.keygen.v1beta1.KeygenService/ExtendKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeygenServiceServer).ExtendKey(ctx, req.(*ExtendKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeygenService_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeygenServiceServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pocketlink.keygen.v1beta1.KeygenService/GetStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeygenServiceServer).GetStats(ctx, req.(*GetStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeygenService_StreamKeys_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(KeygenServiceServer).StreamKeys(&keygenServiceStreamKeysServer{stream})
+}
+
+type KeygenService_StreamKeysServer interface {
+	Send(*StreamKeysResponse) error
+	Recv() (*StreamKeysRequest, error)
+	grpc.ServerStream
+}
+
+type keygenServiceStreamKeysServer struct {
+	grpc.ServerStream
+}
+
+func (x *keygenServiceStreamKeysServer) Send(m *StreamKeysResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *keygenServiceStreamKeysServer) Recv() (*StreamKeysRequest, error) {
+	m := new(StreamKeysRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _KeygenService_ConfirmKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeygenServiceServer).ConfirmKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pocketlink.keygen.v1beta1.KeygenService/ConfirmKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeygenServiceServer).ConfirmKey(ctx, req.(*ConfirmKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeygenService_LookupKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeygenServiceServer).LookupKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pocketlink.keygen.v1beta1.KeygenService/LookupKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeygenServiceServer).LookupKey(ctx, req.(*LookupKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // KeygenService_ServiceDesc is the grpc.ServiceDesc for KeygenService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -95,7 +423,42 @@ var KeygenService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GenerateKey",
 			Handler:    _KeygenService_GenerateKey_Handler,
 		},
+		{
+			MethodName: "GenerateKeys",
+			Handler:    _KeygenService_GenerateKeys_Handler,
+		},
+		{
+			MethodName: "ReserveKey",
+			Handler:    _KeygenService_ReserveKey_Handler,
+		},
+		{
+			MethodName: "ReleaseKey",
+			Handler:    _KeygenService_ReleaseKey_Handler,
+		},
+		{
+			MethodName: "ExtendKey",
+			Handler:    _KeygenService_ExtendKey_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _KeygenService_GetStats_Handler,
+		},
+		{
+			MethodName: "ConfirmKey",
+			Handler:    _KeygenService_ConfirmKey_Handler,
+		},
+		{
+			MethodName: "LookupKey",
+			Handler:    _KeygenService_LookupKey_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamKeys",
+			Handler:       _KeygenService_StreamKeys_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "pocketlink/keygen/v1beta1/keygen_service.proto",
 }
@@ -2,19 +2,47 @@ package main
 
 import (
 	"github.com/demeero/bricks/configbrick"
+	"github.com/redis/go-redis/v9"
 )
 
 type config struct {
 	Profiler configbrick.PyroscopeProfiler `json:"profiler"`
 	configbrick.AppMeta
-	Links    linksClient       `json:"links"`
-	OTEL     configbrick.OTEL  `json:"otel"`
-	RedisLRU configbrick.Redis `json:"redis_lru" split_words:"true"`
-	Log      configbrick.Log   `json:"log"`
-	HTTP     configbrick.HTTP  `json:"http"`
+	Links    linksClient      `json:"links"`
+	OTEL     configbrick.OTEL `json:"otel"`
+	RedisLRU redisLRU         `json:"redis_lru" split_words:"true"`
+	Log      configbrick.Log  `json:"log"`
+	HTTP     configbrick.HTTP `json:"http"`
 }
 
 type linksClient struct {
 	// Addr is a target address for Keygen GRPC server (e.g. localhost:8081).
 	Addr string `required:"true" json:"addr"`
 }
+
+// redisLRU is a configuration of Redis with cached links. It's a single instance at Addr by default,
+// Redis Cluster if Addrs has several nodes and a master monitored by Sentinels at Addrs if MasterName is set.
+// Redis Cluster has a single DB, so DB is ignored.
+type redisLRU struct {
+	configbrick.Redis
+	// MasterName is a name of the master monitored by Sentinels.
+	MasterName string `split_words:"true" json:"master_name"`
+	// SentinelPassword is a password of Sentinels.
+	SentinelPassword string `split_words:"true" json:"-"`
+	// Addrs are addresses of Redis Cluster nodes or Sentinels.
+	Addrs []string `json:"addrs"`
+}
+
+func (r redisLRU) options() *redis.UniversalOptions {
+	addrs := r.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{r.Addr}
+	}
+	return &redis.UniversalOptions{
+		Addrs:            addrs,
+		DB:               r.DB,
+		Password:         r.Password,
+		MasterName:       r.MasterName,
+		SentinelPassword: r.SentinelPassword,
+	}
+}
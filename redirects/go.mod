@@ -74,3 +74,6 @@ require (
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20231120223509-83a465c0220f // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+// the generated code of the repository is used, so the services are built against the current proto without go.work
+replace github.com/demeero/pocket-link/proto/gen/go => ../proto/gen/go
@@ -84,7 +84,7 @@ func main() {
 		log.Fatalf("failed create grpc links connection: %s", err)
 	}
 
-	client := redis.NewClient(&redis.Options{Addr: cfg.RedisLRU.Addr, DB: cfg.RedisLRU.DB, Password: cfg.RedisLRU.Password})
+	client := redis.NewUniversalClient(cfg.RedisLRU.options())
 	if err := redisotel.InstrumentTracing(client); err != nil {
 		slog.Error("failed instrument redis client with tracing", slog.Any("err", err))
 	}